@@ -0,0 +1,100 @@
+package main
+
+/**
+ * govarnam - An Indian language transliteration library
+ * Copyright Subin Siby <mail at subinsb (.) com>, 2021
+ * Licensed under AGPL-3.0-only. See LICENSE.txt
+ */
+
+/* Tokenizer suggestions beyond the limit, see govarnam.TokenizerCursor */
+
+/*
+#include "c-shared.h"
+#include "c-shared-varray.h"
+#include "stdlib.h"
+*/
+import "C"
+import (
+	"sync"
+	"unsafe"
+
+	"github.com/varnamproject/govarnam/govarnam"
+)
+
+type tokenizerCursorHandle struct {
+	cursor         *govarnam.TokenizerCursor
+	varnamHandleID C.int
+}
+
+var tokenizerCursorHandles = map[C.int]*tokenizerCursorHandle{}
+var tokenizerCursorHandlesMapMutex = sync.RWMutex{}
+
+// IDs are never reused, even after varnam_tokenizer_cursor_close()
+var nextTokenizerCursorHandleID C.int
+
+func getTokenizerCursorHandle(id C.int) (*tokenizerCursorHandle, bool) {
+	tokenizerCursorHandlesMapMutex.RLock()
+	defer tokenizerCursorHandlesMapMutex.RUnlock()
+
+	handle, ok := tokenizerCursorHandles[id]
+	return handle, ok
+}
+
+// Start making tokenizer suggestions of word. Cancel with varnam_cancel(id)
+//export varnam_tokenizer_cursor_new
+func varnam_tokenizer_cursor_new(varnamHandleID C.int, id C.int, word *C.char, cursorID *C.int) C.int {
+	ctx, cancel := makeContext(id)
+	defer cancel()
+
+	handle := getVarnamHandle(varnamHandleID)
+
+	cursor, err := handle.varnam.NewTokenizerCursor(ctx, C.GoString(word))
+	if err != nil {
+		return handle.checkError(err)
+	}
+
+	tokenizerCursorHandlesMapMutex.Lock()
+	defer tokenizerCursorHandlesMapMutex.Unlock()
+
+	*cursorID = nextTokenizerCursorHandleID
+	nextTokenizerCursorHandleID++
+
+	tokenizerCursorHandles[*cursorID] = &tokenizerCursorHandle{cursor, varnamHandleID}
+
+	return C.VARNAM_SUCCESS
+}
+
+// Get upto n suggestions after the ones cursor gave before.
+// An empty array means there are no more.
+//export varnam_next_tokenizer_suggestions
+func varnam_next_tokenizer_suggestions(cursorID C.int, n C.int, resultPointer **C.varray) C.int {
+	handle, ok := getTokenizerCursorHandle(cursorID)
+	if !ok {
+		return C.VARNAM_MISUSE
+	}
+
+	sugs := getVarnamHandle(handle.varnamHandleID).varnam.NextTokenizerSuggestions(handle.cursor, int(n))
+
+	cResult := C.varray_init()
+	for _, sug := range sugs {
+		cSug := unsafe.Pointer(goSuggestionToCSuggestion(sug))
+		C.varray_push(cResult, cSug)
+	}
+	*resultPointer = cResult
+
+	return C.VARNAM_SUCCESS
+}
+
+//export varnam_tokenizer_cursor_close
+func varnam_tokenizer_cursor_close(cursorID C.int) C.int {
+	tokenizerCursorHandlesMapMutex.Lock()
+	defer tokenizerCursorHandlesMapMutex.Unlock()
+
+	if _, ok := tokenizerCursorHandles[cursorID]; !ok {
+		return C.VARNAM_MISUSE
+	}
+
+	delete(tokenizerCursorHandles, cursorID)
+
+	return C.VARNAM_SUCCESS
+}
@@ -10,7 +10,5 @@ import "C"
 //export varnam_reindex_dictionary
 func varnam_reindex_dictionary(varnamHandleID C.int) C.int {
 	handle := getVarnamHandle(varnamHandleID)
-	handle.err = handle.varnam.ReIndexDictionary()
-
-	return checkError(handle.err)
+	return handle.checkError(handle.varnam.ReIndexDictionary())
 }
@@ -0,0 +1,178 @@
+package main
+
+/**
+ * govarnam - An Indian language transliteration library
+ * Copyright Subin Siby <mail at subinsb (.) com>, 2021
+ * Licensed under AGPL-3.0-only. See LICENSE.txt
+ */
+
+/* Input method composition sessions, see govarnam.Session */
+
+/*
+#include "c-shared.h"
+#include "c-shared-varray.h"
+#include "stdlib.h"
+*/
+import "C"
+import (
+	"sync"
+	"unsafe"
+
+	"github.com/varnamproject/govarnam/govarnam"
+)
+
+type sessionHandle struct {
+	session        *govarnam.Session
+	varnamHandleID C.int
+}
+
+var sessionHandles = map[C.int]*sessionHandle{}
+var sessionHandlesMapMutex = sync.RWMutex{}
+
+// IDs are never reused, even after varnam_session_close()
+var nextSessionHandleID C.int
+
+func getSessionHandle(id C.int) (*sessionHandle, bool) {
+	sessionHandlesMapMutex.RLock()
+	defer sessionHandlesMapMutex.RUnlock()
+
+	handle, ok := sessionHandles[id]
+	return handle, ok
+}
+
+// Remember err as the last error of the varnam instance
+func (handle *sessionHandle) checkError(err error) C.int {
+	if err != nil {
+		getVarnamHandle(handle.varnamHandleID).setError(err)
+	}
+	return checkError(err)
+}
+
+//export varnam_session_new
+func varnam_session_new(varnamHandleID C.int, sessionID *C.int) C.int {
+	handle := getVarnamHandle(varnamHandleID)
+
+	sessionHandlesMapMutex.Lock()
+	defer sessionHandlesMapMutex.Unlock()
+
+	*sessionID = nextSessionHandleID
+	nextSessionHandleID++
+
+	sessionHandles[*sessionID] = &sessionHandle{handle.varnam.NewSession(), varnamHandleID}
+
+	return C.VARNAM_SUCCESS
+}
+
+// Append text typed to session input. Cancel with varnam_cancel(id)
+//export varnam_session_append
+func varnam_session_append(sessionID C.int, id C.int, text *C.char) C.int {
+	handle, ok := getSessionHandle(sessionID)
+	if !ok {
+		return C.VARNAM_MISUSE
+	}
+
+	ctx, cancel := makeContext(id)
+	defer cancel()
+
+	return handle.checkError(handle.session.Append(ctx, C.GoString(text)))
+}
+
+// Remove last conjunct from session input. Cancel with varnam_cancel(id)
+//export varnam_session_backspace
+func varnam_session_backspace(sessionID C.int, id C.int) C.int {
+	handle, ok := getSessionHandle(sessionID)
+	if !ok {
+		return C.VARNAM_MISUSE
+	}
+
+	ctx, cancel := makeContext(id)
+	defer cancel()
+
+	return handle.checkError(handle.session.Backspace(ctx))
+}
+
+// The returned string should be freed by caller
+//export varnam_session_input
+func varnam_session_input(sessionID C.int, input **C.char) C.int {
+	handle, ok := getSessionHandle(sessionID)
+	if !ok {
+		return C.VARNAM_MISUSE
+	}
+
+	*input = C.CString(handle.session.Input())
+
+	return C.VARNAM_SUCCESS
+}
+
+// The returned string should be freed by caller
+//export varnam_session_preedit
+func varnam_session_preedit(sessionID C.int, preedit **C.char) C.int {
+	handle, ok := getSessionHandle(sessionID)
+	if !ok {
+		return C.VARNAM_MISUSE
+	}
+
+	*preedit = C.CString(handle.session.Preedit())
+
+	return C.VARNAM_SUCCESS
+}
+
+//export varnam_session_candidates
+func varnam_session_candidates(sessionID C.int, resultPointer **C.varray) C.int {
+	handle, ok := getSessionHandle(sessionID)
+	if !ok {
+		return C.VARNAM_MISUSE
+	}
+
+	cResult := C.varray_init()
+	for _, sug := range handle.session.Candidates() {
+		cSug := unsafe.Pointer(goSuggestionToCSuggestion(sug))
+		C.varray_push(cResult, cSug)
+	}
+	*resultPointer = cResult
+
+	return C.VARNAM_SUCCESS
+}
+
+// Commit candidate at index. The committed word is set in
+// word even if learning it failed, it should be freed by caller.
+//export varnam_session_commit
+func varnam_session_commit(sessionID C.int, index C.int, word **C.char) C.int {
+	handle, ok := getSessionHandle(sessionID)
+	if !ok {
+		return C.VARNAM_MISUSE
+	}
+
+	goWord, err := handle.session.Commit(int(index))
+	if goWord != "" {
+		*word = C.CString(goWord)
+	}
+
+	return handle.checkError(err)
+}
+
+//export varnam_session_reset
+func varnam_session_reset(sessionID C.int) C.int {
+	handle, ok := getSessionHandle(sessionID)
+	if !ok {
+		return C.VARNAM_MISUSE
+	}
+
+	handle.session.Reset()
+
+	return C.VARNAM_SUCCESS
+}
+
+//export varnam_session_close
+func varnam_session_close(sessionID C.int) C.int {
+	sessionHandlesMapMutex.Lock()
+	defer sessionHandlesMapMutex.Unlock()
+
+	if _, ok := sessionHandles[sessionID]; !ok {
+		return C.VARNAM_MISUSE
+	}
+
+	delete(sessionHandles, sessionID)
+
+	return C.VARNAM_SUCCESS
+}
@@ -5,7 +5,75 @@ package main
 */
 import "C"
 
-import "github.com/varnamproject/govarnam/govarnam"
+import (
+	"unsafe"
+
+	"github.com/varnamproject/govarnam/govarnam"
+)
+
+func goSuggestionToCSuggestion(sug govarnam.Suggestion) *C.struct_Suggestion_t {
+	return C.makeSuggestion(
+		C.CString(sug.Word),
+		C.int(sug.Weight),
+		C.int(sug.LearnedOn),
+		C.int(sug.Source),
+		C.int(sug.MatchedLength),
+		C.double(sug.Score),
+	)
+}
+
+func cSuggestionToGoSuggestion(cSug *C.struct_Suggestion_t) govarnam.Suggestion {
+	return govarnam.Suggestion{
+		Word:          C.GoString(cSug.Word),
+		Weight:        int(cSug.Weight),
+		LearnedOn:     int(cSug.LearnedOn),
+		Source:        int(cSug.Source),
+		MatchedLength: int(cSug.MatchedLength),
+		Score:         float64(cSug.Score),
+	}
+}
+
+func goLatticeToCLattice(lattice []govarnam.LatticeToken) *C.varray {
+	cLattice := C.varray_init()
+	for _, token := range lattice {
+		cAlternatives := C.varray_init()
+		for _, alternative := range token.Alternatives {
+			cAlternative := C.makeLatticeAlternative(C.CString(alternative.Value), C.int(alternative.Weight))
+			C.varray_push(cAlternatives, unsafe.Pointer(cAlternative))
+		}
+
+		cToken := C.makeLatticeToken(C.int(token.Start), C.int(token.End), C.CString(token.Input), cAlternatives)
+		C.varray_push(cLattice, unsafe.Pointer(cToken))
+	}
+	return cLattice
+}
+
+func cLatticeToGoLattice(cLattice *C.varray) []govarnam.LatticeToken {
+	var lattice []govarnam.LatticeToken
+
+	for i := 0; i < int(C.varray_length(cLattice)); i++ {
+		cToken := (*C.LatticeToken)(C.varray_get(cLattice, C.int(i)))
+
+		token := govarnam.LatticeToken{
+			Start: int(cToken.Start),
+			End:   int(cToken.End),
+			Input: C.GoString(cToken.Input),
+		}
+
+		for j := 0; j < int(C.varray_length(cToken.Alternatives)); j++ {
+			cAlternative := (*C.LatticeAlternative)(C.varray_get(cToken.Alternatives, C.int(j)))
+
+			token.Alternatives = append(token.Alternatives, govarnam.LatticeAlternative{
+				Value:  C.GoString(cAlternative.Value),
+				Weight: int(cAlternative.Weight),
+			})
+		}
+
+		lattice = append(lattice, token)
+	}
+
+	return lattice
+}
 
 func cSymbolToGoSymbol(symbol C.struct_Symbol_t) govarnam.Symbol {
 	var goSymbol govarnam.Symbol
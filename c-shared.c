@@ -3,12 +3,15 @@
 #include "stdlib.h"
 #include "c-shared-varray.h"
 
-Suggestion* makeSuggestion(char* word, int weight, int learned_on)
+Suggestion* makeSuggestion(char* word, int weight, int learned_on, int source, int matched_length, double score)
 {
   Suggestion *sug = (Suggestion*) malloc (sizeof(Suggestion));
   sug->Word = word;
   sug->Weight = weight;
   sug->LearnedOn = learned_on;
+  sug->Source = source;
+  sug->MatchedLength = matched_length;
+  sug->Score = score;
   return sug;
 }
 
@@ -25,6 +28,11 @@ TransliterationResult* makeResult(varray* exact_words, varray* exact_matches, va
   return result;
 }
 
+void callLogCallback(VarnamLogCallback callback, int varnamHandleID, int level, const char* message)
+{
+  callback(varnamHandleID, level, message);
+}
+
 void destroySuggestions(void* pointer)
 {
   if (pointer != NULL) {
@@ -42,6 +50,104 @@ void destroySuggestionsArray(varray* pointer)
   pointer = NULL;
 }
 
+LatticeAlternative* makeLatticeAlternative(char* value, int weight)
+{
+  LatticeAlternative *alternative = (LatticeAlternative*) malloc (sizeof(LatticeAlternative));
+  alternative->Value = value;
+  alternative->Weight = weight;
+  return alternative;
+}
+
+LatticeToken* makeLatticeToken(int start, int end, char* input, varray* alternatives)
+{
+  LatticeToken *token = (LatticeToken*) malloc (sizeof(LatticeToken));
+  token->Start = start;
+  token->End = end;
+  token->Input = input;
+  token->Alternatives = alternatives;
+  return token;
+}
+
+void destroyLatticeAlternative(void* pointer)
+{
+  if (pointer != NULL) {
+    LatticeAlternative* alternative = (LatticeAlternative*) pointer;
+    free(alternative->Value);
+    alternative->Value = NULL;
+    free(alternative);
+  }
+}
+
+void destroyLatticeToken(void* pointer)
+{
+  if (pointer != NULL) {
+    LatticeToken* token = (LatticeToken*) pointer;
+    free(token->Input);
+    token->Input = NULL;
+    varray_free(token->Alternatives, &destroyLatticeAlternative);
+    token->Alternatives = NULL;
+    free(token);
+  }
+}
+
+void destroyLatticeArray(varray* pointer)
+{
+  varray_free(pointer, &destroyLatticeToken);
+}
+
+UnreachableWord* makeUnreachableWord(char* word, char* conjunct, char* pattern)
+{
+  UnreachableWord *unreachable = (UnreachableWord*) malloc (sizeof(UnreachableWord));
+  unreachable->Word = word;
+  unreachable->Conjunct = conjunct;
+  unreachable->Pattern = pattern;
+  return unreachable;
+}
+
+void destroyUnreachableWord(void* pointer)
+{
+  if (pointer != NULL) {
+    UnreachableWord* unreachable = (UnreachableWord*) pointer;
+    free(unreachable->Word);
+    free(unreachable->Conjunct);
+    free(unreachable->Pattern);
+    free(unreachable);
+  }
+}
+
+void destroyUnreachableWordsArray(varray* pointer)
+{
+  varray_free(pointer, &destroyUnreachableWord);
+}
+
+Shortcut* makeShortcut(char* input, char* phrase)
+{
+  Shortcut *shortcut = (Shortcut*) malloc (sizeof(Shortcut));
+  shortcut->Input = input;
+  shortcut->Phrase = phrase;
+  return shortcut;
+}
+
+void destroyShortcut(void* pointer)
+{
+  if (pointer != NULL) {
+    Shortcut* shortcut = (Shortcut*) pointer;
+    free(shortcut->Input);
+    free(shortcut->Phrase);
+    free(shortcut);
+  }
+}
+
+void destroyShortcutsArray(varray* pointer)
+{
+  varray_free(pointer, &destroyShortcut);
+}
+
+void destroyStringsArray(varray* pointer)
+{
+  varray_free(pointer, &free);
+}
+
 void destroyTransliterationResult(TransliterationResult* result)
 {
   destroySuggestionsArray(result->ExactMatches);
@@ -136,3 +242,26 @@ void destroySymbolArray(void* cSymbols)
 {
   varray_free(cSymbols, &destroySymbol);
 }
+
+TunedSymbol* makeTunedSymbol(Symbol* symbol, int weight, int priority)
+{
+  TunedSymbol *tuned = (TunedSymbol*) malloc (sizeof(TunedSymbol));
+  tuned->Symbol = symbol;
+  tuned->Weight = weight;
+  tuned->Priority = priority;
+  return tuned;
+}
+
+void destroyTunedSymbol(void* pointer)
+{
+  if (pointer != NULL) {
+    TunedSymbol* tuned = (TunedSymbol*) pointer;
+    destroySymbol(tuned->Symbol);
+    free(tuned);
+  }
+}
+
+void destroyTunedSymbolsArray(varray* pointer)
+{
+  varray_free(pointer, &destroyTunedSymbol);
+}
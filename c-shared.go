@@ -15,9 +15,12 @@ package main
 */
 import "C"
 import (
+	"bytes"
 	"context"
 	"log"
+	"runtime"
 	"sync"
+	"time"
 	"unsafe"
 
 	"github.com/varnamproject/govarnam/govarnam"
@@ -32,10 +35,7 @@ var cancelFuncsMapMutex = sync.RWMutex{}
 // Returns a C integer status code
 // In the C world, errors are indicated by return int status codes
 func checkError(err error) C.int {
-	if err != nil {
-		return C.VARNAM_ERROR
-	}
-	return C.VARNAM_SUCCESS
+	return C.int(govarnam.ErrorCode(err))
 }
 
 // In C, booleans are implemented with int 0 & int 1
@@ -46,6 +46,8 @@ func cintToBool(val C.int) bool {
 	return false
 }
 
+// The returned func must be called when the operation is over.
+// It releases the context & forgets the operation ID.
 func makeContext(id C.int) (context.Context, func()) {
 	ctx, cancel := context.WithCancel(backgroundContext)
 
@@ -53,7 +55,15 @@ func makeContext(id C.int) (context.Context, func()) {
 	cancelFuncs[id] = &cancel
 	cancelFuncsMapMutex.Unlock()
 
-	return ctx, cancel
+	return ctx, func() {
+		cancel()
+
+		cancelFuncsMapMutex.Lock()
+		if cancelFunc, ok := cancelFuncs[id]; ok && cancelFunc == &cancel {
+			delete(cancelFuncs, id)
+		}
+		cancelFuncsMapMutex.Unlock()
+	}
 }
 
 func makeCTransliterationResult(ctx context.Context, goResult govarnam.TransliterationResult, resultPointer **C.struct_TransliterationResult_t) C.int {
@@ -67,37 +77,37 @@ func makeCTransliterationResult(ctx context.Context, goResult govarnam.Translite
 
 		cExactWords := C.varray_init()
 		for _, sug := range goResult.ExactWords {
-			cSug := unsafe.Pointer(C.makeSuggestion(C.CString(sug.Word), C.int(sug.Weight), C.int(sug.LearnedOn)))
+			cSug := unsafe.Pointer(goSuggestionToCSuggestion(sug))
 			C.varray_push(cExactWords, cSug)
 		}
 
 		cExactMatches := C.varray_init()
 		for _, sug := range goResult.ExactMatches {
-			cSug := unsafe.Pointer(C.makeSuggestion(C.CString(sug.Word), C.int(sug.Weight), C.int(sug.LearnedOn)))
+			cSug := unsafe.Pointer(goSuggestionToCSuggestion(sug))
 			C.varray_push(cExactMatches, cSug)
 		}
 
 		cDictionarySuggestions := C.varray_init()
 		for _, sug := range goResult.DictionarySuggestions {
-			cSug := unsafe.Pointer(C.makeSuggestion(C.CString(sug.Word), C.int(sug.Weight), C.int(sug.LearnedOn)))
+			cSug := unsafe.Pointer(goSuggestionToCSuggestion(sug))
 			C.varray_push(cDictionarySuggestions, cSug)
 		}
 
 		cPatternDictionarySuggestions := C.varray_init()
 		for _, sug := range goResult.PatternDictionarySuggestions {
-			cSug := unsafe.Pointer(C.makeSuggestion(C.CString(sug.Word), C.int(sug.Weight), C.int(sug.LearnedOn)))
+			cSug := unsafe.Pointer(goSuggestionToCSuggestion(sug))
 			C.varray_push(cPatternDictionarySuggestions, cSug)
 		}
 
 		cTokenizerSuggestions := C.varray_init()
 		for _, sug := range goResult.TokenizerSuggestions {
-			cSug := unsafe.Pointer(C.makeSuggestion(C.CString(sug.Word), C.int(sug.Weight), C.int(sug.LearnedOn)))
+			cSug := unsafe.Pointer(goSuggestionToCSuggestion(sug))
 			C.varray_push(cTokenizerSuggestions, cSug)
 		}
 
 		cGreedyTokenized := C.varray_init()
 		for _, sug := range goResult.GreedyTokenized {
-			cSug := unsafe.Pointer(C.makeSuggestion(C.CString(sug.Word), C.int(sug.Weight), C.int(sug.LearnedOn)))
+			cSug := unsafe.Pointer(goSuggestionToCSuggestion(sug))
 			C.varray_push(cGreedyTokenized, cSug)
 		}
 
@@ -124,37 +134,66 @@ func varnam_set_vst_lookup_dir(path *C.char) {
 
 type varnamHandle struct {
 	varnam *govarnam.Varnam
-	err    error
+
+	// Calls on the instance can be from many threads
+	errMutex sync.Mutex
+	err      error
+}
+
+// Remember err as the last error of the varnam instance
+func (handle *varnamHandle) setError(err error) {
+	handle.errMutex.Lock()
+	defer handle.errMutex.Unlock()
+
+	handle.err = err
+}
+
+func (handle *varnamHandle) lastError() error {
+	handle.errMutex.Lock()
+	defer handle.errMutex.Unlock()
+
+	return handle.err
+}
+
+// Remember err as the last error & give its code
+func (handle *varnamHandle) checkError(err error) C.int {
+	handle.setError(err)
+	return checkError(err)
 }
 
 // For storing varnam instances
 var varnamHandles = map[C.int]*varnamHandle{}
 var varnamHandlesMapMutex = sync.RWMutex{}
 
+// IDs are never reused, even after varnam_close()
+var nextVarnamHandleID C.int
+
+func addVarnamHandle(varnamGo *govarnam.Varnam, err error) C.int {
+	varnamHandlesMapMutex.Lock()
+	defer varnamHandlesMapMutex.Unlock()
+
+	handleID := nextVarnamHandleID
+	nextVarnamHandleID++
+
+	varnamHandles[handleID] = &varnamHandle{varnam: varnamGo, err: err}
+
+	return handleID
+}
+
 //export varnam_init
 func varnam_init(vstFile *C.char, learningsFile *C.char, id unsafe.Pointer) C.int {
-	handleID := C.int(len(varnamHandles))
-	*(*C.int)(id) = handleID
-
 	varnamGo, err := govarnam.Init(C.GoString(vstFile), C.GoString(learningsFile))
 
-	varnamHandlesMapMutex.Lock()
-	varnamHandles[handleID] = &varnamHandle{varnamGo, err}
-	varnamHandlesMapMutex.Unlock()
+	*(*C.int)(id) = addVarnamHandle(varnamGo, err)
 
 	return checkError(err)
 }
 
 //export varnam_init_from_id
 func varnam_init_from_id(schemeID *C.char, id unsafe.Pointer) C.int {
-	handleID := C.int(len(varnamHandles))
-	*(*C.int)(id) = handleID
-
 	varnamGo, err := govarnam.InitFromID(C.GoString(schemeID))
 
-	varnamHandlesMapMutex.Lock()
-	varnamHandles[handleID] = &varnamHandle{varnamGo, err}
-	varnamHandlesMapMutex.Unlock()
+	*(*C.int)(id) = addVarnamHandle(varnamGo, err)
 
 	return checkError(err)
 }
@@ -172,10 +211,8 @@ func getVarnamHandle(id C.int) *varnamHandle {
 //export varnam_close
 func varnam_close(varnamHandleID C.int) C.int {
 	handle := getVarnamHandle(varnamHandleID)
-	handle.err = handle.varnam.Close()
-
-	if handle.err != nil {
-		return checkError(handle.err)
+	if err := handle.varnam.Close(); err != nil {
+		return handle.checkError(err)
 	}
 
 	varnamHandlesMapMutex.Lock()
@@ -190,21 +227,25 @@ func varnam_transliterate(varnamHandleID C.int, id C.int, word *C.char, resultPo
 	ctx, cancel := makeContext(id)
 	defer cancel()
 
-	channel := make(chan []govarnam.Suggestion)
+	handle := getVarnamHandle(varnamHandleID)
 
-	go getVarnamHandle(varnamHandleID).varnam.TransliterateWithContext(ctx, C.GoString(word), channel)
+	channel := goTransliterate(ctx, handle.varnam, C.GoString(word))
 
 	select {
 	case <-ctx.Done():
 		return C.VARNAM_CANCELLED
-	case result := <-channel:
+	case output := <-channel:
+		if output.err != nil {
+			return handle.checkError(output.err)
+		}
+
 		// Note that C.CString uses malloc()
 		// They should be freed manually. GC won't pick it.
 		// The freeing should be done by programs using govarnam
 
 		cResult := C.varray_init()
-		for _, sug := range result {
-			cSug := unsafe.Pointer(C.makeSuggestion(C.CString(sug.Word), C.int(sug.Weight), C.int(sug.LearnedOn)))
+		for _, sug := range output.result.Suggestions() {
+			cSug := unsafe.Pointer(goSuggestionToCSuggestion(sug))
 			C.varray_push(cResult, cSug)
 		}
 		*resultPointer = cResult
@@ -213,23 +254,72 @@ func varnam_transliterate(varnamHandleID C.int, id C.int, word *C.char, resultPo
 	}
 }
 
+type transliterationOutput struct {
+	result govarnam.TransliterationResult
+	err    error
+}
+
+// Transliterate in another goroutine so that the caller can return on cancel
+func goTransliterate(ctx context.Context, varnam *govarnam.Varnam, word string) <-chan transliterationOutput {
+	// Buffered, the worker shouldn't block if nobody receives
+	channel := make(chan transliterationOutput, 1)
+
+	go func() {
+		result, err := varnam.TransliterateWithOptions(ctx, word, varnam.GetOptions())
+		channel <- transliterationOutput{result, err}
+	}()
+
+	return channel
+}
+
 //export varnam_transliterate_advanced
 func varnam_transliterate_advanced(varnamHandleID C.int, id C.int, word *C.char, resultPointer **C.struct_TransliterationResult_t) C.int {
 	ctx, cancel := makeContext(id)
 	defer cancel()
 
-	channel := make(chan govarnam.TransliterationResult)
+	handle := getVarnamHandle(varnamHandleID)
 
-	go getVarnamHandle(varnamHandleID).varnam.TransliterateAdvancedWithContext(ctx, C.GoString(word), channel)
+	channel := goTransliterate(ctx, handle.varnam, C.GoString(word))
 
 	select {
 	case <-ctx.Done():
 		return C.VARNAM_CANCELLED
-	case result := <-channel:
-		return makeCTransliterationResult(ctx, result, resultPointer)
+	case output := <-channel:
+		if output.err != nil {
+			return handle.checkError(output.err)
+		}
+		return makeCTransliterationResult(ctx, output.result, resultPointer)
 	}
 }
 
+// Stops waiting for dictionary & tokenizer after timeoutMs and gives
+// what finished. missingStages is set to a bitmask of VARNAM_STAGE_*
+// that didn't finish. skippedStages is set to the ones not run at all
+// since word is longer than the input length limit.
+// Only varnam_cancel() gives VARNAM_CANCELLED.
+//export varnam_transliterate_with_deadline
+func varnam_transliterate_with_deadline(varnamHandleID C.int, id C.int, word *C.char, timeoutMs C.int, resultPointer **C.struct_TransliterationResult_t, missingStages *C.int, skippedStages *C.int) C.int {
+	ctx, cancel := makeContext(id)
+	defer cancel()
+
+	deadline := time.Now().Add(time.Duration(timeoutMs) * time.Millisecond)
+
+	handle := getVarnamHandle(varnamHandleID)
+
+	result, err := handle.varnam.TransliterateWithDeadline(ctx, C.GoString(word), deadline)
+	if err != nil {
+		return handle.checkError(err)
+	}
+
+	code := makeCTransliterationResult(ctx, result, resultPointer)
+	if code == C.VARNAM_SUCCESS {
+		*missingStages = C.int(result.MissingStages)
+		*skippedStages = C.int(result.SkippedStages)
+	}
+
+	return code
+}
+
 //export varnam_transliterate_greedy_tokenized
 func varnam_transliterate_greedy_tokenized(varnamHandleID C.int, word *C.char, resultPointer **C.varray) C.int {
 	handle := getVarnamHandle(varnamHandleID)
@@ -238,7 +328,7 @@ func varnam_transliterate_greedy_tokenized(varnamHandleID C.int, word *C.char, r
 
 	ptr := C.varray_init()
 	for _, sug := range result {
-		cSug := unsafe.Pointer(C.makeSuggestion(C.CString(sug.Word), C.int(sug.Weight), C.int(sug.LearnedOn)))
+		cSug := unsafe.Pointer(goSuggestionToCSuggestion(sug))
 		C.varray_push(ptr, cSug)
 	}
 	*resultPointer = ptr
@@ -252,13 +342,12 @@ func varnam_reverse_transliterate(varnamHandleID C.int, word *C.char, resultPoin
 	sugs, err := handle.varnam.ReverseTransliterate(C.GoString(word))
 
 	if err != nil {
-		handle.err = err
-		return C.VARNAM_ERROR
+		return handle.checkError(err)
 	}
 
 	cResult := C.varray_init()
 	for _, sug := range sugs {
-		cSug := unsafe.Pointer(C.makeSuggestion(C.CString(sug.Word), C.int(sug.Weight), C.int(sug.LearnedOn)))
+		cSug := unsafe.Pointer(goSuggestionToCSuggestion(sug))
 		C.varray_push(cResult, cSug)
 	}
 	*resultPointer = cResult
@@ -266,11 +355,277 @@ func varnam_reverse_transliterate(varnamHandleID C.int, word *C.char, resultPoin
 	return C.VARNAM_SUCCESS
 }
 
+// Phonetic key of a native word. Free key with free()
+//export varnam_phonetic_key
+func varnam_phonetic_key(varnamHandleID C.int, id C.int, word *C.char, key **C.char) C.int {
+	ctx, cancel := makeContext(id)
+	defer cancel()
+
+	handle := getVarnamHandle(varnamHandleID)
+
+	goKey, err := handle.varnam.PhoneticKey(ctx, C.GoString(word))
+	if err != nil {
+		return handle.checkError(err)
+	}
+	*key = C.CString(goKey)
+
+	return C.VARNAM_SUCCESS
+}
+
+// Phonetic key of a Latin input, same as
+// varnam_phonetic_key() of the word meant.
+// Free key with free()
+//export varnam_input_phonetic_key
+func varnam_input_phonetic_key(varnamHandleID C.int, id C.int, input *C.char, key **C.char) C.int {
+	ctx, cancel := makeContext(id)
+	defer cancel()
+
+	handle := getVarnamHandle(varnamHandleID)
+
+	goKey, err := handle.varnam.InputPhoneticKey(ctx, C.GoString(input))
+	if err != nil {
+		return handle.checkError(err)
+	}
+	*key = C.CString(goKey)
+
+	return C.VARNAM_SUCCESS
+}
+
+// Tokens of word with all values they can have.
+// Free the result with destroyLatticeArray()
+//export varnam_token_lattice
+func varnam_token_lattice(varnamHandleID C.int, id C.int, word *C.char, resultPointer **C.varray) C.int {
+	ctx, cancel := makeContext(id)
+	defer cancel()
+
+	handle := getVarnamHandle(varnamHandleID)
+
+	lattice, err := handle.varnam.TokenLattice(ctx, C.GoString(word))
+	if err != nil {
+		return handle.checkError(err)
+	}
+
+	*resultPointer = goLatticeToCLattice(lattice)
+
+	return C.VARNAM_SUCCESS
+}
+
+// Find which of words (char*) can't be typed. All learnt
+// words are checked if words is NULL. Free unreachable
+// with destroyUnreachableWordsArray()
+//export varnam_check_reachability
+func varnam_check_reachability(varnamHandleID C.int, id C.int, words *C.varray, total *C.int, reachable *C.int, unreachable **C.varray) C.int {
+	ctx, cancel := makeContext(id)
+	defer cancel()
+
+	handle := getVarnamHandle(varnamHandleID)
+
+	var (
+		report govarnam.ReachabilityReport
+		err    error
+	)
+
+	if words == nil {
+		report, err = handle.varnam.CheckDictionaryReachability(ctx)
+	} else {
+		var goWords []string
+		for i := 0; i < int(C.varray_length(words)); i++ {
+			goWords = append(goWords, C.GoString((*C.char)(C.varray_get(words, C.int(i)))))
+		}
+		report, err = handle.varnam.CheckReachability(ctx, goWords)
+	}
+
+	if err != nil {
+		return handle.checkError(err)
+	}
+
+	*total = C.int(report.Total)
+	*reachable = C.int(report.Reachable)
+
+	cUnreachable := C.varray_init()
+	for _, word := range report.Unreachable {
+		cWord := C.makeUnreachableWord(C.CString(word.Word), C.CString(word.Conjunct), C.CString(word.Pattern))
+		C.varray_push(cUnreachable, unsafe.Pointer(cWord))
+	}
+	*unreachable = cUnreachable
+
+	return C.VARNAM_SUCCESS
+}
+
+// Tune weights & priorities of symbols so that words[i] (char*)
+// is the first tokenizer suggestion of patterns[i] more often.
+// Trained patterns are used if patterns is NULL. A copy of VST
+// with the tuned weights is written to outputVSTPath if it's not
+// NULL. Free tuned with destroyTunedSymbolsArray()
+//export varnam_tune_symbol_weights
+func varnam_tune_symbol_weights(varnamHandleID C.int, id C.int, patterns *C.varray, words *C.varray, outputVSTPath *C.char, cases *C.int, top1Before *C.int, top1After *C.int, tuned **C.varray) C.int {
+	ctx, cancel := makeContext(id)
+	defer cancel()
+
+	handle := getVarnamHandle(varnamHandleID)
+
+	var (
+		tuningCases []govarnam.TuningCase
+		err         error
+	)
+
+	if patterns == nil {
+		tuningCases, err = handle.varnam.GetTrainedPatterns(ctx)
+		if err != nil {
+			return handle.checkError(err)
+		}
+	} else {
+		if words == nil || C.varray_length(patterns) != C.varray_length(words) {
+			return handle.checkError(&govarnam.Error{Code: govarnam.VARNAM_MISUSE, Message: "Each pattern should have a word"})
+		}
+
+		for i := 0; i < int(C.varray_length(patterns)); i++ {
+			tuningCases = append(tuningCases, govarnam.TuningCase{
+				Pattern: C.GoString((*C.char)(C.varray_get(patterns, C.int(i)))),
+				Word:    C.GoString((*C.char)(C.varray_get(words, C.int(i)))),
+			})
+		}
+	}
+
+	report, err := handle.varnam.TuneSymbolWeights(ctx, tuningCases)
+	if err != nil {
+		return handle.checkError(err)
+	}
+
+	if outputVSTPath != nil {
+		err = handle.varnam.WriteTunedVST(report.Tuned, C.GoString(outputVSTPath))
+		if err != nil {
+			return handle.checkError(err)
+		}
+	}
+
+	*cases = C.int(report.Cases)
+	*top1Before = C.int(report.Top1Before)
+	*top1After = C.int(report.Top1After)
+
+	cTuned := C.varray_init()
+	for _, tunedSymbol := range report.Tuned {
+		cTunedSymbol := C.makeTunedSymbol(goSymbolToCSymbol(tunedSymbol.Symbol), C.int(tunedSymbol.Weight), C.int(tunedSymbol.Priority))
+		C.varray_push(cTuned, unsafe.Pointer(cTunedSymbol))
+	}
+	*tuned = cTuned
+
+	return C.VARNAM_SUCCESS
+}
+
+// Add upto perWord likely patterns of each learnt word
+// to patterns dictionary, marked as generated
+//export varnam_generate_patterns
+func varnam_generate_patterns(varnamHandleID C.int, id C.int, perWord C.int, words *C.int, patterns *C.int) C.int {
+	ctx, cancel := makeContext(id)
+	defer cancel()
+
+	handle := getVarnamHandle(varnamHandleID)
+
+	status, err := handle.varnam.GeneratePatterns(ctx, int(perWord))
+
+	// Some may have been added even if it failed
+	*words = C.int(status.Words)
+	*patterns = C.int(status.Patterns)
+
+	if err != nil {
+		return handle.checkError(err)
+	}
+
+	return C.VARNAM_SUCCESS
+}
+
+// Remove patterns added by varnam_generate_patterns
+// that weren't trained since
+//export varnam_delete_generated_patterns
+func varnam_delete_generated_patterns(varnamHandleID C.int, deleted *C.int) C.int {
+	handle := getVarnamHandle(varnamHandleID)
+
+	count, err := handle.varnam.DeleteGeneratedPatterns()
+	if err != nil {
+		return handle.checkError(err)
+	}
+
+	*deleted = C.int(count)
+
+	return C.VARNAM_SUCCESS
+}
+
+// Make a word from lattice choosing choices[i]th alternative
+// of ith token. The word should be freed by caller.
+//export varnam_build_word
+func varnam_build_word(varnamHandleID C.int, lattice *C.varray, choices *C.int, choicesLength C.int, word **C.char) C.int {
+	handle := getVarnamHandle(varnamHandleID)
+
+	var goChoices []int
+	if choicesLength > 0 {
+		cChoices := (*[1 << 28]C.int)(unsafe.Pointer(choices))[:choicesLength:choicesLength]
+		for _, choice := range cChoices {
+			goChoices = append(goChoices, int(choice))
+		}
+	}
+
+	goWord, err := handle.varnam.BuildWord(cLatticeToGoLattice(lattice), goChoices)
+	if err != nil {
+		return handle.checkError(err)
+	}
+
+	*word = C.CString(goWord)
+
+	return C.VARNAM_SUCCESS
+}
+
 //export varnam_debug
 func varnam_debug(varnamHandleID C.int, val C.int) {
 	getVarnamHandle(varnamHandleID).varnam.Debug = cintToBool(val)
 }
 
+// Number of goroutines running in the library. Callers have a
+// Go runtime of their own, this is for checking leaks in tests.
+// C threads that called in keep an idle goroutine each, those
+// aren't counted.
+//export varnam_debug_goroutines
+func varnam_debug_goroutines() C.int {
+	buf := make([]byte, 1<<16)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) {
+			buf = buf[:n]
+			break
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+
+	count := 0
+	for _, stack := range bytes.Split(buf, []byte("\n\n")) {
+		lines := bytes.SplitN(stack, []byte("\n"), 3)
+		if len(lines) > 1 && !bytes.HasPrefix(lines[1], []byte("runtime.goexit")) {
+			count++
+		}
+	}
+	return C.int(count)
+}
+
+// Diagnostics of the instance are passed to callback with a
+// VARNAM_LOG_* level. The message is freed after callback returns.
+// Pass NULL to stop logging.
+//export varnam_set_log_callback
+func varnam_set_log_callback(varnamHandleID C.int, callback C.VarnamLogCallback) {
+	handle := getVarnamHandle(varnamHandleID)
+
+	if callback == nil {
+		handle.varnam.SetLogger(nil)
+		return
+	}
+
+	handle.varnam.SetLogger(govarnam.LogFunc(func(level int, message string) {
+		cMessage := C.CString(message)
+		defer C.free(unsafe.Pointer(cMessage))
+
+		C.callLogCallback(callback, varnamHandleID, C.int(level), cMessage)
+	}))
+}
+
 // Deprecated. Use varnam_config()
 //export varnam_set_indic_digits
 func varnam_set_indic_digits(varnamHandleID C.int, val C.int) {
@@ -280,50 +635,173 @@ func varnam_set_indic_digits(varnamHandleID C.int, val C.int) {
 // Deprecated. Use varnam_config()
 //export varnam_set_dictionary_suggestions_limit
 func varnam_set_dictionary_suggestions_limit(varnamHandleID C.int, val C.int) {
-	getVarnamHandle(varnamHandleID).varnam.DictionarySuggestionsLimit = int(val)
+	varnam_config(varnamHandleID, C.VARNAM_CONFIG_SET_DICTIONARY_SUGGESTIONS_LIMIT, val)
 }
 
 // Deprecated. Use varnam_config()
 //export varnam_set_pattern_dictionary_suggestions_limit
 func varnam_set_pattern_dictionary_suggestions_limit(varnamHandleID C.int, val C.int) {
-	getVarnamHandle(varnamHandleID).varnam.PatternDictionarySuggestionsLimit = int(val)
+	varnam_config(varnamHandleID, C.VARNAM_CONFIG_SET_PATTERN_DICTIONARY_SUGGESTIONS_LIMIT, val)
 }
 
 // Deprecated. Use varnam_config()
 //export varnam_set_tokenizer_suggestions_limit
 func varnam_set_tokenizer_suggestions_limit(varnamHandleID C.int, val C.int) {
-	getVarnamHandle(varnamHandleID).varnam.TokenizerSuggestionsLimit = int(val)
+	varnam_config(varnamHandleID, C.VARNAM_CONFIG_SET_TOKENIZER_SUGGESTIONS_LIMIT, val)
 }
 
 // Deprecated. Use varnam_config()
 //export varnam_set_dictionary_match_exact
 func varnam_set_dictionary_match_exact(varnamHandleID C.int, val C.int) {
-	if val == 0 {
-		getVarnamHandle(varnamHandleID).varnam.DictionaryMatchExact = false
-	} else {
-		getVarnamHandle(varnamHandleID).varnam.DictionaryMatchExact = true
-	}
+	varnam_config(varnamHandleID, C.VARNAM_CONFIG_SET_DICTIONARY_MATCH_EXACT, val)
+}
+
+// Hit/miss counts of transliteration cache
+//export varnam_get_cache_stats
+func varnam_get_cache_stats(varnamHandleID C.int, stats *C.CacheStats) C.int {
+	goStats := getVarnamHandle(varnamHandleID).varnam.CacheStats()
+
+	stats.ResultHits = C.int(goStats.ResultHits)
+	stats.ResultMisses = C.int(goStats.ResultMisses)
+	stats.TokenHits = C.int(goStats.TokenHits)
+	stats.TokenMisses = C.int(goStats.TokenMisses)
+	stats.Invalidations = C.int(goStats.Invalidations)
+	stats.Results = C.int(goStats.Results)
+	stats.Tokens = C.int(goStats.Tokens)
+
+	return C.VARNAM_SUCCESS
 }
 
 //export varnam_learn
 func varnam_learn(varnamHandleID C.int, word *C.char, weight C.int) C.int {
 	handle := getVarnamHandle(varnamHandleID)
-	handle.err = handle.varnam.Learn(C.GoString(word), int(weight))
-	return checkError(handle.err)
+	return handle.checkError(handle.varnam.Learn(C.GoString(word), int(weight)))
 }
 
 //export varnam_train
 func varnam_train(varnamHandleID C.int, pattern *C.char, word *C.char) C.int {
 	handle := getVarnamHandle(varnamHandleID)
-	handle.err = handle.varnam.Train(C.GoString(pattern), C.GoString(word))
-	return checkError(handle.err)
+	return handle.checkError(handle.varnam.Train(C.GoString(pattern), C.GoString(word)))
+}
+
+// The user chose shown[chosenIndex] for input. It's learnt,
+// the ones shown above it get ranked lower for input.
+//export varnam_accept_suggestion
+func varnam_accept_suggestion(varnamHandleID C.int, input *C.char, shown *C.varray, chosenIndex C.int) C.int {
+	handle := getVarnamHandle(varnamHandleID)
+
+	var sugs []govarnam.Suggestion
+	for i := 0; i < int(C.varray_length(shown)); i++ {
+		cSug := (*C.struct_Suggestion_t)(C.varray_get(shown, C.int(i)))
+		sugs = append(sugs, cSuggestionToGoSuggestion(cSug))
+	}
+
+	return handle.checkError(handle.varnam.AcceptSuggestion(C.GoString(input), sugs, int(chosenIndex)))
 }
 
 //export varnam_unlearn
 func varnam_unlearn(varnamHandleID C.int, word *C.char) C.int {
 	handle := getVarnamHandle(varnamHandleID)
-	handle.err = handle.varnam.Unlearn(C.GoString(word))
-	return checkError(handle.err)
+	return handle.checkError(handle.varnam.Unlearn(C.GoString(word)))
+}
+
+//export varnam_block_word
+func varnam_block_word(varnamHandleID C.int, word *C.char) C.int {
+	handle := getVarnamHandle(varnamHandleID)
+	return handle.checkError(handle.varnam.BlockWord(C.GoString(word)))
+}
+
+//export varnam_unblock_word
+func varnam_unblock_word(varnamHandleID C.int, word *C.char) C.int {
+	handle := getVarnamHandle(varnamHandleID)
+	return handle.checkError(handle.varnam.UnblockWord(C.GoString(word)))
+}
+
+// Block words in file, a word per line. They're
+// not saved, load the file after every init.
+//export varnam_load_blocklist
+func varnam_load_blocklist(varnamHandleID C.int, filePath *C.char) C.int {
+	handle := getVarnamHandle(varnamHandleID)
+	return handle.checkError(handle.varnam.LoadBlocklist(C.GoString(filePath)))
+}
+
+// Words blocked with varnam_block_word() as char*.
+// Free words with destroyStringsArray()
+//export varnam_get_blocked_words
+func varnam_get_blocked_words(varnamHandleID C.int, id C.int, words **C.varray) C.int {
+	ctx, cancel := makeContext(id)
+	defer cancel()
+
+	handle := getVarnamHandle(varnamHandleID)
+
+	result, err := handle.varnam.GetBlockedWords(ctx)
+	if err != nil {
+		return handle.checkError(err)
+	}
+
+	cWords := C.varray_init()
+	for _, word := range result {
+		C.varray_push(cWords, unsafe.Pointer(C.CString(word)))
+	}
+	*words = cWords
+
+	return C.VARNAM_SUCCESS
+}
+
+//export varnam_add_shortcut
+func varnam_add_shortcut(varnamHandleID C.int, input *C.char, phrase *C.char) C.int {
+	handle := getVarnamHandle(varnamHandleID)
+	return handle.checkError(handle.varnam.AddShortcut(C.GoString(input), C.GoString(phrase)))
+}
+
+//export varnam_remove_shortcut
+func varnam_remove_shortcut(varnamHandleID C.int, input *C.char) C.int {
+	handle := getVarnamHandle(varnamHandleID)
+	return handle.checkError(handle.varnam.RemoveShortcut(C.GoString(input)))
+}
+
+// Free shortcuts with destroyShortcutsArray()
+//export varnam_get_shortcuts
+func varnam_get_shortcuts(varnamHandleID C.int, id C.int, shortcuts **C.varray) C.int {
+	ctx, cancel := makeContext(id)
+	defer cancel()
+
+	handle := getVarnamHandle(varnamHandleID)
+
+	result, err := handle.varnam.GetShortcuts(ctx)
+	if err != nil {
+		return handle.checkError(err)
+	}
+
+	cShortcuts := C.varray_init()
+	for _, shortcut := range result {
+		cShortcut := C.makeShortcut(C.CString(shortcut.Input), C.CString(shortcut.Phrase))
+		C.varray_push(cShortcuts, unsafe.Pointer(cShortcut))
+	}
+	*shortcuts = cShortcuts
+
+	return C.VARNAM_SUCCESS
+}
+
+//export varnam_export_shortcuts
+func varnam_export_shortcuts(varnamHandleID C.int, filePath *C.char) C.int {
+	handle := getVarnamHandle(varnamHandleID)
+	return handle.checkError(handle.varnam.ExportShortcuts(C.GoString(filePath)))
+}
+
+//export varnam_import_shortcuts
+func varnam_import_shortcuts(varnamHandleID C.int, filePath *C.char, resultPointer **C.struct_LearnStatus_t) C.int {
+	handle := getVarnamHandle(varnamHandleID)
+	learnStatus, err := handle.varnam.ImportShortcuts(C.GoString(filePath))
+
+	if err != nil {
+		return handle.checkError(err)
+	}
+
+	result := C.makeLearnStatus(C.int(learnStatus.TotalWords), C.int(learnStatus.FailedWords))
+	*resultPointer = &result
+
+	return C.VARNAM_SUCCESS
 }
 
 //export varnam_learn_from_file
@@ -332,8 +810,7 @@ func varnam_learn_from_file(varnamHandleID C.int, filePath *C.char, resultPointe
 	learnStatus, err := handle.varnam.LearnFromFile(C.GoString(filePath))
 
 	if err != nil {
-		handle.err = err
-		return C.VARNAM_ERROR
+		return handle.checkError(err)
 	}
 
 	result := C.makeLearnStatus(C.int(learnStatus.TotalWords), C.int(learnStatus.FailedWords))
@@ -342,6 +819,43 @@ func varnam_learn_from_file(varnamHandleID C.int, filePath *C.char, resultPointe
 	return C.VARNAM_SUCCESS
 }
 
+// Learn words in text files of paths (char*), gzip files
+// & directories. Words seen less than minCount times or
+// longer than maxLength (0 for no limit) are left out.
+// maxWords distinct words are counted at most, 0 for default.
+//export varnam_ingest_corpus
+func varnam_ingest_corpus(varnamHandleID C.int, id C.int, paths *C.varray, minCount C.int, maxLength C.int, maxWords C.int, status *C.CorpusStatus) C.int {
+	ctx, cancel := makeContext(id)
+	defer cancel()
+
+	handle := getVarnamHandle(varnamHandleID)
+
+	var goPaths []string
+	for i := 0; i < int(C.varray_length(paths)); i++ {
+		goPaths = append(goPaths, C.GoString((*C.char)(C.varray_get(paths, C.int(i)))))
+	}
+
+	goStatus, err := handle.varnam.IngestCorpus(ctx, goPaths, govarnam.CorpusOptions{
+		MinCount:  int(minCount),
+		MaxLength: int(maxLength),
+		MaxWords:  int(maxWords),
+	})
+
+	// Some may have been learnt even if it failed
+	status.Files = C.int(goStatus.Files)
+	status.Words = C.int(goStatus.Words)
+	status.Distinct = C.int(goStatus.Distinct)
+	status.MaxUndercount = C.int(goStatus.MaxUndercount)
+	status.TotalWords = C.int(goStatus.LearnStatus.TotalWords)
+	status.FailedWords = C.int(goStatus.LearnStatus.FailedWords)
+
+	if err != nil {
+		return handle.checkError(err)
+	}
+
+	return C.VARNAM_SUCCESS
+}
+
 //export varnam_train_from_file
 func varnam_train_from_file(varnamHandleID C.int, filePath *C.char, resultPointer **C.struct_LearnStatus_t) C.int {
 	handle := getVarnamHandle(varnamHandleID)
@@ -349,8 +863,7 @@ func varnam_train_from_file(varnamHandleID C.int, filePath *C.char, resultPointe
 	learnStatus, err := handle.varnam.TrainFromFile(C.GoString(filePath))
 
 	if err != nil {
-		handle.err = err
-		return C.VARNAM_ERROR
+		return handle.checkError(err)
 	}
 
 	result := C.makeLearnStatus(C.int(learnStatus.TotalWords), C.int(learnStatus.FailedWords))
@@ -366,7 +879,7 @@ func varnam_get_last_error(varnamHandleID C.int) *C.char {
 	if varnamHandleID == -1 {
 		err = generalError
 	} else {
-		err = getVarnamHandle(varnamHandleID).err
+		err = getVarnamHandle(varnamHandleID).lastError()
 	}
 
 	if err != nil {
@@ -393,17 +906,19 @@ func varnam_cancel(id C.int) C.int {
 //export varnam_export
 func varnam_export(varnamHandleID C.int, filePath *C.char, wordsPerFile C.int) C.int {
 	handle := getVarnamHandle(varnamHandleID)
-	handle.err = handle.varnam.Export(C.GoString(filePath), int(wordsPerFile))
-
-	return checkError(handle.err)
+	return handle.checkError(handle.varnam.Export(C.GoString(filePath), int(wordsPerFile)))
 }
 
 //export varnam_import
 func varnam_import(varnamHandleID C.int, filePath *C.char) C.int {
 	handle := getVarnamHandle(varnamHandleID)
-	handle.err = handle.varnam.Import(C.GoString(filePath))
+	return handle.checkError(handle.varnam.Import(C.GoString(filePath)))
+}
 
-	return checkError(handle.err)
+//export varnam_add_base_dictionary
+func varnam_add_base_dictionary(varnamHandleID C.int, dictPath *C.char, weightScale C.double) C.int {
+	handle := getVarnamHandle(varnamHandleID)
+	return handle.checkError(handle.varnam.AddBaseDictionary(C.GoString(dictPath), float64(weightScale)))
 }
 
 //export varnam_get_vst_path
@@ -443,13 +958,17 @@ func varnam_search_symbol_table(varnamHandleID C.int, id C.int, searchCriteria C
 
 	goSearchCriteria := cSymbolToGoSymbol(searchCriteria)
 
-	var results []govarnam.Symbol
+	var (
+		results []govarnam.Symbol
+		err     error
+	)
 
 	select {
 	case <-ctx.Done():
 		return C.VARNAM_CANCELLED
 	default:
-		results, handle.err = handle.varnam.SearchSymbolTable(ctx, goSearchCriteria)
+		results, err = handle.varnam.SearchSymbolTable(ctx, goSearchCriteria)
+		handle.setError(err)
 
 		cResult := C.varray_init()
 		for _, symbol := range results {
@@ -472,13 +991,12 @@ func varnam_get_recently_learned_words(varnamHandleID C.int, id C.int, offset C.
 	result, err := handle.varnam.GetRecentlyLearntWords(ctx, int(offset), int(limit))
 
 	if err != nil {
-		handle.err = err
-		return C.VARNAM_ERROR
+		return handle.checkError(err)
 	}
 
 	ptr := C.varray_init()
 	for _, sug := range result {
-		cSug := unsafe.Pointer(C.makeSuggestion(C.CString(sug.Word), C.int(sug.Weight), C.int(sug.LearnedOn)))
+		cSug := unsafe.Pointer(goSuggestionToCSuggestion(sug))
 		C.varray_push(ptr, cSug)
 	}
 	*resultPointer = ptr
@@ -497,7 +1015,7 @@ func varnam_get_suggestions(varnamHandleID C.int, id C.int, word *C.char, result
 
 	ptr := C.varray_init()
 	for _, sug := range result {
-		cSug := unsafe.Pointer(C.makeSuggestion(C.CString(sug.Word), C.int(sug.Weight), C.int(sug.LearnedOn)))
+		cSug := unsafe.Pointer(goSuggestionToCSuggestion(sug))
 		C.varray_push(ptr, cSug)
 	}
 	*resultPointer = ptr
@@ -574,7 +1092,7 @@ func vm_init(vstPath *C.char, id unsafe.Pointer) C.int {
 	varnamGo, err := govarnam.VMInit(C.GoString(vstPath))
 
 	varnamHandlesMapMutex.Lock()
-	varnamHandles[handleID] = &varnamHandle{varnamGo, err}
+	varnamHandles[handleID] = &varnamHandle{varnam: varnamGo, err: err}
 	varnamHandlesMapMutex.Unlock()
 
 	return checkError(err)
@@ -600,7 +1118,7 @@ func vm_create_token(varnamHandleID C.int, pattern *C.char, value1 *C.char, valu
 	// 	tag = C.CString("")
 	// }
 
-	handle.err = handle.varnam.VMCreateToken(
+	return handle.checkError(handle.varnam.VMCreateToken(
 		C.GoString(pattern),
 		C.GoString(value1),
 		C.GoString(value2),
@@ -611,9 +1129,7 @@ func vm_create_token(varnamHandleID C.int, pattern *C.char, value1 *C.char, valu
 		int(priority),
 		int(acceptCondition),
 		cintToBool(buffered),
-	)
-
-	return checkError(handle.err)
+	))
 }
 
 //export vm_delete_token
@@ -622,25 +1138,27 @@ func vm_delete_token(varnamHandleID C.int, searchCriteria C.struct_Symbol_t) C.i
 
 	goSearchCriteria := cSymbolToGoSymbol(searchCriteria)
 
-	handle.err = handle.varnam.VMDeleteToken(goSearchCriteria)
-	return checkError(handle.err)
+	return handle.checkError(handle.varnam.VMDeleteToken(goSearchCriteria))
 }
 
 //export vm_flush_buffer
 func vm_flush_buffer(varnamHandleID C.int) C.int {
 	handle := getVarnamHandle(varnamHandleID)
 
-	handle.err = handle.varnam.VMFlushBuffer()
-	return checkError(handle.err)
+	return handle.checkError(handle.varnam.VMFlushBuffer())
 }
 
 //export varnam_config
 func varnam_config(varnamHandleID C.int, key C.int, value C.int) C.int {
 	handle := getVarnamHandle(varnamHandleID)
 
+	// Other threads may be transliterating with this handle,
+	// so config is changed through UpdateOptions()
 	switch key {
 	case C.VARNAM_CONFIG_USE_INDIC_DIGITS:
-		handle.varnam.LangRules.IndicDigits = cintToBool(value)
+		handle.varnam.UpdateOptions(func(opts *govarnam.Options) {
+			opts.IndicDigits = cintToBool(value)
+		})
 		break
 	case C.VARNAM_CONFIG_USE_DEAD_CONSONANTS:
 		handle.varnam.VSTMakerConfig.UseDeadConsonants = cintToBool(value)
@@ -649,17 +1167,30 @@ func varnam_config(varnamHandleID C.int, key C.int, value C.int) C.int {
 		handle.varnam.VSTMakerConfig.IgnoreDuplicateTokens = cintToBool(value)
 		break
 	case C.VARNAM_CONFIG_SET_DICTIONARY_SUGGESTIONS_LIMIT:
-		handle.varnam.DictionarySuggestionsLimit = int(value)
+		handle.varnam.UpdateOptions(func(opts *govarnam.Options) {
+			opts.DictionarySuggestionsLimit = int(value)
+		})
 		break
 	case C.VARNAM_CONFIG_SET_PATTERN_DICTIONARY_SUGGESTIONS_LIMIT:
-		handle.varnam.PatternDictionarySuggestionsLimit = int(value)
+		handle.varnam.UpdateOptions(func(opts *govarnam.Options) {
+			opts.PatternDictionarySuggestionsLimit = int(value)
+		})
 		break
 	case C.VARNAM_CONFIG_SET_TOKENIZER_SUGGESTIONS_LIMIT:
-		handle.varnam.TokenizerSuggestionsLimit = int(value)
+		handle.varnam.UpdateOptions(func(opts *govarnam.Options) {
+			opts.TokenizerSuggestionsLimit = int(value)
+		})
 		break
 	case C.VARNAM_CONFIG_SET_DICTIONARY_MATCH_EXACT:
-		handle.varnam.DictionaryMatchExact = cintToBool(value)
+		handle.varnam.UpdateOptions(func(opts *govarnam.Options) {
+			opts.DictionaryMatchExact = cintToBool(value)
+		})
+		break
+	case C.VARNAM_CONFIG_SET_CACHE_SIZE:
+		handle.varnam.SetCacheSize(int(value))
 		break
+	case C.VARNAM_CONFIG_USE_DICTIONARY_INDEX:
+		return handle.checkError(handle.varnam.SetDictionaryIndex(cintToBool(value)))
 	}
 
 	return C.VARNAM_SUCCESS
@@ -669,8 +1200,7 @@ func varnam_config(varnamHandleID C.int, key C.int, value C.int) C.int {
 func vm_set_scheme_details(varnamHandleID C.int, sd *C.struct_SchemeDetails_t) C.int {
 	handle := getVarnamHandle(varnamHandleID)
 
-	handle.err = handle.varnam.VMSetSchemeDetails(makeGoSchemeDetails(sd))
-	return checkError(handle.err)
+	return handle.checkError(handle.varnam.VMSetSchemeDetails(makeGoSchemeDetails(sd)))
 }
 
 func main() {}
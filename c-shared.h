@@ -7,6 +7,12 @@
 #define VARNAM_MISUSE  1
 #define VARNAM_ERROR   2
 #define VARNAM_CANCELLED  3
+#define VARNAM_WORD_NOT_FOUND 4
+#define VARNAM_SINGLE_CONJUNCT 5
+#define VARNAM_NOTHING_TO_LEARN 6
+#define VARNAM_VST_SCHEMA_MISMATCH 7
+#define VARNAM_DB_LOCKED 8
+#define VARNAM_WORD_BLOCKED 9
 
 #define VARNAM_CONFIG_USE_DEAD_CONSONANTS 100
 #define VARNAM_CONFIG_IGNORE_DUPLICATE_TOKEN 101
@@ -17,11 +23,46 @@
 #define VARNAM_CONFIG_SET_PATTERN_DICTIONARY_SUGGESTIONS_LIMIT 105
 #define VARNAM_CONFIG_SET_TOKENIZER_SUGGESTIONS_LIMIT 106
 #define VARNAM_CONFIG_SET_DICTIONARY_MATCH_EXACT 107
-
+// 0 disables caching of transliteration results
+#define VARNAM_CONFIG_SET_CACHE_SIZE 108
+// Keep dictionary words in memory for faster lookups
+#define VARNAM_CONFIG_USE_DICTIONARY_INDEX 109
+#define VARNAM_CACHE_SIZE 512
+
+// Stages of transliteration, see varnam_transliterate_with_deadline
+#define VARNAM_STAGE_GREEDY_TOKENIZED (1 << 0)
+#define VARNAM_STAGE_DICTIONARY (1 << 1)
+#define VARNAM_STAGE_PATTERN_DICTIONARY (1 << 2)
+#define VARNAM_STAGE_TOKENIZER (1 << 3)
+
+// Log levels, see varnam_set_log_callback
+#define VARNAM_LOG_DEBUG -4
+#define VARNAM_LOG_INFO 0
+#define VARNAM_LOG_WARN 4
+#define VARNAM_LOG_ERROR 8
+
+typedef void (*VarnamLogCallback)(int varnamHandleID, int level, const char* message);
+
+void callLogCallback(VarnamLogCallback callback, int varnamHandleID, int level, const char* message);
+
+// Source of a suggestion
+#define VARNAM_SOURCE_EXACT_WORD 1
+#define VARNAM_SOURCE_EXACT_MATCH 2
+#define VARNAM_SOURCE_DICTIONARY 3
+#define VARNAM_SOURCE_PATTERN_DICTIONARY 4
+#define VARNAM_SOURCE_TOKENIZER 5
+#define VARNAM_SOURCE_GREEDY_TOKENIZED 6
+#define VARNAM_SOURCE_SHORTCUT 7
+
+// New fields are only added at the end so that
+// programs built with an older header still work
 typedef struct Suggestion_t {
   char* Word;
   int Weight;
   int LearnedOn;
+  int Source;
+  int MatchedLength;
+  double Score;
 } Suggestion;
 
 typedef struct TransliterationResult_t {
@@ -33,11 +74,53 @@ typedef struct TransliterationResult_t {
   varray* GreedyTokenized;
 } TransliterationResult;
 
-Suggestion* makeSuggestion(char* word, int weight, int learned_on);
+Suggestion* makeSuggestion(char* word, int weight, int learned_on, int source, int matched_length, double score);
 
 TransliterationResult* makeResult(varray* exact_words, varray* exact_matches, varray* dictionary_suggestions, varray* pattern_dictionary_suggestions, varray* tokenizer_suggestions, varray* greedy_tokenized);
 
 void destroySuggestionsArray(varray* pointer);
+
+// See varnam_token_lattice
+typedef struct LatticeAlternative_t {
+  char* Value;
+  int Weight;
+} LatticeAlternative;
+
+typedef struct LatticeToken_t {
+  int Start;
+  int End;
+  char* Input;
+  varray* Alternatives;
+} LatticeToken;
+
+LatticeAlternative* makeLatticeAlternative(char* value, int weight);
+LatticeToken* makeLatticeToken(int start, int end, char* input, varray* alternatives);
+
+void destroyLatticeArray(varray* pointer);
+
+// See varnam_check_reachability
+typedef struct UnreachableWord_t {
+  char* Word;
+  char* Conjunct;
+  char* Pattern;
+} UnreachableWord;
+
+UnreachableWord* makeUnreachableWord(char* word, char* conjunct, char* pattern);
+
+void destroyUnreachableWordsArray(varray* pointer);
+
+// See varnam_get_shortcuts
+typedef struct Shortcut_t {
+  char* Input;
+  char* Phrase;
+} Shortcut;
+
+Shortcut* makeShortcut(char* input, char* phrase);
+
+void destroyShortcutsArray(varray* pointer);
+
+// Array of char*
+void destroyStringsArray(varray* pointer);
 void destroyTransliterationResult(TransliterationResult*);
 
 typedef struct SchemeDetails_t {
@@ -60,6 +143,27 @@ typedef struct LearnStatus_t {
 
 LearnStatus makeLearnStatus(int TotalWords, int FailedWords);
 
+// See varnam_ingest_corpus
+typedef struct CorpusStatus_t {
+  int Files;
+  int Words;
+  int Distinct;
+  int MaxUndercount;
+  int TotalWords;
+  int FailedWords;
+} CorpusStatus;
+
+// See varnam_get_cache_stats
+typedef struct CacheStats_t {
+  int ResultHits;
+  int ResultMisses;
+  int TokenHits;
+  int TokenMisses;
+  int Invalidations;
+  int Results;
+  int Tokens;
+} CacheStats;
+
 typedef struct Symbol_t {
   int Identifier;
   int Type;
@@ -79,4 +183,15 @@ Symbol* makeSymbol(int Identifier, int Type, int MatchType, char* Pattern, char*
 
 void destroySymbolArray(void* cSymbols);
 
+// See varnam_tune_symbol_weights
+typedef struct TunedSymbol_t {
+  Symbol* Symbol;
+  int Weight;
+  int Priority;
+} TunedSymbol;
+
+TunedSymbol* makeTunedSymbol(Symbol* symbol, int weight, int priority);
+
+void destroyTunedSymbolsArray(varray* pointer);
+
 #endif /* __C_SHARED_H__ */
\ No newline at end of file
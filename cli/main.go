@@ -7,18 +7,45 @@ package main
  */
 
 import (
+	"bufio"
 	"context"
 	"flag"
 	"fmt"
 	"log"
+	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
+	"github.com/varnamproject/govarnam/evaluate"
 	"github.com/varnamproject/govarnam/govarnamgo"
 )
 
 var varnam *govarnamgo.VarnamHandle
 
+// Prints varnam's diagnostics. Args come already in the message.
+type cliLogger struct {
+	debug bool
+}
+
+func (l cliLogger) Debug(msg string, args ...interface{}) {
+	if l.debug {
+		log.Println("DEBUG", msg)
+	}
+}
+
+func (l cliLogger) Info(msg string, args ...interface{}) {
+	log.Println(msg)
+}
+
+func (l cliLogger) Warn(msg string, args ...interface{}) {
+	log.Println("WARN", msg)
+}
+
+func (l cliLogger) Error(msg string, args ...interface{}) {
+	log.Println("ERROR", msg)
+}
+
 func printSugs(sugs []govarnamgo.Suggestion) {
 	for _, sug := range sugs {
 		if sug.LearnedOn == 0 {
@@ -29,6 +56,218 @@ func printSugs(sugs []govarnamgo.Suggestion) {
 	}
 }
 
+// Transliterate each line of stdin. ":more" shows the
+// next page of tokenizer suggestions of the last input.
+func interactive(pageSize int) {
+	var cursor *govarnamgo.TokenizerCursor
+
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		input := strings.TrimSpace(scanner.Text())
+
+		if input == "" {
+			continue
+		}
+
+		if input == ":more" {
+			if cursor == nil {
+				fmt.Println("Nothing more")
+				continue
+			}
+
+			sugs, err := varnam.NextTokenizerSuggestions(cursor, pageSize)
+			if err != nil {
+				log.Fatal(err.Error())
+			}
+
+			if len(sugs) == 0 {
+				fmt.Println("Nothing more")
+			}
+			printSugs(sugs)
+			continue
+		}
+
+		if cursor != nil {
+			cursor.Close()
+			cursor = nil
+		}
+
+		result, err := varnam.Transliterate(context.Background(), input)
+		if err != nil {
+			log.Fatal(err.Error())
+		}
+		printSugs(result)
+
+		cursor, err = varnam.NewTokenizerCursor(context.Background(), input)
+		if err != nil {
+			log.Fatal(err.Error())
+		}
+
+		// Already shown as part of the result
+		_, err = varnam.NextTokenizerSuggestions(cursor, pageSize)
+		if err != nil {
+			log.Fatal(err.Error())
+		}
+	}
+
+	if cursor != nil {
+		cursor.Close()
+	}
+}
+
+// TransliterationResult field names of sources.
+// Shortcuts are told apart from the exact words they're
+// among so that they don't count as hits of the scheme.
+var sourceBuckets = map[int]string{
+	govarnamgo.VARNAM_SOURCE_EXACT_WORD:         "ExactWords",
+	govarnamgo.VARNAM_SOURCE_EXACT_MATCH:        "ExactMatches",
+	govarnamgo.VARNAM_SOURCE_DICTIONARY:         "DictionarySuggestions",
+	govarnamgo.VARNAM_SOURCE_PATTERN_DICTIONARY: "PatternDictionarySuggestions",
+	govarnamgo.VARNAM_SOURCE_TOKENIZER:          "TokenizerSuggestions",
+	govarnamgo.VARNAM_SOURCE_GREEDY_TOKENIZED:   "GreedyTokenized",
+	govarnamgo.VARNAM_SOURCE_SHORTCUT:           "Shortcuts",
+}
+
+// Evaluate against gold file, compare with baseline report
+// if given & save the report if reportPath is given.
+func runEvaluation(goldPath string, baselinePath string, reportPath string) {
+	goldFile, err := os.Open(goldPath)
+	if err != nil {
+		log.Fatal(err.Error())
+	}
+	defer goldFile.Close()
+
+	cases, err := evaluate.ReadGold(goldFile)
+	if err != nil {
+		log.Fatal(err.Error())
+	}
+
+	report := evaluate.Run(context.Background(), cases, func(ctx context.Context, pattern string) ([]evaluate.Candidate, error) {
+		var candidates []evaluate.Candidate
+
+		sugs, err := varnam.Transliterate(ctx, pattern)
+		for _, sug := range sugs {
+			candidates = append(candidates, evaluate.Candidate{Word: sug.Word, Bucket: sourceBuckets[sug.Source]})
+		}
+
+		return candidates, err
+	})
+
+	report.WriteText(os.Stdout)
+
+	if baselinePath != "" {
+		baselineFile, err := os.Open(baselinePath)
+		if err != nil {
+			log.Fatal(err.Error())
+		}
+		defer baselineFile.Close()
+
+		baseline, err := evaluate.ReadReport(baselineFile)
+		if err != nil {
+			log.Fatal(err.Error())
+		}
+
+		fmt.Println("\nCompared to baseline")
+		evaluate.Compare(baseline, report).WriteText(os.Stdout)
+	}
+
+	if reportPath != "" {
+		reportFile, err := os.Create(reportPath)
+		if err != nil {
+			log.Fatal(err.Error())
+		}
+		defer reportFile.Close()
+
+		err = report.WriteJSON(reportFile)
+		if err != nil {
+			log.Fatal(err.Error())
+		}
+		fmt.Printf("Saved report to %s\n", reportPath)
+	}
+}
+
+// Tune symbol weights with patterns in goldPath, or trained
+// patterns if it's empty. Tuned VST is saved to outputVSTPath.
+func runTuning(goldPath string, outputVSTPath string) {
+	var cases []govarnamgo.TuningCase
+
+	if goldPath != "" {
+		goldFile, err := os.Open(goldPath)
+		if err != nil {
+			log.Fatal(err.Error())
+		}
+
+		gold, err := evaluate.ReadGold(goldFile)
+		goldFile.Close()
+		if err != nil {
+			log.Fatal(err.Error())
+		}
+
+		for _, c := range gold {
+			cases = append(cases, govarnamgo.TuningCase{Pattern: c.Pattern, Word: c.Expected})
+		}
+	}
+
+	report, err := varnam.TuneSymbolWeights(context.Background(), cases, outputVSTPath)
+	if err != nil {
+		log.Fatal(err.Error())
+	}
+
+	if len(report.Tuned) > 0 {
+		fmt.Println("Pattern\tValue\tWeight\tPriority")
+	}
+	for _, tuned := range report.Tuned {
+		fmt.Printf("%s\t%s\t%d => %d\t%d => %d\n", tuned.Symbol.Pattern, tuned.Symbol.Value1, tuned.Symbol.Weight, tuned.Weight, tuned.Symbol.Priority, tuned.Priority)
+	}
+
+	fmt.Printf("Top-1 accuracy of tokenizer: %.2f%% => %.2f%% (%d => %d of %d)\n", report.AccuracyBefore()*100, report.AccuracyAfter()*100, report.Top1Before, report.Top1After, report.Cases)
+	fmt.Printf("Saved tuned VST to %s\n", outputVSTPath)
+}
+
+// Show words that can't be typed, from wordsPath
+// (a word per line) or from learnings if it's empty
+func runReachability(schemeID string, wordsPath string) {
+	var (
+		report govarnamgo.ReachabilityReport
+		err    error
+	)
+
+	if wordsPath == "" {
+		report, err = varnam.CheckDictionaryReachability(context.Background())
+	} else {
+		var words []string
+
+		file, openErr := os.Open(wordsPath)
+		if openErr != nil {
+			log.Fatal(openErr.Error())
+		}
+
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			fields := strings.Fields(scanner.Text())
+			if len(fields) > 0 {
+				words = append(words, fields[0])
+			}
+		}
+		file.Close()
+
+		report, err = varnam.CheckReachability(context.Background(), words)
+	}
+
+	if err != nil {
+		log.Fatal(err.Error())
+	}
+
+	if len(report.Unreachable) > 0 {
+		fmt.Println("Word\tConjunct\tPattern")
+	}
+	for _, word := range report.Unreachable {
+		fmt.Printf("%s\t%s\t%s\n", word.Word, word.Conjunct, word.Pattern)
+	}
+
+	fmt.Printf("Scheme %s can make %.2f%% of words (%d of %d)\n", schemeID, report.Coverage()*100, report.Reachable, report.Total)
+}
+
 func main() {
 	versionFlag := flag.Bool("version", false, "Show version information")
 
@@ -39,11 +278,30 @@ func main() {
 
 	learnFlag := flag.Bool("learn", false, "Learn a word")
 	unlearnFlag := flag.Bool("unlearn", false, "Unlearn a word")
+	blockFlag := flag.Bool("block", false, "Never suggest a word & don't learn it")
+	unblockFlag := flag.Bool("unblock", false, "Unblock a word blocked with -block")
+	blockedFlag := flag.Bool("blocked", false, "Show words blocked with -block")
+	blocklistFlag := flag.String("blocklist", "", "Block words in this file with a word per line for this run")
+
+	addShortcutFlag := flag.Bool("add-shortcut", false, "Suggest a phrase first for an input. 2 Arguments: Input & Phrase")
+	removeShortcutFlag := flag.Bool("remove-shortcut", false, "Remove shortcut of an input")
+	shortcutsFlag := flag.Bool("shortcuts", false, "Show shortcuts")
+	exportShortcutsFlag := flag.Bool("export-shortcuts", false, "Export shortcuts to file")
+	importShortcutsFlag := flag.Bool("import-shortcuts", false, "Import shortcuts from file")
 	trainFlag := flag.Bool("train", false, "Train a word with a particular pattern. 2 Arguments: Pattern & Word")
 
 	learnFromFileFlag := flag.Bool("learn-from-file", false, "Learn words in a file")
 	trainFromFileFlag := flag.Bool("train-from-file", false, "Train pattern => word from a file.")
 
+	ingestCorpusFlag := flag.Bool("ingest-corpus", false, "Learn words in text files, gzip files & directories given as arguments")
+	corpusMinCount := flag.Int("corpus-min-count", 2, "Learn only words seen at least these many times in corpus")
+	corpusMaxLength := flag.Int("corpus-max-length", 40, "Learn only words with at most these many characters from corpus. 0 for no limit")
+	corpusMaxWords := flag.Int("corpus-max-words", 0, "Distinct words to count in memory at most. 0 for default")
+
+	generatePatternsFlag := flag.Bool("generate-patterns", false, "Add likely patterns of learnt words to patterns dictionary")
+	generatePatternsPerWord := flag.Int("generate-patterns-per-word", 5, "Patterns to generate per word")
+	deleteGeneratedPatternsFlag := flag.Bool("delete-generated-patterns", false, "Remove generated patterns that weren't trained")
+
 	exportFlag := flag.Bool("export", false, "Export learnings to file")
 	exportWordsPerFile := flag.Int("export-words-per-file", 30000, "Words per export file")
 	importFlag := flag.Bool("import", false, "Import learnings from file")
@@ -52,6 +310,15 @@ func main() {
 
 	advanced := flag.Bool("advanced", false, "Show transliteration result in advanced mode")
 	reverseTransliterate := flag.Bool("reverse", false, "Reverse transliterate. Find which pattern to use for a specific word")
+	evaluateFlag := flag.Bool("evaluate", false, "Evaluate suggestions with a TSV file of pattern & expected word")
+	evaluateBaseline := flag.String("evaluate-baseline", "", "Report of an earlier evaluation to compare with")
+	evaluateReport := flag.String("evaluate-report", "", "Save evaluation report to this file")
+
+	tuneWeightsFlag := flag.Bool("tune-weights", false, "Tune symbol weights with trained patterns & save a copy of VST. Arguments: Output VST path & optionally a TSV file of pattern & expected word to use instead")
+
+	reachabilityFlag := flag.Bool("reachability", false, "Show learnt words that can't be typed. Optional argument: file with a word per line to check instead")
+
+	interactiveFlag := flag.Bool("interactive", false, "Transliterate lines from stdin. Type :more for more suggestions of the last one")
 
 	flag.Parse()
 
@@ -72,8 +339,16 @@ func main() {
 		log.Fatal(err.Error())
 	}
 
+	varnam.SetLogger(cliLogger{debug: *debugFlag})
 	varnam.Debug(*debugFlag)
 
+	if *blocklistFlag != "" {
+		err = varnam.LoadBlocklist(*blocklistFlag)
+		if err != nil {
+			log.Fatal(err.Error())
+		}
+	}
+
 	config := govarnamgo.Config{IndicDigits: *indicDigitsFlag, DictionarySuggestionsLimit: 10, PatternDictionarySuggestionsLimit: 10, TokenizerSuggestionsLimit: 10, TokenizerSuggestionsAlways: true}
 	varnam.SetConfig(config)
 
@@ -114,6 +389,65 @@ func main() {
 			fmt.Printf("Couldn't learn %s", word)
 			log.Fatal(err.Error())
 		}
+	} else if *blockFlag {
+		word := args[0]
+
+		err := varnam.BlockWord(word)
+		if err != nil {
+			log.Fatal(err.Error())
+		}
+		fmt.Printf("Blocked %s\n", word)
+	} else if *unblockFlag {
+		word := args[0]
+
+		err := varnam.UnblockWord(word)
+		if err != nil {
+			log.Fatal(err.Error())
+		}
+		fmt.Printf("Unblocked %s\n", word)
+	} else if *blockedFlag {
+		words, err := varnam.GetBlockedWords(context.Background())
+		if err != nil {
+			log.Fatal(err.Error())
+		}
+		for _, word := range words {
+			fmt.Println(word)
+		}
+	} else if *addShortcutFlag {
+		input := args[0]
+		phrase := strings.Join(args[1:], " ")
+
+		err := varnam.AddShortcut(input, phrase)
+		if err != nil {
+			log.Fatal(err.Error())
+		}
+		fmt.Printf("Added shortcut %s => %s\n", input, phrase)
+	} else if *removeShortcutFlag {
+		err := varnam.RemoveShortcut(args[0])
+		if err != nil {
+			log.Fatal(err.Error())
+		}
+		fmt.Printf("Removed shortcut %s\n", args[0])
+	} else if *shortcutsFlag {
+		shortcuts, err := varnam.GetShortcuts(context.Background())
+		if err != nil {
+			log.Fatal(err.Error())
+		}
+		for _, shortcut := range shortcuts {
+			fmt.Printf("%s\t%s\n", shortcut.Input, shortcut.Phrase)
+		}
+	} else if *exportShortcutsFlag {
+		err := varnam.ExportShortcuts(args[0])
+		if err != nil {
+			log.Fatal(err.Error())
+		}
+		fmt.Println("Finished exporting shortcuts to file")
+	} else if *importShortcutsFlag {
+		learnStatus, err := varnam.ImportShortcuts(args[0])
+		if err != nil {
+			log.Fatal(err.Error())
+		}
+		fmt.Printf("Finished importing shortcuts. Total: %d. Failed: %d\n", learnStatus.TotalWords, learnStatus.FailedWords)
 	} else if *learnFromFileFlag {
 		learnStatus, err := varnam.LearnFromFile(args[0])
 		if err == nil {
@@ -121,6 +455,31 @@ func main() {
 		} else {
 			log.Fatal(err.Error())
 		}
+	} else if *ingestCorpusFlag {
+		status, err := varnam.IngestCorpus(context.Background(), args, govarnamgo.CorpusOptions{
+			MinCount:  *corpusMinCount,
+			MaxLength: *corpusMaxLength,
+			MaxWords:  *corpusMaxWords,
+		})
+		if err != nil {
+			log.Fatal(err.Error())
+		}
+		fmt.Printf("Read %d words (%d distinct) from %d files. Learnt: %d. Failed: %d\n", status.Words, status.Distinct, status.Files, status.LearnStatus.TotalWords-status.LearnStatus.FailedWords, status.LearnStatus.FailedWords)
+		if status.MaxUndercount > 0 {
+			fmt.Printf("Counts may be lower by up to %d, increase -corpus-max-words for exact counts\n", status.MaxUndercount)
+		}
+	} else if *generatePatternsFlag {
+		status, err := varnam.GeneratePatterns(context.Background(), *generatePatternsPerWord)
+		if err != nil {
+			log.Fatal(err.Error())
+		}
+		fmt.Printf("Generated %d patterns for %d words\n", status.Patterns, status.Words)
+	} else if *deleteGeneratedPatternsFlag {
+		deleted, err := varnam.DeleteGeneratedPatterns()
+		if err != nil {
+			log.Fatal(err.Error())
+		}
+		fmt.Printf("Removed %d generated patterns\n", deleted)
 	} else if *trainFromFileFlag {
 		learnStatus, err := varnam.TrainFromFile(args[0])
 		if err == nil {
@@ -169,6 +528,22 @@ func main() {
 			fmt.Println(sug.Word + " " + fmt.Sprint(sug.Weight))
 			lastWeight = sug.Weight
 		}
+	} else if *evaluateFlag {
+		runEvaluation(args[0], *evaluateBaseline, *evaluateReport)
+	} else if *tuneWeightsFlag {
+		goldPath := ""
+		if len(args) > 1 {
+			goldPath = args[1]
+		}
+		runTuning(goldPath, args[0])
+	} else if *reachabilityFlag {
+		wordsPath := ""
+		if len(args) > 0 {
+			wordsPath = args[0]
+		}
+		runReachability(*schemeFlag, wordsPath)
+	} else if *interactiveFlag {
+		interactive(config.TokenizerSuggestionsLimit)
 	} else if *advanced {
 		var result govarnamgo.TransliterationResult
 
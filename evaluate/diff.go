@@ -0,0 +1,122 @@
+package evaluate
+
+/**
+ * govarnam - An Indian language transliteration library
+ * Copyright Subin Siby <mail at subinsb (.) com>, 2021
+ * Licensed under AGPL-3.0-only. See LICENSE.txt
+ */
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// RankChange a case whose rank changed from baseline
+type RankChange struct {
+	Pattern  string
+	Expected string
+	Before   int
+	After    int
+}
+
+// Diff how a report differs from the baseline. Metrics
+// are current minus baseline.
+type Diff struct {
+	Top1           float64
+	Top5           float64
+	MRR            float64
+	BucketHitRates map[string]float64
+	Latency        Latency
+
+	// Cases whose expected word moved up or down.
+	// Cases in only one of the reports aren't here.
+	Improved  []RankChange
+	Regressed []RankChange
+}
+
+// Compare current report with baseline
+func Compare(baseline Report, current Report) Diff {
+	diff := Diff{
+		Top1:           current.Top1 - baseline.Top1,
+		Top5:           current.Top5 - baseline.Top5,
+		MRR:            current.MRR - baseline.MRR,
+		BucketHitRates: map[string]float64{},
+		Latency: Latency{
+			P50: current.Latency.P50 - baseline.Latency.P50,
+			P90: current.Latency.P90 - baseline.Latency.P90,
+			P99: current.Latency.P99 - baseline.Latency.P99,
+			Max: current.Latency.Max - baseline.Latency.Max,
+		},
+	}
+
+	for bucket, rate := range current.BucketHitRates {
+		diff.BucketHitRates[bucket] = rate - baseline.BucketHitRates[bucket]
+	}
+	for bucket, rate := range baseline.BucketHitRates {
+		if _, ok := current.BucketHitRates[bucket]; !ok {
+			diff.BucketHitRates[bucket] = -rate
+		}
+	}
+
+	type caseKey struct{ pattern, expected string }
+
+	before := map[caseKey]int{}
+	for _, result := range baseline.Results {
+		before[caseKey{result.Pattern, result.Expected}] = result.Rank
+	}
+
+	for _, result := range current.Results {
+		rank, ok := before[caseKey{result.Pattern, result.Expected}]
+		if !ok || rank == result.Rank {
+			continue
+		}
+
+		change := RankChange{result.Pattern, result.Expected, rank, result.Rank}
+
+		// 0 is not found, worse than any rank
+		if rank == 0 || (result.Rank != 0 && result.Rank < rank) {
+			diff.Improved = append(diff.Improved, change)
+		} else {
+			diff.Regressed = append(diff.Regressed, change)
+		}
+	}
+
+	return diff
+}
+
+// WriteText write diff for humans
+func (diff Diff) WriteText(w io.Writer) error {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "Top-1: %+.2f%%\n", diff.Top1*100)
+	fmt.Fprintf(&b, "Top-%d: %+.2f%%\n", TopN, diff.Top5*100)
+	fmt.Fprintf(&b, "MRR: %+.4f\n", diff.MRR)
+
+	fmt.Fprintln(&b, "Buckets:")
+	for _, bucket := range sortedKeys(diff.BucketHitRates) {
+		fmt.Fprintf(&b, "  %s: %+.2f%%\n", bucket, diff.BucketHitRates[bucket]*100)
+	}
+
+	fmt.Fprintf(&b, "Latency (ms): p50 %+.2f, p90 %+.2f, p99 %+.2f, max %+.2f\n", diff.Latency.P50, diff.Latency.P90, diff.Latency.P99, diff.Latency.Max)
+
+	writeChanges := func(title string, changes []RankChange) {
+		fmt.Fprintf(&b, "%s: %d\n", title, len(changes))
+		for _, change := range changes {
+			fmt.Fprintf(&b, "  %s => %s: %s -> %s\n", change.Pattern, change.Expected, rankString(change.Before), rankString(change.After))
+		}
+	}
+
+	writeChanges("Improved", diff.Improved)
+	writeChanges("Regressed", diff.Regressed)
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func rankString(rank int) string {
+	if rank == 0 {
+		return "missing"
+	}
+	return fmt.Sprint(rank)
+}
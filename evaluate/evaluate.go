@@ -0,0 +1,252 @@
+// Package evaluate measures how well a scheme & dictionary
+// transliterate a gold set of pattern => word pairs.
+// Reports can be saved & compared to see if tuning helped.
+package evaluate
+
+/**
+ * govarnam - An Indian language transliteration library
+ * Copyright Subin Siby <mail at subinsb (.) com>, 2021
+ * Licensed under AGPL-3.0-only. See LICENSE.txt
+ */
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// TopN suggestions counted for the top-N accuracy
+const TopN = 5
+
+// Case a pattern & the word expected for it
+type Case struct {
+	Pattern  string
+	Expected string
+}
+
+// Candidate a suggestion made for a pattern. Bucket is where it
+// came from, like the TransliterationResult field holding it.
+type Candidate struct {
+	Word   string
+	Bucket string
+}
+
+// Transliterator gives suggestions for pattern, best first
+type Transliterator func(ctx context.Context, pattern string) ([]Candidate, error)
+
+// CaseResult how a case went
+type CaseResult struct {
+	Pattern  string `json:"pattern"`
+	Expected string `json:"expected"`
+
+	// Position of expected word in suggestions
+	// starting from 1. 0 if it wasn't there.
+	Rank   int    `json:"rank"`
+	Bucket string `json:"bucket,omitempty"`
+
+	LatencyMs float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// Latency percentiles in milliseconds
+type Latency struct {
+	P50 float64 `json:"p50"`
+	P90 float64 `json:"p90"`
+	P99 float64 `json:"p99"`
+	Max float64 `json:"max"`
+}
+
+// Report of an evaluation
+type Report struct {
+	Cases  int `json:"cases"`
+	Errors int `json:"errors"`
+
+	// Share of cases with expected word
+	// first & in the first TopN
+	Top1 float64 `json:"top1"`
+	Top5 float64 `json:"top5"`
+
+	// Mean reciprocal rank
+	MRR float64 `json:"mrr"`
+
+	// Share of cases whose expected word came from a bucket
+	BucketHitRates map[string]float64 `json:"bucket_hit_rates"`
+
+	Latency Latency `json:"latency"`
+
+	Results []CaseResult `json:"results"`
+}
+
+// ReadGold read cases from a TSV of pattern & expected word.
+// Empty lines & lines starting with # are skipped.
+func ReadGold(r io.Reader) ([]Case, error) {
+	var cases []Case
+
+	scanner := bufio.NewScanner(r)
+
+	lineCount := 0
+	for scanner.Scan() {
+		lineCount++
+
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		parts := strings.Split(line, "\t")
+		if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" || strings.TrimSpace(parts[1]) == "" {
+			return cases, fmt.Errorf("Line %d should be pattern<TAB>word", lineCount)
+		}
+
+		cases = append(cases, Case{
+			Pattern:  strings.TrimSpace(parts[0]),
+			Expected: strings.TrimSpace(parts[1]),
+		})
+	}
+
+	return cases, scanner.Err()
+}
+
+// Run the cases one by one. Cases after ctx is done are skipped.
+func Run(ctx context.Context, cases []Case, transliterate Transliterator) Report {
+	var results []CaseResult
+
+	for _, c := range cases {
+		if ctx.Err() != nil {
+			break
+		}
+
+		result := CaseResult{Pattern: c.Pattern, Expected: c.Expected}
+
+		start := time.Now()
+		candidates, err := transliterate(ctx, c.Pattern)
+		result.LatencyMs = float64(time.Since(start).Microseconds()) / 1000
+
+		if err != nil {
+			result.Error = err.Error()
+		}
+
+		for i, candidate := range candidates {
+			if candidate.Word == c.Expected {
+				result.Rank = i + 1
+				result.Bucket = candidate.Bucket
+				break
+			}
+		}
+
+		results = append(results, result)
+	}
+
+	return makeReport(results)
+}
+
+func makeReport(results []CaseResult) Report {
+	report := Report{
+		Cases:          len(results),
+		BucketHitRates: map[string]float64{},
+		Results:        results,
+	}
+
+	if len(results) == 0 {
+		return report
+	}
+
+	var latencies []float64
+
+	for _, result := range results {
+		if result.Error != "" {
+			report.Errors++
+		}
+
+		if result.Rank == 1 {
+			report.Top1++
+		}
+		if result.Rank > 0 && result.Rank <= TopN {
+			report.Top5++
+		}
+		if result.Rank > 0 {
+			report.MRR += 1 / float64(result.Rank)
+			report.BucketHitRates[result.Bucket]++
+		}
+
+		latencies = append(latencies, result.LatencyMs)
+	}
+
+	total := float64(len(results))
+
+	report.Top1 /= total
+	report.Top5 /= total
+	report.MRR /= total
+
+	for bucket := range report.BucketHitRates {
+		report.BucketHitRates[bucket] /= total
+	}
+
+	sort.Float64s(latencies)
+	report.Latency = Latency{
+		P50: percentile(latencies, 50),
+		P90: percentile(latencies, 90),
+		P99: percentile(latencies, 99),
+		Max: latencies[len(latencies)-1],
+	}
+
+	return report
+}
+
+// Nearest rank percentile of sorted values
+func percentile(sorted []float64, p float64) float64 {
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+// WriteJSON save report, read it back with ReadReport()
+func (report Report) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(report)
+}
+
+// ReadReport read a report saved with WriteJSON()
+func ReadReport(r io.Reader) (Report, error) {
+	var report Report
+	err := json.NewDecoder(r).Decode(&report)
+	return report, err
+}
+
+// WriteText write a summary of report for humans
+func (report Report) WriteText(w io.Writer) error {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "Cases: %d (errors: %d)\n", report.Cases, report.Errors)
+	fmt.Fprintf(&b, "Top-1: %.2f%%\n", report.Top1*100)
+	fmt.Fprintf(&b, "Top-%d: %.2f%%\n", TopN, report.Top5*100)
+	fmt.Fprintf(&b, "MRR: %.4f\n", report.MRR)
+
+	fmt.Fprintln(&b, "Buckets:")
+	for _, bucket := range sortedKeys(report.BucketHitRates) {
+		fmt.Fprintf(&b, "  %s: %.2f%%\n", bucket, report.BucketHitRates[bucket]*100)
+	}
+
+	fmt.Fprintf(&b, "Latency (ms): p50 %.2f, p90 %.2f, p99 %.2f, max %.2f\n", report.Latency.P50, report.Latency.P90, report.Latency.P99, report.Latency.Max)
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func sortedKeys(m map[string]float64) []string {
+	var keys []string
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
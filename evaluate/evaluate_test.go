@@ -0,0 +1,85 @@
+package evaluate
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func assertEqual(t *testing.T, value1 interface{}, value2 interface{}) {
+	t.Helper()
+	if value1 != value2 {
+		t.Errorf("Received %v (type %T), expected %v (type %T)", value1, value1, value2, value2)
+	}
+}
+
+func fakeTransliterator(suggestions map[string][]Candidate) Transliterator {
+	return func(ctx context.Context, pattern string) ([]Candidate, error) {
+		candidates, ok := suggestions[pattern]
+		if !ok {
+			return nil, errors.New("unknown pattern")
+		}
+		return candidates, nil
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	gold := "# comment\nmalayalam\tമലയാളം\n\nkeralam\tകേരളം\nvarnam\tവർണം\nxyz\tx\n"
+
+	cases, err := ReadGold(strings.NewReader(gold))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, len(cases), 4)
+
+	_, err = ReadGold(strings.NewReader("malayalam മലയാളം"))
+	assertEqual(t, err != nil, true)
+
+	report := Run(context.Background(), cases, fakeTransliterator(map[string][]Candidate{
+		"malayalam": {{"മലയാളം", "exact"}, {"മലയളം", "tokenizer"}},
+		"keralam":   {{"കേരലം", "tokenizer"}, {"കേരളം", "dictionary"}},
+		"varnam":    {{"വര്ണം", "tokenizer"}},
+	}))
+
+	assertEqual(t, report.Cases, 4)
+	assertEqual(t, report.Errors, 1)
+	assertEqual(t, report.Top1, 0.25)
+	assertEqual(t, report.Top5, 0.5)
+	assertEqual(t, report.MRR, (1+0.5)/4)
+	assertEqual(t, report.BucketHitRates["exact"], 0.25)
+	assertEqual(t, report.BucketHitRates["dictionary"], 0.25)
+	assertEqual(t, report.Results[1].Rank, 2)
+	assertEqual(t, report.Results[2].Rank, 0)
+
+	// Saved & read back
+	var saved bytes.Buffer
+	if err := report.WriteJSON(&saved); err != nil {
+		t.Fatal(err)
+	}
+	baseline, err := ReadReport(&saved)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, baseline.MRR, report.MRR)
+
+	current := Run(context.Background(), cases, fakeTransliterator(map[string][]Candidate{
+		"malayalam": {{"മലയളം", "tokenizer"}, {"മലയാളം", "exact"}},
+		"keralam":   {{"കേരളം", "dictionary"}},
+		"varnam":    {{"വർണം", "tokenizer"}},
+	}))
+
+	diff := Compare(baseline, current)
+	assertEqual(t, diff.Top1, 0.25)
+	assertEqual(t, diff.BucketHitRates["tokenizer"], 0.25)
+	assertEqual(t, len(diff.Improved), 2)
+	assertEqual(t, len(diff.Regressed), 1)
+	assertEqual(t, diff.Regressed[0].Pattern, "malayalam")
+
+	var text bytes.Buffer
+	if err := diff.WriteText(&text); err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, strings.Contains(text.String(), "varnam => വർണം: missing -> 1"), true)
+}
@@ -2,4 +2,7 @@ module github.com/varnamproject/govarnam
 
 go 1.16
 
-require github.com/mattn/go-sqlite3 v1.14.6
+require (
+	github.com/mattn/go-sqlite3 v1.14.6
+	golang.org/x/text v0.13.0
+)
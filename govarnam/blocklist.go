@@ -0,0 +1,309 @@
+package govarnam
+
+/**
+ * govarnam - An Indian language transliteration library
+ * Copyright Subin Siby <mail at subinsb (.) com>, 2021
+ * Licensed under AGPL-3.0-only. See LICENSE.txt
+ */
+
+import (
+	"bufio"
+	"context"
+	sql "database/sql"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+	"time"
+)
+
+// Words checked at once in blocklist table
+const blocklistQueryBatchSize = 500
+
+// Blocked words never show up in suggestions of any source &
+// can't be learnt. User's blocklist is in learnings, see
+// BlockWord(). Lists in files are read-only, see LoadBlocklist().
+
+// Blocklists shipped in the same directory as VST,
+// one for the language & one for the scheme
+func findBlocklistPaths(vstPath string, schemeDetails SchemeDetails) []string {
+	paths := []string{path.Join(path.Dir(vstPath), schemeDetails.LangCode+".blocklist")}
+
+	if schemeDetails.Identifier != "" && schemeDetails.Identifier != schemeDetails.LangCode {
+		paths = append(paths, path.Join(path.Dir(vstPath), schemeDetails.Identifier+".blocklist"))
+	}
+
+	return paths
+}
+
+// Load blocklists shipped with the VST if there are
+func (varnam *Varnam) loadShippedBlocklists(vstPath string) error {
+	for _, blocklistPath := range findBlocklistPaths(vstPath, varnam.SchemeDetails) {
+		if !fileExists(blocklistPath) {
+			continue
+		}
+
+		err := varnam.LoadBlocklist(blocklistPath)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// LoadBlocklist block words in a file with a word per line.
+// Empty lines & lines starting with # are skipped. The words
+// aren't saved in learnings, load the file every time.
+func (varnam *Varnam) LoadBlocklist(filePath string) error {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	words := map[string]bool{}
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		words[varnam.sanitizeWord(line)] = true
+	}
+
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("Reading blocklist %q failed: %s", filePath, err.Error())
+	}
+
+	varnam.configMutex.Lock()
+	if varnam.blocklist == nil {
+		varnam.blocklist = map[string]bool{}
+	}
+	for word := range words {
+		varnam.blocklist[word] = true
+	}
+	varnam.configMutex.Unlock()
+
+	varnam.cache.clear()
+
+	return nil
+}
+
+// BlockWord never suggest word & refuse to learn it.
+// If it was learnt already, it's kept but not shown.
+func (varnam *Varnam) BlockWord(word string) error {
+	word = varnam.sanitizeWord(word)
+	if strings.TrimSpace(word) == "" {
+		return &Error{Code: VARNAM_MISUSE, Message: "Nothing to block"}
+	}
+
+	ctx, cancelFunc := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelFunc()
+
+	err := varnam.writeDict(ctx, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, "INSERT OR IGNORE INTO blocklist (word) VALUES (?)", word)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	varnam.cache.clear()
+
+	return nil
+}
+
+// UnblockWord undo BlockWord(). Words in
+// blocklist files can't be unblocked.
+func (varnam *Varnam) UnblockWord(word string) error {
+	word = varnam.sanitizeWord(word)
+
+	ctx, cancelFunc := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelFunc()
+
+	err := varnam.writeDict(ctx, func(tx *sql.Tx) error {
+		result, err := tx.ExecContext(ctx, "DELETE FROM blocklist WHERE word = ?", word)
+		if err != nil {
+			return err
+		}
+
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+
+		if affected == 0 {
+			return &Error{Code: VARNAM_WORD_NOT_FOUND, Message: "Word isn't blocked"}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	varnam.cache.clear()
+
+	return nil
+}
+
+// GetBlockedWords words blocked with BlockWord()
+func (varnam *Varnam) GetBlockedWords(ctx context.Context) ([]string, error) {
+	var words []string
+
+	rows, err := varnam.dictConn.QueryContext(ctx, "SELECT word FROM blocklist ORDER BY word")
+	if err != nil {
+		return words, dictError(err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var word string
+		rows.Scan(&word)
+		words = append(words, word)
+	}
+
+	if err := rows.Err(); err != nil {
+		return words, dictError(err)
+	}
+
+	return words, nil
+}
+
+// Find which of the words are blocked
+func (varnam *Varnam) findBlockedWords(ctx context.Context, words []string) (map[string]bool, error) {
+	blocked := map[string]bool{}
+
+	varnam.configMutex.RLock()
+	for _, word := range words {
+		if varnam.blocklist[word] {
+			blocked[word] = true
+		}
+	}
+	varnam.configMutex.RUnlock()
+
+	for start := 0; start < len(words); start += blocklistQueryBatchSize {
+		end := start + blocklistQueryBatchSize
+		if end > len(words) {
+			end = len(words)
+		}
+
+		var args []interface{}
+		for _, word := range words[start:end] {
+			args = append(args, word)
+		}
+
+		rows, err := varnam.dictConn.QueryContext(ctx, "SELECT word FROM blocklist WHERE word IN (?"+strings.Repeat(", ?", len(args)-1)+")", args...)
+		if err != nil {
+			return blocked, err
+		}
+
+		for rows.Next() {
+			var word string
+			rows.Scan(&word)
+			blocked[word] = true
+		}
+		rows.Close()
+
+		if err := rows.Err(); err != nil {
+			return blocked, err
+		}
+	}
+
+	return blocked, nil
+}
+
+// Remove blocked words from suggestions
+func (varnam *Varnam) removeBlockedSuggestions(ctx context.Context, sugs []Suggestion) []Suggestion {
+	var words []string
+	for i := range sugs {
+		words = append(words, sugs[i].Word)
+	}
+
+	blocked, err := varnam.findBlockedWords(ctx, words)
+	if err != nil {
+		varnam.lookupFailed(ctx, dictError(err))
+	}
+
+	return filterBlockedSuggestions(sugs, blocked)
+}
+
+// Remove blocked words from all sources of result
+func (varnam *Varnam) removeBlockedWords(ctx context.Context, result *TransliterationResult) {
+	sources := []*[]Suggestion{
+		&result.ExactWords,
+		&result.ExactMatches,
+		&result.DictionarySuggestions,
+		&result.PatternDictionarySuggestions,
+		&result.TokenizerSuggestions,
+		&result.GreedyTokenized,
+	}
+
+	var words []string
+	for _, sugs := range sources {
+		for i := range *sugs {
+			words = append(words, (*sugs)[i].Word)
+		}
+	}
+
+	blocked, err := varnam.findBlockedWords(ctx, words)
+	if err != nil {
+		varnam.lookupFailed(ctx, dictError(err))
+	}
+
+	for _, sugs := range sources {
+		*sugs = filterBlockedSuggestions(*sugs, blocked)
+	}
+}
+
+func filterBlockedSuggestions(sugs []Suggestion, blocked map[string]bool) []Suggestion {
+	if len(blocked) == 0 {
+		return sugs
+	}
+
+	var filtered []Suggestion
+	for i := range sugs {
+		if !blocked[sugs[i].Word] {
+			filtered = append(filtered, sugs[i])
+		}
+	}
+	return filtered
+}
+
+// Leave out blocked words & their patterns from import
+func (varnam *Varnam) removeBlockedImports(ctx context.Context, data exportFormat) (exportFormat, error) {
+	var words []string
+	for _, item := range data.WordsDict {
+		if word, ok := varnam.normalizeImportedWord(item["w"]).(string); ok {
+			words = append(words, word)
+		}
+	}
+
+	blocked, err := varnam.findBlockedWords(ctx, words)
+	if err != nil || len(blocked) == 0 {
+		return data, err
+	}
+
+	isBlocked := func(item map[string]interface{}) bool {
+		word, ok := varnam.normalizeImportedWord(item["w"]).(string)
+		return ok && blocked[word]
+	}
+
+	var filtered exportFormat
+
+	for _, item := range data.WordsDict {
+		if isBlocked(item) {
+			varnam.getLogger().Warn("Not importing blocked word", "word", item["w"])
+			continue
+		}
+		filtered.WordsDict = append(filtered.WordsDict, item)
+	}
+
+	for _, item := range data.PatternsDict {
+		if !isBlocked(item) {
+			filtered.PatternsDict = append(filtered.PatternsDict, item)
+		}
+	}
+
+	return filtered, nil
+}
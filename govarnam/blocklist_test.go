@@ -0,0 +1,99 @@
+package govarnam
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestBlocklist(t *testing.T) {
+	varnam := makeTestVarnam("blocklist", simpleTestSymbols, 0)
+	defer varnam.Close()
+
+	ctx := context.Background()
+
+	checkError(varnam.Learn("കമല", 0))
+	checkError(varnam.Learn("കമലപ", 0))
+	checkError(varnam.Train("kamal", "കമല"))
+
+	// Whether word is in any of the sources
+	suggested := func(input string, word string) bool {
+		result, err := varnam.TransliterateWithOptions(ctx, input, varnam.GetOptions())
+		checkError(err)
+
+		for _, sugs := range [][]Suggestion{
+			result.ExactWords,
+			result.ExactMatches,
+			result.DictionarySuggestions,
+			result.PatternDictionarySuggestions,
+			result.TokenizerSuggestions,
+			result.GreedyTokenized,
+		} {
+			if hasSuggestion(sugs, word) {
+				return true
+			}
+		}
+		return false
+	}
+
+	assertEqual(t, suggested("kamala", "കമല"), true)
+	assertEqual(t, suggested("kamal", "കമല"), true)
+	assertEqual(t, hasSuggestion(varnam.GetSuggestions(ctx, "കമ"), "കമല"), true)
+
+	checkError(varnam.BlockWord("കമല"))
+
+	assertEqual(t, suggested("kamala", "കമല"), false)
+	assertEqual(t, suggested("kamal", "കമല"), false)
+	assertEqual(t, suggested("kamala", "കമലപ"), true)
+	assertEqual(t, hasSuggestion(varnam.GetSuggestions(ctx, "കമ"), "കമല"), false)
+	assertEqual(t, hasSuggestion(varnam.GetSuggestions(ctx, "കമ"), "കമലപ"), true)
+
+	words, err := varnam.GetBlockedWords(ctx)
+	checkError(err)
+	assertEqual(t, strings.Join(words, " "), "കമല")
+
+	// Blocked words can't be learnt
+	assertEqual(t, errors.Is(varnam.Learn("കമല", 0), ErrWordBlocked), true)
+
+	status, err := varnam.LearnMany([]WordInfo{{word: "കമല"}, {word: "മല"}})
+	checkError(err)
+	assertEqual(t, status.TotalWords, 2)
+	assertEqual(t, status.FailedWords, 1)
+
+	// Nor imported
+	filePath := makeFile("blocklist-import.json", `
+		{
+			"words": [
+				{"w": "മല", "c": 5, "l": 1531131220},
+				{"w": "കമല", "c": 5, "l": 1531131220}
+			],
+			"patterns": [
+				{"p": "mala", "w": "മല"},
+				{"p": "kamla", "w": "കമല"}
+			]
+		}
+	`)
+	checkError(varnam.Unlearn("കമല"))
+	checkError(varnam.Import(filePath))
+	assertEqual(t, len(varnam.searchDictionary(ctx, []string{"കമല"}, searchExactWords)), 0)
+	assertEqual(t, len(varnam.searchDictionary(ctx, []string{"മല"}, searchExactWords)), 1)
+
+	checkError(varnam.UnblockWord("കമല"))
+	assertEqual(t, errors.Is(varnam.UnblockWord("കമല"), ErrWordNotFound), true)
+
+	checkError(varnam.Learn("കമല", 0))
+	assertEqual(t, suggested("kamala", "കമല"), true)
+
+	// Words in files
+	blocklistPath := makeFile("blocklist.txt", "# Comment\n\nകമലപ\n")
+	checkError(varnam.LoadBlocklist(blocklistPath))
+
+	assertEqual(t, suggested("kamala", "കമലപ"), false)
+	assertEqual(t, errors.Is(varnam.Learn("കമലപ", 0), ErrWordBlocked), true)
+
+	// Only ones blocked in learnings are listed
+	words, err = varnam.GetBlockedWords(ctx)
+	checkError(err)
+	assertEqual(t, len(words), 0)
+}
@@ -0,0 +1,633 @@
+package govarnam
+
+/**
+ * govarnam - An Indian language transliteration library
+ * Copyright Subin Siby <mail at subinsb (.) com>, 2021
+ * Licensed under AGPL-3.0-only. See LICENSE.txt
+ */
+
+import (
+	"container/list"
+	"context"
+	sql "database/sql"
+	"strings"
+	"sync"
+)
+
+// CacheStats counters of the transliteration cache.
+// See Varnam.CacheStats()
+type CacheStats struct {
+	ResultHits   int
+	ResultMisses int
+	TokenHits    int
+	TokenMisses  int
+
+	// Results removed because learnings or VST changed
+	Invalidations int
+
+	// Entries in the cache now
+	Results int
+	Tokens  int
+}
+
+// Results are per input & config, so that
+// a config change doesn't need invalidation
+type resultCacheKey struct {
+	word string
+	opts Options
+}
+
+type resultCacheEntry struct {
+	// Tokens the result was made from,
+	// used to find if a learnt word affects it
+	tokens []Token
+	result TransliterationResult
+}
+
+type tokenCacheKey struct {
+	word        string
+	matchType   int
+	partial     bool
+	indicDigits bool
+}
+
+// Least recently used items are removed when full
+type lruCache struct {
+	size  int
+	order *list.List
+	items map[interface{}]*list.Element
+}
+
+type lruCacheItem struct {
+	key   interface{}
+	value interface{}
+}
+
+func newLRUCache(size int) *lruCache {
+	return &lruCache{
+		size:  size,
+		order: list.New(),
+		items: map[interface{}]*list.Element{},
+	}
+}
+
+func (cache *lruCache) get(key interface{}) (interface{}, bool) {
+	element, found := cache.items[key]
+	if !found {
+		return nil, false
+	}
+	cache.order.MoveToFront(element)
+	return element.Value.(*lruCacheItem).value, true
+}
+
+func (cache *lruCache) put(key interface{}, value interface{}) {
+	if cache.size <= 0 {
+		return
+	}
+
+	if element, found := cache.items[key]; found {
+		element.Value.(*lruCacheItem).value = value
+		cache.order.MoveToFront(element)
+		return
+	}
+
+	cache.items[key] = cache.order.PushFront(&lruCacheItem{key, value})
+
+	for cache.order.Len() > cache.size {
+		oldest := cache.order.Back()
+		cache.order.Remove(oldest)
+		delete(cache.items, oldest.Value.(*lruCacheItem).key)
+	}
+}
+
+// Remove items for which remove() is true.
+// Returns count of removed items.
+func (cache *lruCache) removeIf(remove func(key interface{}, value interface{}) bool) int {
+	removed := 0
+	for element := cache.order.Front(); element != nil; {
+		next := element.Next()
+		item := element.Value.(*lruCacheItem)
+		if remove(item.key, item.value) {
+			cache.order.Remove(element)
+			delete(cache.items, item.key)
+			removed++
+		}
+		element = next
+	}
+	return removed
+}
+
+func (cache *lruCache) clear() {
+	cache.order.Init()
+	cache.items = map[interface{}]*list.Element{}
+}
+
+// Cache of transliteration results & tokens.
+// Results depend on learnings, they're removed when a
+// word or pattern that can show up in them is learnt.
+// Writes by other processes are found with the learnings
+// DB's data_version & then the whole cache is cleared.
+// VST is opened read-only, but can still be changed by
+// others (VM*). Its data_version is checked likewise &
+// tokens are cleared along with results when it changes.
+// Both are checked once for a ctx, see withCheckedCache().
+type varnamCache struct {
+	mutex sync.Mutex
+
+	results *lruCache
+	tokens  *lruCache
+
+	stats CacheStats
+
+	// Incremented on every invalidation. Results made from
+	// learnings read before it are not stored.
+	generation int
+
+	// Symbol preferences of learnings, valid
+	// while generation is preferencesGeneration
+	preferences           map[symbolPreferenceKey]int
+	preferencesGeneration int
+
+	// data_version is only comparable on the same connection
+	db          *sql.DB
+	versionConn *sql.Conn
+	dataVersion int64
+
+	vstDB          *sql.DB
+	vstVersionConn *sql.Conn
+	vstVersion     int64
+}
+
+func newVarnamCache(db *sql.DB, vstDB *sql.DB, size int) *varnamCache {
+	return &varnamCache{
+		results: newLRUCache(size),
+		tokens:  newLRUCache(size),
+		db:      db,
+		vstDB:   vstDB,
+	}
+}
+
+// Read data_version of learnings DB. It changes
+// when a connection other than this commits.
+func (cache *varnamCache) readDataVersion() (int64, error) {
+	return readDataVersion(cache.db, &cache.versionConn)
+}
+
+// Read data_version of db on conn, which is taken
+// from db if nil. data_version is only comparable
+// on the same connection.
+func readDataVersion(db *sql.DB, conn **sql.Conn) (int64, error) {
+	var err error
+
+	ctx := context.Background()
+
+	if *conn == nil {
+		*conn, err = db.Conn(ctx)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	var version int64
+	err = (*conn).QueryRowContext(ctx, "PRAGMA data_version").Scan(&version)
+	return version, err
+}
+
+type cacheCheckedContextKey struct{}
+
+// Clear cache if learnings or VST changed without us
+// knowing. Done once for all lookups made with ctx.
+func (varnam *Varnam) withCheckedCache(ctx context.Context) context.Context {
+	if ctx.Value(cacheCheckedContextKey{}) != nil {
+		return ctx
+	}
+
+	varnam.cache.check()
+
+	return context.WithValue(ctx, cacheCheckedContextKey{}, true)
+}
+
+func (cache *varnamCache) check() {
+	if cache == nil {
+		return
+	}
+
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	cache.checkVSTVersion()
+	cache.checkDataVersion()
+}
+
+// Clear results if learnings changed without us knowing
+func (cache *varnamCache) checkDataVersion() {
+	version, err := cache.readDataVersion()
+	if err == nil && version == cache.dataVersion {
+		return
+	}
+
+	cache.stats.Invalidations += cache.results.order.Len()
+	cache.results.clear()
+	cache.generation++
+
+	if err == nil {
+		cache.dataVersion = version
+	}
+}
+
+// Clear everything if VST changed. If VST can't be read,
+// it's cleared too so that lookups find out the error.
+func (cache *varnamCache) checkVSTVersion() {
+	version, err := readDataVersion(cache.vstDB, &cache.vstVersionConn)
+	if err == nil && version == cache.vstVersion {
+		return
+	}
+
+	cache.removeAll()
+
+	if err == nil {
+		cache.vstVersion = version
+	}
+}
+
+func (cache *varnamCache) removeAll() {
+	cache.stats.Invalidations += cache.results.order.Len()
+	cache.results.clear()
+	cache.tokens.clear()
+	cache.generation++
+}
+
+// The result is a copy, callers can modify it
+func (cache *varnamCache) getResult(key resultCacheKey) (resultCacheEntry, int, bool) {
+	if cache == nil {
+		return resultCacheEntry{}, 0, false
+	}
+
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	value, found := cache.results.get(key)
+	if !found {
+		cache.stats.ResultMisses++
+		return resultCacheEntry{}, cache.generation, false
+	}
+
+	cache.stats.ResultHits++
+
+	entry := value.(resultCacheEntry)
+	return resultCacheEntry{copyTokens(entry.tokens), copyResult(entry.result)}, cache.generation, true
+}
+
+// Store result made after getResult() gave generation
+func (cache *varnamCache) putResult(key resultCacheKey, entry resultCacheEntry, generation int) {
+	if cache == nil {
+		return
+	}
+
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	if generation != cache.generation {
+		return
+	}
+
+	cache.results.put(key, resultCacheEntry{copyTokens(entry.tokens), copyResult(entry.result)})
+}
+
+func (cache *varnamCache) getTokens(key tokenCacheKey) ([]Token, bool) {
+	if cache == nil {
+		return nil, false
+	}
+
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	value, found := cache.tokens.get(key)
+	if !found {
+		cache.stats.TokenMisses++
+		return nil, false
+	}
+
+	cache.stats.TokenHits++
+
+	return copyTokens(value.([]Token)), true
+}
+
+func (cache *varnamCache) putTokens(key tokenCacheKey, tokens []Token) {
+	if cache == nil {
+		return
+	}
+
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	cache.tokens.put(key, copyTokens(tokens))
+}
+
+// Symbol preferences loaded in the current generation.
+// The map is shared, callers shouldn't modify it.
+func (cache *varnamCache) getPreferences() (map[symbolPreferenceKey]int, int, bool) {
+	if cache == nil {
+		return nil, 0, false
+	}
+
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	if cache.preferences == nil || cache.preferencesGeneration != cache.generation {
+		return nil, cache.generation, false
+	}
+	return cache.preferences, cache.generation, true
+}
+
+// Store preferences loaded after getPreferences() gave generation
+func (cache *varnamCache) putPreferences(preferences map[symbolPreferenceKey]int, generation int) {
+	if cache == nil {
+		return
+	}
+
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	if generation != cache.generation {
+		return
+	}
+
+	cache.preferences = preferences
+	cache.preferencesGeneration = generation
+}
+
+// Whether there is any result that can be affected
+func (cache *varnamCache) hasResults() bool {
+	if cache == nil {
+		return false
+	}
+
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	return cache.results.order.Len() > 0
+}
+
+// Remove results affected by our own write to learnings.
+// Writes by others are found by writeDictTx().
+func (cache *varnamCache) invalidate(affected func(key resultCacheKey, entry resultCacheEntry) bool) {
+	if cache == nil {
+		return
+	}
+
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	cache.stats.Invalidations += cache.results.removeIf(func(key interface{}, value interface{}) bool {
+		return affected(key.(resultCacheKey), value.(resultCacheEntry))
+	})
+	cache.generation++
+}
+
+// Clear results if learnings changed since the last
+// check. Called right before our write is committed.
+func (cache *varnamCache) checkBeforeCommit() {
+	if cache == nil {
+		return
+	}
+
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	cache.checkDataVersion()
+}
+
+// data_version after our write is committed. Read
+// before finding if others wrote along with it.
+func (cache *varnamCache) readVersionAfterCommit() (int64, error) {
+	if cache == nil {
+		return 0, nil
+	}
+
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	return cache.readDataVersion()
+}
+
+// Store data_version read after our commit if only we
+// wrote, results affected by us are removed after this.
+// Else all results are removed.
+func (cache *varnamCache) afterCommit(version int64, err error, onlyOurs bool) {
+	if cache == nil {
+		return
+	}
+
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	if err != nil || !onlyOurs {
+		cache.stats.Invalidations += cache.results.order.Len()
+		cache.results.clear()
+		cache.generation++
+	}
+	if err == nil {
+		cache.dataVersion = version
+	}
+}
+
+// Remove all results & tokens
+func (cache *varnamCache) clear() {
+	if cache == nil {
+		return
+	}
+
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	cache.removeAll()
+}
+
+func (cache *varnamCache) close() {
+	if cache == nil {
+		return
+	}
+
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	if cache.versionConn != nil {
+		cache.versionConn.Close()
+		cache.versionConn = nil
+	}
+	if cache.vstVersionConn != nil {
+		cache.vstVersionConn.Close()
+		cache.vstVersionConn = nil
+	}
+}
+
+func copyTokens(tokens []Token) []Token {
+	if tokens == nil {
+		return nil
+	}
+
+	copied := make([]Token, len(tokens))
+	for i, token := range tokens {
+		copied[i] = token
+		copied[i].symbols = append([]Symbol(nil), token.symbols...)
+	}
+	return copied
+}
+
+func copySuggestions(sugs []Suggestion) []Suggestion {
+	if sugs == nil {
+		return nil
+	}
+	return append([]Suggestion(nil), sugs...)
+}
+
+func copyResult(result TransliterationResult) TransliterationResult {
+	result.ExactWords = copySuggestions(result.ExactWords)
+	result.ExactMatches = copySuggestions(result.ExactMatches)
+	result.DictionarySuggestions = copySuggestions(result.DictionarySuggestions)
+	result.PatternDictionarySuggestions = copySuggestions(result.PatternDictionarySuggestions)
+	result.TokenizerSuggestions = copySuggestions(result.TokenizerSuggestions)
+	result.GreedyTokenized = copySuggestions(result.GreedyTokenized)
+	return result
+}
+
+// CacheStats hit/miss counts of the transliteration cache
+func (varnam *Varnam) CacheStats() CacheStats {
+	cache := varnam.cache
+	if cache == nil {
+		return CacheStats{}
+	}
+
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	stats := cache.stats
+	stats.Results = cache.results.order.Len()
+	stats.Tokens = cache.tokens.order.Len()
+	return stats
+}
+
+// SetCacheSize change how many results & tokens are cached.
+// 0 disables caching. Cached items are removed.
+func (varnam *Varnam) SetCacheSize(size int) {
+	if varnam.cache == nil {
+		return
+	}
+
+	cache := varnam.cache
+
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	cache.results = newLRUCache(size)
+	cache.tokens = newLRUCache(size)
+	cache.generation++
+}
+
+// Learning/unlearning words affects results which have them or
+// can have them. Patterns are of the words, which can have been
+// found with the input too.
+func (varnam *Varnam) invalidateCachedWords(words []string, patterns []string) {
+	if len(words) > VARNAM_CACHE_INVALIDATE_LIMIT {
+		varnam.cache.clear()
+		return
+	}
+
+	var normalized []string
+	for _, word := range words {
+		normalized = append(normalized, varnam.normalizeText(word))
+	}
+
+	varnam.cache.invalidate(func(key resultCacheKey, entry resultCacheEntry) bool {
+		for _, word := range normalized {
+			if varnam.resultHasWord(entry, word) {
+				return true
+			}
+		}
+		for _, pattern := range patterns {
+			if patternMatchesInput(pattern, key.word) {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// Whether a dictionary lookup of the tokens can find word.
+// Dictionary is searched with values of the first token,
+// then with values of later tokens added on to it.
+func (varnam *Varnam) resultHasWord(entry resultCacheEntry, word string) bool {
+	for _, sug := range entry.result.Suggestions() {
+		if sug.Word == word {
+			return true
+		}
+	}
+
+	if len(entry.tokens) == 0 || entry.tokens[0].tokenType != VARNAM_TOKEN_SYMBOL {
+		return false
+	}
+
+	for _, symbol := range entry.tokens[0].symbols {
+		value := getSymbolValue(symbol, 0)
+		if strings.HasPrefix(word, value) || strings.HasPrefix(value, word) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Whether patterns dictionary lookup of input can
+// match pattern. Lookup is a case insensitive LIKE.
+func patternMatchesInput(pattern string, input string) bool {
+	// Wildcards, can't tell what it matches
+	if strings.ContainsAny(pattern, "%_") {
+		return true
+	}
+
+	pattern = strings.ToLower(pattern)
+	input = strings.ToLower(input)
+
+	return strings.HasPrefix(input, pattern) || strings.HasPrefix(pattern, input)
+}
+
+// Patterns of words in learnings & base dictionaries.
+// Looked up only if there are cached results.
+func (varnam *Varnam) getPatternsOfWords(words []string) []string {
+	var patterns []string
+
+	if !varnam.cache.hasResults() || len(words) == 0 || len(words) > VARNAM_CACHE_INVALIDATE_LIMIT {
+		return patterns
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(words)), ", ")
+
+	var args []interface{}
+	for _, word := range words {
+		args = append(args, word)
+	}
+
+	conns := []*sql.DB{varnam.dictConn}
+	for _, layer := range varnam.getBaseDictionaries() {
+		conns = append(conns, layer.conn)
+	}
+
+	for _, conn := range conns {
+		rows, err := conn.Query("SELECT pattern FROM patterns WHERE word_id IN (SELECT id FROM words WHERE word IN ("+placeholders+"))", args...)
+		if err != nil {
+			// Can't be precise, make sure nothing stale stays
+			varnam.cache.clear()
+			return nil
+		}
+
+		for rows.Next() {
+			var pattern string
+			rows.Scan(&pattern)
+			patterns = append(patterns, pattern)
+		}
+		rows.Close()
+	}
+
+	return patterns
+}
@@ -0,0 +1,126 @@
+package govarnam
+
+import (
+	"context"
+	"testing"
+)
+
+func TestCache(t *testing.T) {
+	varnam := makeTestVarnam("cache", simpleTestSymbols, 0)
+	defer varnam.Close()
+
+	ctx := context.Background()
+
+	transliterate := func(word string) TransliterationResult {
+		result, err := varnam.TransliterateWithOptions(ctx, word, varnam.GetOptions())
+		checkError(err)
+		return result
+	}
+
+	first := transliterate("kama")
+	assertEqual(t, first.GreedyTokenized[0].Word, "കമ")
+
+	// Changing the result doesn't change what's cached
+	first.GreedyTokenized[0].Word = "changed"
+
+	second := transliterate("kama")
+	assertEqual(t, second.GreedyTokenized[0].Word, "കമ")
+
+	stats := varnam.CacheStats()
+	assertEqual(t, stats.ResultMisses, 1)
+	assertEqual(t, stats.ResultHits, 1)
+	assertEqual(t, stats.Results, 1)
+
+	// Different config, different result
+	opts := varnam.GetOptions()
+	opts.TokenizerSuggestionsLimit = 1
+	_, err := varnam.TransliterateWithOptions(ctx, "kama", opts)
+	checkError(err)
+	assertEqual(t, varnam.CacheStats().ResultMisses, 2)
+
+	transliterate("pala")
+	transliterate("xy")
+
+	// Only results that can have the word are removed
+	checkError(varnam.Learn("കമല", 0))
+
+	stats = varnam.CacheStats()
+	assertEqual(t, stats.Invalidations, 2)
+	assertEqual(t, stats.Results, 2)
+
+	assertEqual(t, hasSuggestion(transliterate("kama").Suggestions(), "കമല"), true)
+
+	// Input starting with the trained pattern is affected too
+	checkError(varnam.Train("xyz", "കമല"))
+	assertEqual(t, hasSuggestion(transliterate("xy").PatternDictionarySuggestions, "കമല"), true)
+
+	hits := varnam.CacheStats().ResultHits
+	transliterate("pala")
+	assertEqual(t, varnam.CacheStats().ResultHits, hits+1)
+
+	// Patterns go along with the word
+	checkError(varnam.Unlearn("കമല"))
+	assertEqual(t, hasSuggestion(transliterate("kama").Suggestions(), "കമല"), false)
+	assertEqual(t, hasSuggestion(transliterate("xy").Suggestions(), "കമല"), false)
+
+	// Learnt by another instance, like another process would
+	other, err := Init(varnam.VSTPath, varnam.DictPath)
+	checkError(err)
+	defer other.Close()
+
+	checkError(other.Learn("പലക", 0))
+	assertEqual(t, hasSuggestion(transliterate("pala").Suggestions(), "പലക"), true)
+
+	// Disabled
+	varnam.SetCacheSize(0)
+	transliterate("kama")
+	transliterate("kama")
+	assertEqual(t, varnam.CacheStats().Results, 0)
+}
+
+func TestCacheTokens(t *testing.T) {
+	varnam := makeTestVarnam("cache-tokens", simpleTestSymbols, 0)
+	defer varnam.Close()
+
+	assertEqual(t, varnam.TransliterateGreedyTokenized("kamala")[0].Word, "കമല")
+	assertEqual(t, varnam.TransliterateGreedyTokenized("kamala")[0].Word, "കമല")
+
+	stats := varnam.CacheStats()
+	assertEqual(t, stats.TokenMisses, 1)
+	assertEqual(t, stats.TokenHits, 1)
+
+	// Digits are tokenized differently
+	varnam.UpdateOptions(func(opts *Options) {
+		opts.IndicDigits = true
+	})
+	varnam.TransliterateGreedyTokenized("kamala")
+	assertEqual(t, varnam.CacheStats().TokenMisses, 2)
+}
+
+// Typing a word, and typing it again after erasing
+func benchmarkRepeatedPrefix(b *testing.B, varnam *Varnam) {
+	ctx := context.Background()
+	word := "thiruvananthapuram"
+
+	for i := 0; i < b.N; i++ {
+		for end := 1; end <= len(word); end++ {
+			_, err := varnam.TransliterateWithOptions(ctx, word[:end], varnam.GetOptions())
+			checkError(err)
+		}
+	}
+}
+
+func BenchmarkMLRepeatedPrefix(b *testing.B) {
+	varnam := getVarnamInstance("ml")
+	defer varnam.SetCacheSize(VARNAM_CACHE_SIZE)
+
+	b.Run("Cached", func(b *testing.B) {
+		varnam.SetCacheSize(VARNAM_CACHE_SIZE)
+		benchmarkRepeatedPrefix(b, varnam)
+	})
+
+	b.Run("Uncached", func(b *testing.B) {
+		varnam.SetCacheSize(0)
+		benchmarkRepeatedPrefix(b, varnam)
+	})
+}
@@ -2,9 +2,8 @@ package govarnam
 
 import (
 	"context"
-	"fmt"
-	"log"
 	"time"
+	"unicode/utf8"
 )
 
 type channelDictionaryResult struct {
@@ -23,9 +22,7 @@ func (varnam *Varnam) channelTokenizeWord(ctx context.Context, word string, matc
 
 		tokens := varnam.tokenizeWord(ctx, word, matchType, partial)
 
-		if LOG_TIME_TAKEN {
-			log.Printf("%s took %v\n", "channelTokenizeWord", time.Since(start))
-		}
+		varnam.logTimeTaken("channelTokenizeWord", start)
 
 		channel <- tokens
 		close(channel)
@@ -42,9 +39,7 @@ func (varnam *Varnam) channelTokensToSuggestions(ctx context.Context, tokens *[]
 
 		sugs := varnam.tokensToSuggestions(ctx, tokens, false, limit)
 
-		if LOG_TIME_TAKEN {
-			log.Printf("%s took %v\n", "channelTokensToSuggestions", time.Since(start))
-		}
+		varnam.logTimeTaken("channelTokensToSuggestions", start)
 
 		channel <- sugs
 		close(channel)
@@ -59,11 +54,9 @@ func (varnam *Varnam) channelTokensToGreedySuggestions(ctx context.Context, toke
 	default:
 		start := time.Now()
 
-		sugs := varnam.tokensToSuggestions(ctx, tokens, false, varnam.TokenizerSuggestionsLimit)
+		sugs := varnam.tokensToSuggestions(ctx, tokens, false, varnam.options(ctx).TokenizerSuggestionsLimit)
 
-		if LOG_TIME_TAKEN {
-			log.Printf("%s took %v\n", "channelTokensToGreedySuggestions", time.Since(start))
-		}
+		varnam.logTimeTaken("channelTokensToGreedySuggestions", start)
 
 		channel <- sugs
 		close(channel)
@@ -86,9 +79,7 @@ func (varnam *Varnam) channelGetFromDictionary(ctx context.Context, word string,
 
 		dictResult := varnam.getFromDictionary(ctx, tokens)
 
-		if varnam.Debug {
-			fmt.Println("Dictionary results:", dictResult)
-		}
+		varnam.debug("Dictionary results", "results", dictResult)
 
 		if len(dictResult.exactMatches) > 0 {
 			start := time.Now()
@@ -97,9 +88,7 @@ func (varnam *Varnam) channelGetFromDictionary(ctx context.Context, word string,
 			// with help of this function's result
 			moreFromDict := varnam.getMoreFromDictionary(ctx, dictResult.exactMatches)
 
-			if varnam.Debug {
-				fmt.Println("More dictionary results:", moreFromDict)
-			}
+			varnam.debug("More dictionary results", "results", moreFromDict)
 
 			// dictResult.exactMatches will have both matches and exact words.
 			// getMoreFromDictionary() will separate out the exact words.
@@ -121,32 +110,30 @@ func (varnam *Varnam) channelGetFromDictionary(ctx context.Context, word string,
 				moreSuggestions = append(moreSuggestions, sugSet...)
 			}
 
-			if LOG_TIME_TAKEN {
-				log.Printf("%s took %v\n", "getMoreFromDictionary", time.Since(start))
-			}
+			varnam.logTimeTaken("getMoreFromDictionary", start)
 		}
 
 		if len(dictResult.partialMatches) > 0 {
 			// Tokenize the word after the longest match found in dictionary
 			restOfWord := string([]rune(word)[dictResult.longestMatchPosition+1:])
 
+			for i := range dictResult.partialMatches {
+				dictResult.partialMatches[i].MatchedLength = dictResult.longestMatchPosition + 1
+			}
+
 			start := time.Now()
 
 			moreSuggestions = varnam.tokenizeRestOfWord(
 				ctx,
 				restOfWord,
 				dictResult.partialMatches,
-				varnam.DictionarySuggestionsLimit,
+				varnam.options(ctx).DictionarySuggestionsLimit,
 			)
 
-			if LOG_TIME_TAKEN {
-				log.Printf("%s took %v\n", "tokenizeRestOfWord", time.Since(start))
-			}
+			varnam.logTimeTaken("tokenizeRestOfWord", start)
 		}
 
-		if LOG_TIME_TAKEN {
-			log.Printf("%s took %v\n", "channelGetFromDictionary", time.Since(start))
-		}
+		varnam.logTimeTaken("channelGetFromDictionary", start)
 
 		channel <- channelDictionaryResult{
 			exactWords,
@@ -173,26 +160,28 @@ func (varnam *Varnam) channelGetFromPatternDictionary(ctx context.Context, word
 		patternDictSugs := varnam.getFromPatternDictionary(ctx, word)
 
 		if len(patternDictSugs) > 0 {
-			if varnam.Debug {
-				fmt.Println("Pattern dictionary results:", patternDictSugs)
-			}
+			varnam.debug("Pattern dictionary results", "results", patternDictSugs)
 
 			var partialMatches []PatternDictionarySuggestion
 
+			// Length of patterns is in characters, not bytes
+			wordLength := utf8.RuneCountInString(word)
+
 			for _, match := range patternDictSugs {
-				if match.Length < len(word) {
+				if match.Length < wordLength {
 					sug := &match.Sug
+					sug.MatchedLength = match.Length
 
 					// Increase weight on length matched.
 					// 50 because half of 100%
 					sug.Weight += match.Length * 50
 
-					for _, cb := range varnam.PatternWordPartializers {
+					for _, cb := range varnam.getPatternWordPartializers() {
 						cb(sug)
 					}
 
 					partialMatches = append(partialMatches, match)
-				} else if match.Length == len(word) {
+				} else if match.Length == wordLength {
 					// Same length, exact word matched
 					exactWords = append(exactWords, match.Sug)
 				} else {
@@ -200,14 +189,14 @@ func (varnam *Varnam) channelGetFromPatternDictionary(ctx context.Context, word
 				}
 			}
 
-			perMatchLimit := varnam.PatternDictionarySuggestionsLimit
+			perMatchLimit := varnam.options(ctx).PatternDictionarySuggestionsLimit
 
 			if len(partialMatches) > 0 && perMatchLimit > len(partialMatches) {
 				perMatchLimit = perMatchLimit / len(partialMatches)
 			}
 
 			for i := range partialMatches {
-				restOfWord := word[partialMatches[i].Length:]
+				restOfWord := string([]rune(word)[partialMatches[i].Length:])
 
 				filled := varnam.tokenizeRestOfWord(
 					ctx,
@@ -218,15 +207,13 @@ func (varnam *Varnam) channelGetFromPatternDictionary(ctx context.Context, word
 
 				moreSuggestions = append(moreSuggestions, filled...)
 
-				if len(moreSuggestions) >= varnam.PatternDictionarySuggestionsLimit {
+				if len(moreSuggestions) >= varnam.options(ctx).PatternDictionarySuggestionsLimit {
 					break
 				}
 			}
 		}
 
-		if LOG_TIME_TAKEN {
-			log.Printf("%s took %v\n", "channelGetFromPatternDictionary", time.Since(start))
-		}
+		varnam.logTimeTaken("channelGetFromPatternDictionary", start)
 
 		channel <- channelDictionaryResult{
 			exactWords,
@@ -247,9 +234,7 @@ func (varnam *Varnam) channelGetMoreFromDictionary(ctx context.Context, sugs []S
 
 		result := varnam.getMoreFromDictionary(ctx, sugs)
 
-		if LOG_TIME_TAKEN {
-			log.Printf("%s took %v\n", "channelGetMoreFromDictionary", time.Since(start))
-		}
+		varnam.logTimeTaken("channelGetMoreFromDictionary", start)
 
 		channel <- result
 		close(channel)
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"path"
+	"time"
 )
 
 // Compile-time variables.
@@ -22,11 +23,45 @@ const STRUCT_INT_DEFAULT_VALUE = -1
 const ZWNJ = "\u200c"
 const ZWJ = "\u200d"
 
+/* Status codes, same as in c-shared.h */
+const VARNAM_SUCCESS = 0
+const VARNAM_MISUSE = 1
+const VARNAM_ERROR = 2
+const VARNAM_CANCELLED = 3
+const VARNAM_WORD_NOT_FOUND = 4
+const VARNAM_SINGLE_CONJUNCT = 5
+const VARNAM_NOTHING_TO_LEARN = 6
+const VARNAM_VST_SCHEMA_MISMATCH = 7
+const VARNAM_DB_LOCKED = 8
+const VARNAM_WORD_BLOCKED = 9
+
+/* Log levels, same as of log/slog */
+const VARNAM_LOG_DEBUG = -4
+const VARNAM_LOG_INFO = 0
+const VARNAM_LOG_WARN = 4
+const VARNAM_LOG_ERROR = 8
+
 /* Pattern matching */
 const VARNAM_MATCH_EXACT = 1
 const VARNAM_MATCH_POSSIBILITY = 2
 const VARNAM_MATCH_ALL = 3
 
+/* Transliteration stages, bitmask of unfinished ones is given on deadline */
+const VARNAM_STAGE_GREEDY_TOKENIZED = (1 << 0)
+const VARNAM_STAGE_DICTIONARY = (1 << 1)
+const VARNAM_STAGE_PATTERN_DICTIONARY = (1 << 2)
+const VARNAM_STAGE_TOKENIZER = (1 << 3)
+const VARNAM_STAGE_ALL = VARNAM_STAGE_GREEDY_TOKENIZED | VARNAM_STAGE_DICTIONARY | VARNAM_STAGE_PATTERN_DICTIONARY | VARNAM_STAGE_TOKENIZER
+
+/* Source of a suggestion, same as in c-shared.h */
+const VARNAM_SOURCE_EXACT_WORD = 1
+const VARNAM_SOURCE_EXACT_MATCH = 2
+const VARNAM_SOURCE_DICTIONARY = 3
+const VARNAM_SOURCE_PATTERN_DICTIONARY = 4
+const VARNAM_SOURCE_TOKENIZER = 5
+const VARNAM_SOURCE_GREEDY_TOKENIZED = 6
+const VARNAM_SOURCE_SHORTCUT = 7
+
 /* Type of tokens */
 const VARNAM_TOKEN_CHAR = 1   // Non-lang characters like A, B, 1, * etc.
 const VARNAM_TOKEN_SYMBOL = 2 // Lang characters
@@ -34,6 +69,15 @@ const VARNAM_TOKEN_SYMBOL = 2 // Lang characters
 /* A symbol token's maximum possible weight value */
 const VARNAM_TOKEN_BASIC_WEIGHT = 10
 
+// VARNAM_SYMBOL_PREFERENCE_WEIGHT Weight added to a symbol each time the
+// user chose it. A possibility match chosen 4 times more than the exact
+// match with it (weight 200) gets ahead of it.
+const VARNAM_SYMBOL_PREFERENCE_WEIGHT = 50
+
+// VARNAM_SYMBOL_PREFERENCE_MAX_COUNT Times a symbol being chosen is
+// counted. Choosing another symbol this many times gets it ahead again.
+const VARNAM_SYMBOL_PREFERENCE_MAX_COUNT = 6
+
 /* Available type of symbol tokens */
 const VARNAM_SYMBOL_VOWEL = 1
 const VARNAM_SYMBOL_CONSONANT = 2
@@ -58,6 +102,17 @@ const VARNAM_TOKEN_ACCEPT_IF_ENDS_WITH = 3
 // VARNAM_LEARNT_WORD_MIN_WEIGHT Minimum weight/confidence for learnt words.
 const VARNAM_LEARNT_WORD_MIN_WEIGHT = 30
 
+// VARNAM_REJECTION_PENALTY part of weight a suggestion loses
+// for an input each time it's passed over, upto
+// VARNAM_REJECTION_MAX_PENALTY. So a word learnt far more than
+// others stays ahead even if it was passed over a few times.
+const VARNAM_REJECTION_PENALTY = 0.2
+const VARNAM_REJECTION_MAX_PENALTY = 0.6
+
+// VARNAM_CORPUS_MAX_WORDS distinct words counted in memory by
+// default while ingesting a corpus. See CorpusOptions.MaxWords
+const VARNAM_CORPUS_MAX_WORDS = 1000000
+
 const CHIL_TAG = "chill"
 
 /* VST creation */
@@ -74,6 +129,44 @@ const VARNAM_METADATA_SCHEME_AUTHOR = "scheme-author"
 const VARNAM_METADATA_SCHEME_COMPILED_DATE = "scheme-compiled-date"
 const VARNAM_METADATA_SCHEME_STABLE = "scheme-stable"
 
+// VARNAM_METADATA_NORMALIZATION_VERSION learnings DB metadata key
+// storing the version of normalization words went through
+const VARNAM_METADATA_NORMALIZATION_VERSION = "normalization-version"
+
+// VARNAM_NORMALIZATION_VERSION Increment when normalizeWord() changes
+// so that existing learnings gets normalized again
+const VARNAM_NORMALIZATION_VERSION = 2
+
+// VARNAM_BASE_DICTIONARY_WEIGHT_SCALE weight scale of
+// the base dictionary found along with VST
+const VARNAM_BASE_DICTIONARY_WEIGHT_SCALE = 1.0
+
+// VARNAM_INPUT_MAX_LENGTH inputs longer than this many characters
+// only get greedy tokenized. Dictionary lookups & tokenizer
+// suggestions of such inputs take too long to be of any use.
+// Stages left out are in TransliterationResult.SkippedStages
+const VARNAM_INPUT_MAX_LENGTH = 100
+
+// VARNAM_CACHE_SIZE count of transliteration results &
+// tokens cached by default. See SetCacheSize()
+const VARNAM_CACHE_SIZE = 512
+
+// VARNAM_CACHE_INVALIDATE_LIMIT learning more words than this
+// at once clears cached results instead of finding the affected
+const VARNAM_CACHE_INVALIDATE_LIMIT = 100
+
+// VARNAM_DB_BUSY_TIMEOUT time to wait for a lock held by
+// another process (say an IME & the CLI) before giving up
+var VARNAM_DB_BUSY_TIMEOUT = 3 * time.Second
+
+// VARNAM_DB_WRITE_RETRIES times a write to learnings DB is
+// retried if it's still locked after busy timeout
+const VARNAM_DB_WRITE_RETRIES = 5
+
+// VARNAM_DB_WRITE_RETRY_BACKOFF wait before first retry.
+// Doubles on every retry.
+const VARNAM_DB_WRITE_RETRY_BACKOFF = 50 * time.Millisecond
+
 var VARNAM_VST_DIR = os.Getenv("VARNAM_VST_DIR")
 var VARNAM_LEARNINGS_DIR = os.Getenv("VARNAM_LEARNINGS_DIR")
 
@@ -82,6 +175,12 @@ func SetVSTLookupDir(path string) {
 	VARNAM_VST_DIR = path
 }
 
+// SetDBBusyTimeout This overrides the default busy timeout.
+// Applies to databases opened after this.
+func SetDBBusyTimeout(timeout time.Duration) {
+	VARNAM_DB_BUSY_TIMEOUT = timeout
+}
+
 // SetVSTLookupDir This overrides the environment variable
 func SetLearningsDir(path string) {
 	VARNAM_LEARNINGS_DIR = path
@@ -142,4 +241,13 @@ func findLearningsFilePath(langCode string) string {
 	return loc
 }
 
+// Base dictionary is shipped in the same directory as VST
+func findBaseDictionaryPath(vstPath string, langCode string) string {
+	return path.Join(path.Dir(vstPath), langCode+".base.learnings")
+}
+
 var LOG_TIME_TAKEN = os.Getenv("GOVARNAM_LOG_TIME_TAKEN") != ""
+
+// VARNAM_DICTIONARY_INDEX dictionaries are indexed in
+// memory when opened if set. See SetDictionaryIndex()
+var VARNAM_DICTIONARY_INDEX = os.Getenv("VARNAM_DICTIONARY_INDEX") != ""
@@ -0,0 +1,263 @@
+package govarnam
+
+/**
+ * govarnam - An Indian language transliteration library
+ * Copyright Subin Siby <mail at subinsb (.) com>, 2021
+ * Licensed under AGPL-3.0-only. See LICENSE.txt
+ */
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+// CorpusOptions filters for IngestCorpus()
+type CorpusOptions struct {
+	// Words seen fewer times are left out
+	MinCount int
+
+	// Words with more characters are left out. 0 for no limit.
+	MaxLength int
+
+	// Distinct words counted in memory at most.
+	// VARNAM_CORPUS_MAX_WORDS if 0.
+	MaxWords int
+}
+
+// CorpusStatus result of IngestCorpus()
+type CorpusStatus struct {
+	Files int
+
+	// Words read, including ones filtered out
+	Words int
+
+	// Distinct words counted
+	Distinct int
+
+	// Counts of words, so their weights, are lower than the
+	// actual by at most this. It's the times counts were
+	// decreased to make space, 0 if MaxWords wasn't reached.
+	MaxUndercount int
+
+	// Of words that passed the filters
+	LearnStatus LearnStatus
+}
+
+// Word counts in bounded memory (Misra-Gries). When there's no
+// space for a new word, every count is decreased by one & words
+// reaching 0 are dropped. The new word is counted if that made
+// space. Counts are lower than the actual by at most the times
+// counts were decreased, frequent words are kept.
+type wordCounter struct {
+	counts   map[string]int
+	maxWords int
+
+	decreases int
+}
+
+func newWordCounter(maxWords int) *wordCounter {
+	return &wordCounter{map[string]int{}, maxWords, 0}
+}
+
+func (counter *wordCounter) add(word string) {
+	if _, ok := counter.counts[word]; ok || len(counter.counts) < counter.maxWords {
+		counter.counts[word]++
+		return
+	}
+
+	counter.decreases++
+
+	for w, count := range counter.counts {
+		if count == 1 {
+			delete(counter.counts, w)
+		} else {
+			counter.counts[w] = count - 1
+		}
+	}
+
+	if len(counter.counts) < counter.maxWords {
+		counter.counts[word] = 1
+	}
+}
+
+// Whether r can be in a word of the language. Everything
+// else like punctuation, digits & Latin separates words.
+func (varnam *Varnam) isCorpusWordRune(r rune) bool {
+	if string(r) == ZWJ || string(r) == ZWNJ {
+		return true
+	}
+	return unicode.In(r, &varnam.LangRules.UnicodeBlock) && (unicode.IsLetter(r) || unicode.IsMark(r))
+}
+
+// Split text read from reader into words of the language
+func (varnam *Varnam) scanCorpus(ctx context.Context, reader io.Reader, opts CorpusOptions, counter *wordCounter, status *CorpusStatus) error {
+	bufReader := bufio.NewReader(reader)
+
+	var (
+		word    strings.Builder
+		length  int
+		tooLong bool
+	)
+
+	flush := func() {
+		// Joiners only join characters
+		w := strings.Trim(word.String(), ZWJ+ZWNJ)
+
+		if tooLong || w != "" {
+			status.Words++
+		}
+		if !tooLong && w != "" {
+			counter.add(varnam.sanitizeWord(w))
+		}
+		word.Reset()
+		length = 0
+		tooLong = false
+	}
+
+	for read := 0; ; read++ {
+		if read%100000 == 0 && ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		r, _, err := bufReader.ReadRune()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		if !varnam.isCorpusWordRune(r) {
+			flush()
+			continue
+		}
+
+		length++
+		if opts.MaxLength > 0 && length > opts.MaxLength {
+			// Not kept in memory
+			tooLong = true
+			continue
+		}
+		word.WriteRune(r)
+	}
+
+	flush()
+
+	return nil
+}
+
+func (varnam *Varnam) scanCorpusFile(ctx context.Context, filePath string, opts CorpusOptions, counter *wordCounter, status *CorpusStatus) error {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	var reader io.Reader = file
+
+	if strings.HasSuffix(filePath, ".gz") {
+		gzipReader, err := gzip.NewReader(file)
+		if err != nil {
+			return err
+		}
+		defer gzipReader.Close()
+
+		reader = gzipReader
+	}
+
+	varnam.getLogger().Info("Reading corpus", "file", filePath)
+
+	status.Files++
+
+	return varnam.scanCorpus(ctx, reader, opts, counter, status)
+}
+
+// IngestCorpus learn words of the language in plain text or
+// gzip (.gz) files. Files in directories are read too. Words
+// are learnt with the times they're seen as weight.
+func (varnam *Varnam) IngestCorpus(ctx context.Context, paths []string, opts CorpusOptions) (CorpusStatus, error) {
+	var status CorpusStatus
+
+	if len(varnam.LangRules.UnicodeBlock.R16) == 0 && len(varnam.LangRules.UnicodeBlock.R32) == 0 {
+		return status, &Error{Code: VARNAM_MISUSE, Message: "Characters of the language aren't known, can't find words"}
+	}
+
+	if opts.MaxWords <= 0 {
+		opts.MaxWords = VARNAM_CORPUS_MAX_WORDS
+	}
+
+	counter := newWordCounter(opts.MaxWords)
+
+	for _, corpusPath := range paths {
+		err := filepath.Walk(corpusPath, func(filePath string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if !info.Mode().IsRegular() {
+				return nil
+			}
+			return varnam.scanCorpusFile(ctx, filePath, opts, counter, &status)
+		})
+		if err != nil {
+			return status, err
+		}
+	}
+
+	status.Distinct = len(counter.counts)
+	status.MaxUndercount = counter.decreases
+
+	var words []WordInfo
+	for word, count := range counter.counts {
+		if count >= opts.MinCount {
+			words = append(words, WordInfo{0, word, count, 0})
+		}
+	}
+
+	// Most frequent first
+	sort.Slice(words, func(i, j int) bool {
+		if words[i].weight != words[j].weight {
+			return words[i].weight > words[j].weight
+		}
+		return words[i].word < words[j].word
+	})
+
+	limitVariableNumber, err := getDBLimit(varnam.dictConn, sqlite3.SQLITE_LIMIT_VARIABLE_NUMBER)
+	if err != nil {
+		return status, err
+	}
+
+	// We have 2 fields per item, word and weight
+	insertsPerTransaction := limitVariableNumber / 2
+
+	for start := 0; start < len(words); start += insertsPerTransaction {
+		if ctx.Err() != nil {
+			return status, ctx.Err()
+		}
+
+		end := start + insertsPerTransaction
+		if end > len(words) {
+			end = len(words)
+		}
+
+		learnStatus, err := varnam.LearnMany(words[start:end])
+		if err != nil {
+			return status, err
+		}
+
+		status.LearnStatus.TotalWords += learnStatus.TotalWords
+		status.LearnStatus.FailedWords += learnStatus.FailedWords
+
+		varnam.getLogger().Info("Learning corpus words", "processed", end)
+	}
+
+	return status, nil
+}
@@ -0,0 +1,104 @@
+package govarnam
+
+import (
+	"compress/gzip"
+	"context"
+	"os"
+	"path"
+	"testing"
+)
+
+func TestWordCounter(t *testing.T) {
+	counter := newWordCounter(2)
+
+	for _, word := range []string{"a", "a", "b", "c"} {
+		counter.add(word)
+	}
+
+	// c made everything go down by one,
+	// & is counted in the space b left
+	assertEqual(t, len(counter.counts), 2)
+	assertEqual(t, counter.counts["a"], 1)
+	assertEqual(t, counter.counts["c"], 1)
+	assertEqual(t, counter.decreases, 1)
+
+	counter.add("a")
+	counter.add("c")
+	assertEqual(t, counter.counts["a"], 2)
+	assertEqual(t, counter.counts["c"], 2)
+
+	// No space made, d isn't counted
+	counter.add("d")
+	assertEqual(t, len(counter.counts), 2)
+	assertEqual(t, counter.counts["a"], 1)
+	assertEqual(t, counter.counts["c"], 1)
+	assertEqual(t, counter.decreases, 2)
+
+	// a & c were seen 3 times, d once
+	for word, count := range map[string]int{"a": 3, "c": 3, "d": 1} {
+		assertEqual(t, count-counter.counts[word] <= counter.decreases, true)
+	}
+}
+
+func TestIngestCorpus(t *testing.T) {
+	vstPath := path.Join(testTempDir, "corpus.vst")
+
+	vm, err := VMInit(vstPath)
+	checkError(err)
+	for pattern, value := range map[string]string{"ka": "ക", "ma": "മ", "la": "ല", "pa": "പ"} {
+		checkError(vm.VMCreateToken(pattern, value, "", "", "", VARNAM_SYMBOL_CONSONANT, VARNAM_MATCH_EXACT, 0, 0, false))
+	}
+	checkError(vm.VMSetSchemeDetails(SchemeDetails{Identifier: "ml", LangCode: "ml", DisplayName: "Malayalam"}))
+	vm.Close()
+
+	varnam, err := Init(vstPath, path.Join(testTempDir, "corpus.learnings"))
+	checkError(err)
+	defer varnam.Close()
+
+	corpusDir := path.Join(testTempDir, "corpus")
+	checkError(os.MkdirAll(path.Join(corpusDir, "archive"), 0755))
+
+	checkError(os.WriteFile(path.Join(corpusDir, "a.txt"), []byte("കമല, കമല! mala\nകമല. പലക 123 കമലകമലകമല"), 0644))
+
+	gzipFile, err := os.Create(path.Join(corpusDir, "archive", "b.txt.gz"))
+	checkError(err)
+	gzipWriter := gzip.NewWriter(gzipFile)
+	_, err = gzipWriter.Write([]byte("പലക‌ (കമല)"))
+	checkError(err)
+	checkError(gzipWriter.Close())
+	checkError(gzipFile.Close())
+
+	ctx := context.Background()
+
+	status, err := varnam.IngestCorpus(ctx, []string{corpusDir}, CorpusOptions{MinCount: 2, MaxLength: 6})
+	checkError(err)
+
+	assertEqual(t, status.Files, 2)
+	assertEqual(t, status.Words, 7)
+
+	// Too long one isn't counted
+	assertEqual(t, status.Distinct, 2)
+	assertEqual(t, status.MaxUndercount, 0)
+	assertEqual(t, status.LearnStatus, LearnStatus{2, 0})
+
+	wordInfo, err := varnam.getWordInfo("കമല")
+	checkError(err)
+	assertEqual(t, wordInfo.weight, 4)
+
+	wordInfo, err = varnam.getWordInfo("പലക")
+	checkError(err)
+	assertEqual(t, wordInfo.weight, 2)
+
+	// Seen once
+	status, err = varnam.IngestCorpus(ctx, []string{path.Join(corpusDir, "archive")}, CorpusOptions{MinCount: 2})
+	checkError(err)
+	assertEqual(t, status.Words, 2)
+	assertEqual(t, status.LearnStatus.TotalWords, 0)
+
+	// കമല takes the place of പലക
+	status, err = varnam.IngestCorpus(ctx, []string{path.Join(corpusDir, "archive")}, CorpusOptions{MinCount: 1, MaxWords: 1})
+	checkError(err)
+	assertEqual(t, status.Distinct, 1)
+	assertEqual(t, status.MaxUndercount, 1)
+	assertEqual(t, status.LearnStatus.TotalWords, 1)
+}
@@ -0,0 +1,73 @@
+package govarnam
+
+/**
+ * govarnam - An Indian language transliteration library
+ * Copyright Subin Siby <mail at subinsb (.) com>, 2021
+ * Licensed under AGPL-3.0-only. See LICENSE.txt
+ */
+
+import (
+	"context"
+	"sync"
+	"unicode/utf8"
+)
+
+// TokenizerCursor where making tokenizer suggestions of an input
+// stopped. Get more with NextTokenizerSuggestions() when
+// TokenizerSuggestionsLimit isn't enough.
+type TokenizerCursor struct {
+	Input string
+
+	mutex      sync.Mutex
+	enumerator *tokenEnumerator
+	rejections map[string]int
+}
+
+// NewTokenizerCursor start making tokenizer suggestions of word.
+// The first TokenizerSuggestionsLimit of them are the ones
+// in TransliterationResult.TokenizerSuggestions.
+func (varnam *Varnam) NewTokenizerCursor(ctx context.Context, word string) (*TokenizerCursor, error) {
+	ctx = varnam.withOptions(ctx)
+	ctx = withLookupErrors(ctx)
+
+	tokens := varnam.tokenizeWord(ctx, word, VARNAM_MATCH_ALL, false)
+
+	rejections := varnam.getRejections(ctx, word)
+
+	if err := getLookupError(ctx); err != nil {
+		return nil, err
+	}
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	return &TokenizerCursor{
+		Input:      word,
+		enumerator: varnam.newTokenEnumerator(*tokens, false),
+		rejections: rejections,
+	}, nil
+}
+
+// NextTokenizerSuggestions get upto n suggestions after the ones
+// cursor gave before. Suggestions of a call are sorted by weight.
+// Less than n are returned when there are no more, or when
+// some of them are blocked words.
+func (varnam *Varnam) NextTokenizerSuggestions(cursor *TokenizerCursor, n int) []Suggestion {
+	cursor.mutex.Lock()
+	defer cursor.mutex.Unlock()
+
+	sugs := sortSuggestions(cursor.enumerator.next(n), cursor.rejections)
+	sugs = varnam.removeBlockedSuggestions(context.Background(), sugs)
+
+	annotateResult(&TransliterationResult{TokenizerSuggestions: sugs}, utf8.RuneCountInString(cursor.Input))
+
+	return sugs
+}
+
+// Done whether all suggestions have been given
+func (cursor *TokenizerCursor) Done() bool {
+	cursor.mutex.Lock()
+	defer cursor.mutex.Unlock()
+
+	return cursor.enumerator.done
+}
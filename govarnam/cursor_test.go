@@ -0,0 +1,70 @@
+package govarnam
+
+import (
+	"context"
+	"testing"
+)
+
+func TestTokenizerCursor(t *testing.T) {
+	varnam := makeTestVarnam("cursor", kalamaTestSymbols, 100)
+	defer varnam.Close()
+
+	varnam.UpdateOptions(func(opts *Options) {
+		opts.TokenizerSuggestionsLimit = 3
+	})
+
+	result, err := varnam.TransliterateWithOptions(context.Background(), "kalama", varnam.GetOptions())
+	checkError(err)
+
+	cursor, err := varnam.NewTokenizerCursor(context.Background(), "kalama")
+	checkError(err)
+
+	// First page is what transliteration gives
+	sugs := varnam.NextTokenizerSuggestions(cursor, 3)
+	assertSameWords(t, sugs, result.TokenizerSuggestions)
+	assertEqual(t, sugs[0].Source, VARNAM_SOURCE_TOKENIZER)
+
+	seen := map[string]bool{}
+	for _, sug := range sugs {
+		seen[sug.Word] = true
+	}
+
+	var secondPage []Suggestion
+
+	// 2 possibilities for 3 tokens
+	for i, expected := range []int{3, 2, 0} {
+		sugs = varnam.NextTokenizerSuggestions(cursor, 3)
+		assertEqual(t, len(sugs), expected)
+
+		if i == 0 {
+			secondPage = sugs
+		}
+
+		for _, sug := range sugs {
+			assertEqual(t, seen[sug.Word], false)
+			seen[sug.Word] = true
+		}
+	}
+
+	assertEqual(t, len(seen), 8)
+	assertEqual(t, cursor.Done(), true)
+
+	// Blocked words are left out of later pages too
+	checkError(varnam.BlockWord(secondPage[1].Word))
+
+	cursor, err = varnam.NewTokenizerCursor(context.Background(), "kalama")
+	checkError(err)
+
+	varnam.NextTokenizerSuggestions(cursor, 3)
+	sugs = varnam.NextTokenizerSuggestions(cursor, 3)
+	assertEqual(t, len(sugs), 2)
+	assertEqual(t, hasSuggestion(sugs, secondPage[1].Word), false)
+	assertEqual(t, hasSuggestion(sugs, secondPage[0].Word), true)
+	checkError(varnam.UnblockWord(secondPage[1].Word))
+
+	// Cancelled
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = varnam.NewTokenizerCursor(ctx, "kalama")
+	assertEqual(t, err, context.Canceled)
+}
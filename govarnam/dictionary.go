@@ -8,13 +8,19 @@ package govarnam
 
 import (
 	"context"
+	sql "database/sql"
 	"embed"
+	"errors"
 	"fmt"
 	"io/fs"
-	"log"
 	"os"
 	"path"
+	"strconv"
+	"strings"
 	"time"
+	"unicode/utf8"
+
+	"github.com/mattn/go-sqlite3"
 )
 
 //go:embed migrations/*.sql
@@ -58,14 +64,17 @@ func (varnam *Varnam) InitDict(dictPath string) error {
 	var err error
 
 	if !fileExists(dictPath) {
-		log.Printf("Making Varnam Learnings Dir for %s\n", dictPath)
+		varnam.getLogger().Info("Making Varnam Learnings Dir", "path", dictPath)
 		err := os.MkdirAll(path.Dir(dictPath), 0750)
 		if err != nil {
 			return err
 		}
 	}
 
-	varnam.dictConn, err = openDB(dictPath)
+	// WAL lets readers & a writer work at the same time.
+	// BEGIN IMMEDIATE takes the write lock upfront so that
+	// two processes won't deadlock upgrading their read locks.
+	varnam.dictConn, err = openDB(dictPath + "?_journal_mode=WAL&_txlock=immediate&" + busyTimeoutParam())
 	if err != nil {
 		return err
 	}
@@ -85,15 +94,122 @@ func (varnam *Varnam) InitDict(dictPath string) error {
 
 	ranMigrations, err := mg.Run()
 	if ranMigrations != 0 {
-		log.Printf("ran %d migrations", ranMigrations)
+		varnam.getLogger().Info("Ran migrations", "count", ranMigrations)
+	}
+	if err != nil {
+		return err
+	}
+
+	err = varnam.migrateNormalization()
+	if err != nil {
+		return err
 	}
 
 	// Since SQLite v3.12.0, default page size is 4096
 	varnam.dictConn.Exec("PRAGMA page_size=4096;")
-	// WAL makes writes & reads happen concurrently => significantly fast
-	varnam.dictConn.Exec("PRAGMA journal_mode=wal;")
 
-	return err
+	if VARNAM_DICTIONARY_INDEX {
+		varnam.dictIndex, err = newDictionaryIndex(varnam.dictConn, true)
+		if err != nil {
+			varnam.dictIndex.close()
+			varnam.dictIndex = nil
+			return dictError(err)
+		}
+	}
+
+	return nil
+}
+
+// Check if the error is because DB is locked by another connection
+func isDBLocked(err error) bool {
+	var sqliteErr sqlite3.Error
+	if errors.As(err, &sqliteErr) {
+		return sqliteErr.Code == sqlite3.ErrBusy || sqliteErr.Code == sqlite3.ErrLocked
+	}
+	return false
+}
+
+// Run writes to learnings DB in a transaction.
+// If another process is holding the lock even after busy timeout,
+// retry with backoff.
+func (varnam *Varnam) writeDict(ctx context.Context, write func(tx *sql.Tx) error) error {
+	var err error
+
+	backoff := VARNAM_DB_WRITE_RETRY_BACKOFF
+
+	for attempt := 0; attempt <= VARNAM_DB_WRITE_RETRIES; attempt++ {
+		if attempt > 0 {
+			varnam.getLogger().Warn("Learnings DB is locked, retrying", "backoff", backoff)
+
+			select {
+			case <-ctx.Done():
+				return err
+			case <-time.After(backoff):
+			}
+
+			backoff *= 2
+		}
+
+		err = varnam.writeDictTx(ctx, write)
+		if !isDBLocked(err) {
+			return err
+		}
+	}
+
+	return dictError(err)
+}
+
+// data_version of the connection writing doesn't change with
+// its own commits, only with ones by others. If it's the same
+// after commit as in the transaction, what others watching
+// data_version (cache, dictionary index) read after commit is
+// of our write alone.
+func (varnam *Varnam) writeDictTx(ctx context.Context, write func(tx *sql.Tx) error) error {
+	conn, err := varnam.dictConn.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	err = write(tx)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	var before int64
+	err = tx.QueryRowContext(ctx, "PRAGMA data_version").Scan(&before)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	index := varnam.getDictionaryIndex()
+	index.checkBeforeCommit()
+	varnam.cache.checkBeforeCommit()
+
+	err = tx.Commit()
+	if err != nil {
+		index.markStale()
+		return err
+	}
+
+	indexVersion, indexErr := index.readVersionAfterCommit()
+	cacheVersion, cacheErr := varnam.cache.readVersionAfterCommit()
+
+	var after int64
+	err = conn.QueryRowContext(ctx, "PRAGMA data_version").Scan(&after)
+	onlyOurs := err == nil && after == before
+
+	index.afterCommit(indexVersion, indexErr, onlyOurs)
+	varnam.cache.afterCommit(cacheVersion, cacheErr, onlyOurs)
+
+	return nil
 }
 
 // ReIndexDictionary re-indexes dictionary
@@ -112,6 +228,68 @@ const (
 
 // all - Search for words starting with the word
 func (varnam *Varnam) searchDictionary(ctx context.Context, words []string, searchType searchDictionaryType) []searchDictionaryResult {
+	// Prefix matches are the same for the same input,
+	// sessions reuse them as more input comes
+	cache := getDictionaryCache(ctx)
+	if cache == nil || searchType != searchMatches {
+		return varnam.lookupDictionary(ctx, words, searchType)
+	}
+
+	if results, found := cache.get(words); found {
+		return results
+	}
+
+	results := varnam.lookupDictionary(ctx, words, searchType)
+
+	// Results may be incomplete if these happened
+	if ctx.Err() == nil && getLookupError(ctx) == nil {
+		cache.put(words, results)
+	}
+
+	return results
+}
+
+// Search in learnings & base dictionaries
+func (varnam *Varnam) lookupDictionary(ctx context.Context, words []string, searchType searchDictionaryType) []searchDictionaryResult {
+	var results []searchDictionaryResult
+
+	select {
+	case <-ctx.Done():
+		return results
+	default:
+		results = varnam.searchDictionaryLayer(ctx, varnam.dictConn, getDictionaryIndex(ctx), words, searchType)
+
+		baseDicts := varnam.getBaseDictionaries()
+		if len(baseDicts) == 0 {
+			return results
+		}
+
+		for _, layer := range baseDicts {
+			layerResults := varnam.searchDictionaryLayer(ctx, layer.conn, layer.index, words, searchType)
+
+			for i := range layerResults {
+				layerResults[i].weight = layer.scaleWeight(layerResults[i].weight)
+			}
+
+			// searchMatches is only used to find words starting with something.
+			// Suppressing there would stop matching of longer words.
+			if searchType != searchMatches {
+				layerResults = varnam.removeSuppressedResults(ctx, layerResults)
+			}
+
+			results = append(results, layerResults...)
+		}
+
+		return mergeSearchDictionaryResults(results, searchType, varnam.options(ctx).DictionarySuggestionsLimit)
+	}
+}
+
+// Search in a single dictionary layer, in its index if there is one
+func (varnam *Varnam) searchDictionaryLayer(ctx context.Context, conn *sql.DB, index *dictionaryIndex, words []string, searchType searchDictionaryType) []searchDictionaryResult {
+	if index != nil {
+		return index.search(words, searchType, varnam.options(ctx).DictionarySuggestionsLimit)
+	}
+
 	likes := ""
 
 	var (
@@ -175,15 +353,15 @@ func (varnam *Varnam) searchDictionary(ctx context.Context, words []string, sear
 					AND w.word != c.match
 				ORDER BY weight DESC LIMIT ?
 				`
-			vals = append(vals, varnam.DictionarySuggestionsLimit)
+			vals = append(vals, varnam.options(ctx).DictionarySuggestionsLimit)
 		} else if searchType == searchExactWords {
 			query = "SELECT * FROM words WHERE word IN ((?) " + likes + ")"
 		}
 
-		rows, err := varnam.dictConn.QueryContext(ctx, query, vals...)
+		rows, err := conn.QueryContext(ctx, query, vals...)
 
 		if err != nil {
-			log.Print(err)
+			varnam.lookupFailed(ctx, dictError(err))
 			return results
 		}
 
@@ -197,7 +375,7 @@ func (varnam *Varnam) searchDictionary(ctx context.Context, words []string, sear
 
 		err = rows.Err()
 		if err != nil {
-			log.Print(err)
+			varnam.lookupFailed(ctx, dictError(err))
 			return results
 		}
 
@@ -209,6 +387,8 @@ func (varnam *Varnam) getFromDictionary(ctx context.Context, tokensPointer *[]To
 	var result DictionaryResult
 	tokens := *tokensPointer
 
+	ctx = varnam.withDictionaryIndex(ctx)
+
 	select {
 	case <-ctx.Done():
 		return result
@@ -243,13 +423,7 @@ func (varnam *Varnam) getFromDictionary(ctx context.Context, tokensPointer *[]To
 					tempFoundDictWords = searchResults
 					tokenizedWords = searchResults
 
-					if LOG_TIME_TAKEN {
-						log.Printf(
-							"%s took %v\n",
-							"getFromDictionaryToken0",
-							time.Since(start),
-						)
-					}
+					varnam.logTimeTaken("getFromDictionaryToken0", start)
 				} else {
 					start := time.Now()
 					for j := range tokenizedWords {
@@ -295,9 +469,7 @@ func (varnam *Varnam) getFromDictionary(ctx context.Context, tokensPointer *[]To
 							tokenizedWords[j].weight = -1
 						}
 					}
-					if LOG_TIME_TAKEN {
-						log.Printf("%s%d took %v\n", "getFromDictionaryToken", i, time.Since(start))
-					}
+					varnam.logTimeTaken("getFromDictionaryToken"+strconv.Itoa(i), start)
 				}
 			}
 			if len(tempFoundDictWords) > 0 {
@@ -307,7 +479,10 @@ func (varnam *Varnam) getFromDictionary(ctx context.Context, tokensPointer *[]To
 		}
 
 		if lastFoundPosition == tokens[len(tokens)-1].position {
-			result.exactMatches = convertSearchDictResultToSuggestion(lastFoundDictWords, false)
+			result.exactMatches = varnam.removeSuppressedSuggestions(
+				ctx,
+				convertSearchDictResultToSuggestion(lastFoundDictWords, false),
+			)
 		} else {
 			result.partialMatches = convertSearchDictResultToSuggestion(lastFoundDictWords, false)
 		}
@@ -321,6 +496,8 @@ func (varnam *Varnam) getFromDictionary(ctx context.Context, tokensPointer *[]To
 func (varnam *Varnam) getMoreFromDictionary(ctx context.Context, words []Suggestion) MoreDictionaryResult {
 	var result MoreDictionaryResult
 
+	ctx = varnam.withDictionaryIndex(ctx)
+
 	select {
 	case <-ctx.Done():
 		return result
@@ -358,29 +535,137 @@ func (varnam *Varnam) getFromPatternDictionary(ctx context.Context, pattern stri
 	case <-ctx.Done():
 		return results
 	default:
-		rows, err := varnam.dictConn.QueryContext(ctx, "SELECT LENGTH(pts.pattern), w.word, w.weight, w.learned_on FROM `patterns` pts LEFT JOIN words w ON w.id = pts.word_id WHERE ? LIKE (pts.pattern || '%') OR pattern LIKE ? ORDER BY LENGTH(pts.pattern) DESC LIMIT ?", pattern, pattern+"%", varnam.PatternDictionarySuggestionsLimit)
+		results = varnam.getFromPatternDictionaryLayer(ctx, varnam.dictConn, pattern)
 
-		if err != nil {
-			log.Print(err)
-			return results
-		}
+		baseDicts := varnam.getBaseDictionaries()
 
-		defer rows.Close()
+		for _, layer := range baseDicts {
+			var (
+				layerResults []PatternDictionarySuggestion
+				words        []string
+			)
 
-		for rows.Next() {
-			var item PatternDictionarySuggestion
-			rows.Scan(&item.Length, &item.Sug.Word, &item.Sug.Weight, &item.Sug.LearnedOn)
-			item.Sug.Weight += VARNAM_LEARNT_WORD_MIN_WEIGHT
-			results = append(results, item)
-		}
+			layerResults = varnam.getFromPatternDictionaryLayer(ctx, layer.conn, pattern)
 
-		err = rows.Err()
-		if err != nil {
-			log.Print(err)
+			for i := range layerResults {
+				words = append(words, layerResults[i].Sug.Word)
+			}
+
+			suppressed := varnam.getSuppressedWords(ctx, words)
+
+			for _, item := range layerResults {
+				if suppressed[item.Sug.Word] {
+					continue
+				}
+				item.Sug.Weight = layer.scaleWeight(item.Sug.Weight)
+				results = append(results, item)
+			}
 		}
 
+		// A word can be there for many of its patterns
+		return mergePatternDictionarySuggestions(results, utf8.RuneCountInString(pattern), varnam.options(ctx).PatternDictionarySuggestionsLimit)
+	}
+}
+
+// Pattern dictionary search in a single dictionary layer
+func (varnam *Varnam) getFromPatternDictionaryLayer(ctx context.Context, conn *sql.DB, pattern string) []PatternDictionarySuggestion {
+	var results []PatternDictionarySuggestion
+
+	query, args := makePatternDictionaryQuery(pattern, varnam.options(ctx).PatternDictionarySuggestionsLimit)
+
+	rows, err := conn.QueryContext(ctx, query, args...)
+
+	if err != nil {
+		varnam.lookupFailed(ctx, dictError(err))
 		return results
 	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var item PatternDictionarySuggestion
+		rows.Scan(&item.Length, &item.Sug.Word, &item.Sug.Weight, &item.Sug.LearnedOn)
+		item.Sug.Weight += VARNAM_LEARNT_WORD_MIN_WEIGHT
+		results = append(results, item)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		varnam.lookupFailed(ctx, dictError(err))
+	}
+
+	return results
+}
+
+// Finds patterns input starts with & patterns starting with
+// input. Both are lookups in index of patterns, the first with
+// all prefixes of input & the second a range of patterns:
+//
+//	pattern IN ('m', 'ma', 'mal') -- for "mala"
+//	pattern >= 'mala' AND pattern < 'mala' || U+10FFFF
+//
+// Order is the same as patternDictionaryLess()
+func makePatternDictionaryQuery(pattern string, limit int) (string, []interface{}) {
+	var (
+		prefixes     []string
+		placeholders []string
+		args         []interface{}
+	)
+
+	for i := range pattern {
+		if i > 0 {
+			prefixes = append(prefixes, pattern[:i])
+		}
+	}
+
+	for _, prefix := range prefixes {
+		placeholders = append(placeholders, "?")
+		args = append(args, prefix)
+	}
+
+	prefixesQuery := ""
+	if len(prefixes) > 0 {
+		prefixesQuery = "SELECT pattern, word_id FROM patterns WHERE pattern IN (" + strings.Join(placeholders, ", ") + ") UNION ALL "
+	}
+
+	// Every character sorts before this, even with NOCASE
+	length := utf8.RuneCountInString(pattern)
+	args = append(args, pattern, pattern+"\U0010FFFF", length, length, limit)
+
+	query := `SELECT LENGTH(pts.pattern) AS length, w.word, w.weight, w.learned_on FROM (
+		` + prefixesQuery + `SELECT pattern, word_id FROM patterns WHERE pattern >= ? AND pattern < ?
+	) pts JOIN words w ON w.id = pts.word_id
+	ORDER BY
+		length > ? ASC,
+		CASE WHEN length > ? THEN 0 ELSE length END DESC,
+		w.weight DESC,
+		w.learned_on DESC,
+		length ASC
+	LIMIT ?`
+
+	return query, args
+}
+
+// Patterns input starts with come first, longest first.
+// Then patterns starting with input. Heavier & recently
+// learnt words first in both, then shorter patterns.
+func patternDictionaryLess(a PatternDictionarySuggestion, b PatternDictionarySuggestion, inputLength int) bool {
+	aPrefix := a.Length <= inputLength
+	bPrefix := b.Length <= inputLength
+
+	if aPrefix != bPrefix {
+		return aPrefix
+	}
+	if aPrefix && a.Length != b.Length {
+		return a.Length > b.Length
+	}
+	if a.Sug.Weight != b.Sug.Weight {
+		return a.Sug.Weight > b.Sug.Weight
+	}
+	if a.Sug.LearnedOn != b.Sug.LearnedOn {
+		return a.Sug.LearnedOn > b.Sug.LearnedOn
+	}
+	return a.Length < b.Length
 }
 
 // GetRecentlyLearntWords get recently learnt words
@@ -394,7 +679,7 @@ func (varnam *Varnam) GetRecentlyLearntWords(ctx context.Context, offset int, li
 		rows, err := varnam.dictConn.QueryContext(ctx, "SELECT word, weight, learned_on FROM words ORDER BY learned_on DESC, id DESC LIMIT "+fmt.Sprint(offset)+", "+fmt.Sprint(limit))
 
 		if err != nil {
-			return result, err
+			return result, dictError(err)
 		}
 		defer rows.Close()
 
@@ -406,8 +691,7 @@ func (varnam *Varnam) GetRecentlyLearntWords(ctx context.Context, offset int, li
 
 		err = rows.Err()
 		if err != nil {
-			log.Print(err)
-			return result, err
+			return result, dictError(err)
 		}
 
 		return result, nil
@@ -418,14 +702,16 @@ func (varnam *Varnam) GetRecentlyLearntWords(ctx context.Context, offset int, li
 func (varnam *Varnam) GetSuggestions(ctx context.Context, word string) []Suggestion {
 	var sugs []Suggestion
 
+	ctx = varnam.withDictionaryIndex(ctx)
+
 	select {
 	case <-ctx.Done():
 		return sugs
 	default:
-		return convertSearchDictResultToSuggestion(
-			varnam.searchDictionary(ctx, []string{word}, searchStartingWith),
+		return varnam.removeBlockedSuggestions(ctx, convertSearchDictResultToSuggestion(
+			varnam.searchDictionary(ctx, []string{varnam.normalizeWord(word)}, searchStartingWith),
 			true,
-		)
+		))
 	}
 }
 
@@ -433,9 +719,9 @@ func convertSearchDictResultToSuggestion(searchResults []searchDictionaryResult,
 	var sugs []Suggestion
 	for i := range searchResults {
 		sug := Suggestion{
-			searchResults[i].match,
-			searchResults[i].weight,
-			searchResults[i].learnedOn,
+			Word:      searchResults[i].match,
+			Weight:    searchResults[i].weight,
+			LearnedOn: searchResults[i].learnedOn,
 		}
 		if word {
 			sug.Word = searchResults[i].word
@@ -0,0 +1,499 @@
+package govarnam
+
+/**
+ * govarnam - An Indian language transliteration library
+ * Copyright Subin Siby <mail at subinsb (.) com>, 2021
+ * Licensed under AGPL-3.0-only. See LICENSE.txt
+ */
+
+import (
+	"context"
+	sql "database/sql"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Words looked up at once when updating index
+const dictionaryIndexSyncBatchSize = 500
+
+// In-memory index of words in a dictionary, sorted so that
+// words starting with something are next to each other.
+// Prefix searches are a binary search & a walk through them.
+//
+// Learn(), Unlearn() etc. update it with the words they
+// changed, in the transaction of their write. Other writes to
+// learnings (Import, other processes) change data_version & the
+// words are read again. See writeDictTx() for how our own writes
+// are told apart from others.
+type dictionaryIndex struct {
+	mutex   sync.RWMutex
+	entries []dictionaryIndexEntry
+
+	// nil for base dictionaries, they're read-only
+	db          *sql.DB
+	versionConn *sql.Conn
+	dataVersion int64
+
+	// Updating failed, read again on next check
+	stale bool
+}
+
+type dictionaryIndexEntry struct {
+	word      string
+	weight    int
+	learnedOn int
+}
+
+type dictionaryIndexContextKey struct{}
+
+// watch is whether the dictionary can be changed
+func newDictionaryIndex(conn *sql.DB, watch bool) (*dictionaryIndex, error) {
+	index := &dictionaryIndex{}
+	if watch {
+		index.db = conn
+	}
+
+	index.mutex.Lock()
+	defer index.mutex.Unlock()
+
+	return index, index.load(conn)
+}
+
+// Read all words again. Called with lock held.
+func (index *dictionaryIndex) load(conn *sql.DB) error {
+	// Read before words so that a write in
+	// between is found on the next check
+	if index.db != nil {
+		version, err := readDataVersion(index.db, &index.versionConn)
+		if err != nil {
+			return err
+		}
+		index.dataVersion = version
+	}
+
+	rows, err := conn.Query("SELECT word, IFNULL(weight, 0), IFNULL(learned_on, 0) FROM words ORDER BY word")
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var entries []dictionaryIndexEntry
+	for rows.Next() {
+		var entry dictionaryIndexEntry
+		rows.Scan(&entry.word, &entry.weight, &entry.learnedOn)
+		entries = append(entries, entry)
+	}
+
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	// SQLite compares text bytewise just like Go,
+	// this is in case the column has another collation
+	if !sort.SliceIsSorted(entries, func(i, j int) bool { return entries[i].word < entries[j].word }) {
+		sort.Slice(entries, func(i, j int) bool { return entries[i].word < entries[j].word })
+	}
+
+	index.entries = entries
+	index.stale = false
+
+	return nil
+}
+
+// Read words again if learnings were changed outside
+func (index *dictionaryIndex) checkDataVersion() error {
+	if index.db == nil {
+		return nil
+	}
+
+	index.mutex.Lock()
+	defer index.mutex.Unlock()
+
+	version, err := readDataVersion(index.db, &index.versionConn)
+	if err != nil {
+		return err
+	}
+
+	if version == index.dataVersion && !index.stale {
+		return nil
+	}
+
+	return index.load(index.db)
+}
+
+// Put current weight of words in index. Words not in
+// dictionary anymore are removed. tx is of the write which
+// changed the words, so that no one else changes them before.
+func (index *dictionaryIndex) sync(ctx context.Context, tx *sql.Tx, words []string) error {
+	found := map[string]dictionaryIndexEntry{}
+
+	for start := 0; start < len(words); start += dictionaryIndexSyncBatchSize {
+		end := start + dictionaryIndexSyncBatchSize
+		if end > len(words) {
+			end = len(words)
+		}
+
+		var args []interface{}
+		for _, word := range words[start:end] {
+			args = append(args, word)
+		}
+
+		rows, err := tx.QueryContext(
+			ctx,
+			"SELECT word, IFNULL(weight, 0), IFNULL(learned_on, 0) FROM words WHERE word IN (?"+strings.Repeat(", ?", len(args)-1)+")",
+			args...,
+		)
+		if err != nil {
+			return err
+		}
+
+		for rows.Next() {
+			var entry dictionaryIndexEntry
+			rows.Scan(&entry.word, &entry.weight, &entry.learnedOn)
+			found[entry.word] = entry
+		}
+		rows.Close()
+
+		if err := rows.Err(); err != nil {
+			return err
+		}
+	}
+
+	index.mutex.Lock()
+	defer index.mutex.Unlock()
+
+	var (
+		added   []dictionaryIndexEntry
+		removed = map[string]bool{}
+	)
+
+	for _, word := range words {
+		entry, inDictionary := found[word]
+		i, inIndex := index.find(word)
+
+		if inDictionary && inIndex {
+			index.entries[i] = entry
+		} else if inDictionary {
+			added = append(added, entry)
+			// Same word can be given twice
+			delete(found, word)
+		} else if inIndex {
+			removed[word] = true
+		}
+	}
+
+	if len(removed) > 0 {
+		kept := index.entries[:0]
+		for _, entry := range index.entries {
+			if !removed[entry.word] {
+				kept = append(kept, entry)
+			}
+		}
+		index.entries = kept
+	}
+
+	if len(added) > 0 {
+		sort.Slice(added, func(i, j int) bool { return added[i].word < added[j].word })
+
+		// Merge from the end so that nothing is overwritten
+		entries := append(index.entries, make([]dictionaryIndexEntry, len(added))...)
+		i, j := len(index.entries)-1, len(added)-1
+		for k := len(entries) - 1; j >= 0; k-- {
+			if i >= 0 && entries[i].word > added[j].word {
+				entries[k] = entries[i]
+				i--
+			} else {
+				entries[k] = added[j]
+				j--
+			}
+		}
+		index.entries = entries
+	}
+
+	return nil
+}
+
+// Find writes by others since the last check.
+// Called right before our write is committed.
+func (index *dictionaryIndex) checkBeforeCommit() {
+	if index == nil || index.db == nil {
+		return
+	}
+
+	index.mutex.Lock()
+	defer index.mutex.Unlock()
+
+	version, err := readDataVersion(index.db, &index.versionConn)
+	if err != nil || version != index.dataVersion {
+		index.stale = true
+	}
+}
+
+// data_version after our write is committed. Read
+// before finding if others wrote along with it.
+func (index *dictionaryIndex) readVersionAfterCommit() (int64, error) {
+	if index == nil || index.db == nil {
+		return 0, nil
+	}
+
+	index.mutex.Lock()
+	defer index.mutex.Unlock()
+
+	return readDataVersion(index.db, &index.versionConn)
+}
+
+// Store data_version read after our commit if only we wrote.
+// Else words are read again on next check.
+func (index *dictionaryIndex) afterCommit(version int64, err error, onlyOurs bool) {
+	if index == nil || index.db == nil {
+		return
+	}
+
+	index.mutex.Lock()
+	defer index.mutex.Unlock()
+
+	if err != nil || !onlyOurs {
+		index.stale = true
+		return
+	}
+	index.dataVersion = version
+}
+
+func (index *dictionaryIndex) markStale() {
+	if index == nil {
+		return
+	}
+
+	index.mutex.Lock()
+	index.stale = true
+	index.mutex.Unlock()
+}
+
+// Position of word in entries, or where it would be.
+// Called with lock held.
+func (index *dictionaryIndex) find(word string) (int, bool) {
+	i := sort.Search(len(index.entries), func(i int) bool {
+		return index.entries[i].word >= word
+	})
+	return i, i < len(index.entries) && index.entries[i].word == word
+}
+
+// Same results as searchDictionaryLayer() gives from SQLite
+func (index *dictionaryIndex) search(words []string, searchType searchDictionaryType, limit int) []searchDictionaryResult {
+	var results []searchDictionaryResult
+
+	index.mutex.RLock()
+	defer index.mutex.RUnlock()
+
+	seen := map[string]bool{}
+
+	for _, word := range words {
+		if word == "" || seen[word] {
+			continue
+		}
+		seen[word] = true
+
+		start, exists := index.find(word)
+
+		if searchType == searchExactWords {
+			if exists {
+				entry := index.entries[start]
+				results = append(results, searchDictionaryResult{word, entry.word, entry.weight, entry.learnedOn})
+			}
+			continue
+		}
+
+		// Whether any word starts with it & the highest
+		// weight, learned_on among them
+		var match *searchDictionaryResult
+
+		for i := start; i < len(index.entries) && strings.HasPrefix(index.entries[i].word, word); i++ {
+			entry := index.entries[i]
+
+			if searchType == searchStartingWith {
+				// Like the FTS query, word itself isn't left out
+				results = insertByWeight(results, searchDictionaryResult{word, entry.word, entry.weight, entry.learnedOn}, limit)
+				continue
+			}
+
+			if match == nil {
+				match = &searchDictionaryResult{word, entry.word, entry.weight, entry.learnedOn}
+				continue
+			}
+			if entry.weight > match.weight {
+				match.word = entry.word
+				match.weight = entry.weight
+			}
+			if entry.learnedOn > match.learnedOn {
+				match.learnedOn = entry.learnedOn
+			}
+		}
+
+		if match != nil {
+			results = append(results, *match)
+		}
+	}
+
+	// SQLite gives these in order of words, by
+	// grouping or by going through index of words
+	if searchType != searchStartingWith {
+		sort.Slice(results, func(i, j int) bool {
+			return results[i].match < results[j].match
+		})
+	}
+
+	return results
+}
+
+// Add item to results sorted by weight, keeping only limit items
+func insertByWeight(results []searchDictionaryResult, item searchDictionaryResult, limit int) []searchDictionaryResult {
+	i := sort.Search(len(results), func(i int) bool {
+		return results[i].weight < item.weight
+	})
+	if i >= limit {
+		return results
+	}
+
+	if len(results) < limit {
+		results = append(results, searchDictionaryResult{})
+	}
+	copy(results[i+1:], results[i:])
+	results[i] = item
+
+	return results
+}
+
+func (index *dictionaryIndex) close() {
+	if index == nil {
+		return
+	}
+
+	index.mutex.Lock()
+	defer index.mutex.Unlock()
+
+	if index.versionConn != nil {
+		index.versionConn.Close()
+		index.versionConn = nil
+	}
+}
+
+// SetDictionaryIndex keep words of learnings & base dictionaries
+// in memory so that dictionary lookups while transliterating
+// don't query SQLite. Learnings with many words make a lot of
+// lookups for each input, this makes them faster at the cost
+// of memory about the size of the words. Enabled when
+// dictionaries are opened if VARNAM_DICTIONARY_INDEX env is set.
+func (varnam *Varnam) SetDictionaryIndex(enable bool) error {
+	if !enable {
+		varnam.configMutex.Lock()
+		index := varnam.dictIndex
+		varnam.dictIndex = nil
+
+		var layers []*DictionaryLayer
+		for _, layer := range varnam.BaseDictionaries {
+			// Lookups may be using the old one
+			unindexed := *layer
+			unindexed.index = nil
+			layers = append(layers, &unindexed)
+		}
+		varnam.BaseDictionaries = layers
+		varnam.configMutex.Unlock()
+
+		index.close()
+		return nil
+	}
+
+	if varnam.getDictionaryIndex() != nil {
+		return nil
+	}
+
+	// Reading words takes time, lookups go on meanwhile
+	index, err := newDictionaryIndex(varnam.dictConn, true)
+	if err != nil {
+		index.close()
+		return dictError(err)
+	}
+
+	indexed := map[*DictionaryLayer]*DictionaryLayer{}
+	for _, layer := range varnam.getBaseDictionaries() {
+		layerIndex, err := newDictionaryIndex(layer.conn, false)
+		if err != nil {
+			index.close()
+			return dictError(err)
+		}
+
+		indexedLayer := *layer
+		indexedLayer.index = layerIndex
+		indexed[layer] = &indexedLayer
+	}
+
+	varnam.configMutex.Lock()
+	defer varnam.configMutex.Unlock()
+
+	varnam.dictIndex = index
+
+	var layers []*DictionaryLayer
+	for _, layer := range varnam.BaseDictionaries {
+		// One added meanwhile is looked up with SQL
+		if indexedLayer, ok := indexed[layer]; ok {
+			layer = indexedLayer
+		}
+		layers = append(layers, layer)
+	}
+	varnam.BaseDictionaries = layers
+
+	return nil
+}
+
+func (varnam *Varnam) getDictionaryIndex() *dictionaryIndex {
+	varnam.configMutex.RLock()
+	defer varnam.configMutex.RUnlock()
+
+	return varnam.dictIndex
+}
+
+// Attach index of learnings to ctx after making sure
+// it's up to date, so that it's checked once for
+// all the lookups made with ctx
+func (varnam *Varnam) withDictionaryIndex(ctx context.Context) context.Context {
+	if _, ok := ctx.Value(dictionaryIndexContextKey{}).(*dictionaryIndex); ok {
+		return ctx
+	}
+
+	index := varnam.getDictionaryIndex()
+	if index == nil {
+		return ctx
+	}
+
+	err := index.checkDataVersion()
+	if err != nil {
+		// Lookups can still be done with SQL
+		varnam.getLogger().Warn("Couldn't read dictionary index again", "error", err)
+		return ctx
+	}
+
+	return context.WithValue(ctx, dictionaryIndexContextKey{}, index)
+}
+
+func getDictionaryIndex(ctx context.Context) *dictionaryIndex {
+	index, _ := ctx.Value(dictionaryIndexContextKey{}).(*dictionaryIndex)
+	return index
+}
+
+// Update index with words just learnt or unlearnt.
+// Called at the end of the write in tx.
+func (varnam *Varnam) syncDictionaryIndex(ctx context.Context, tx *sql.Tx, words []string) {
+	index := varnam.getDictionaryIndex()
+	if index == nil || len(words) == 0 {
+		return
+	}
+
+	err := index.sync(ctx, tx, words)
+	if err != nil {
+		// Some may have been updated, or none
+		index.markStale()
+
+		varnam.getLogger().Warn("Couldn't update dictionary index", "error", err)
+	}
+}
@@ -0,0 +1,194 @@
+package govarnam
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+// Results of both ways of searching as text to compare.
+// Word of searchMatches is any word starting with match.
+func searchDictionaryBothWays(varnam *Varnam, index *dictionaryIndex, words []string, searchType searchDictionaryType) (string, string) {
+	ctx := varnam.withOptions(context.Background())
+
+	format := func(results []searchDictionaryResult) string {
+		var lines []string
+		for _, result := range results {
+			if searchType == searchMatches {
+				lines = append(lines, fmt.Sprint(result.match, result.weight, result.learnedOn))
+			} else {
+				lines = append(lines, fmt.Sprint(result.word, result.weight, result.learnedOn))
+			}
+		}
+		return strings.Join(lines, "\n")
+	}
+
+	return format(varnam.searchDictionaryLayer(ctx, varnam.dictConn, nil, words, searchType)),
+		format(varnam.searchDictionaryLayer(ctx, varnam.dictConn, index, words, searchType))
+}
+
+func TestDictionaryIndex(t *testing.T) {
+	varnam := makeTestVarnam("dictionary-index", simpleTestSymbols, 0)
+	defer varnam.Close()
+
+	varnam.SetCacheSize(0)
+	defer varnam.SetCacheSize(VARNAM_CACHE_SIZE)
+
+	for i, word := range []string{"കമല", "കമലപ", "കപ", "മല", "മലപ", "പല"} {
+		checkError(varnam.Learn(word, (i+1)*10))
+	}
+
+	ctx := context.Background()
+
+	transliterate := func(word string) string {
+		result, err := varnam.TransliterateWithOptions(ctx, word, varnam.GetOptions())
+		checkError(err)
+
+		var words []string
+		for _, sug := range result.Suggestions() {
+			words = append(words, sug.Word)
+		}
+		return strings.Join(words, " ")
+	}
+
+	withoutIndex := transliterate("kamala")
+
+	checkError(varnam.SetDictionaryIndex(true))
+	index := varnam.getDictionaryIndex()
+	assertEqual(t, index != nil, true)
+
+	assertEqual(t, transliterate("kamala"), withoutIndex)
+
+	compare := func() {
+		for _, words := range [][]string{{"ക"}, {"ക", "മ"}, {"കമ"}, {"കമല"}, {"കമല", "കപ"}, {"x"}} {
+			for _, searchType := range []searchDictionaryType{searchMatches, searchStartingWith, searchExactWords} {
+				sql, indexed := searchDictionaryBothWays(varnam, index, words, searchType)
+				assertEqual(t, indexed, sql)
+			}
+		}
+	}
+	compare()
+
+	// Learning & unlearning updates the index
+	checkError(varnam.Learn("കമപ", 0))
+	checkError(varnam.Learn("മല", 0))
+	checkError(varnam.Unlearn("കമല"))
+
+	_, found := index.find("കമപ")
+	assertEqual(t, found, true)
+	_, found = index.find("കമല")
+	assertEqual(t, found, false)
+	compare()
+
+	status, err := varnam.LearnMany([]WordInfo{{word: "ലപ"}, {word: "ലക"}, {word: "ലപ"}})
+	checkError(err)
+	assertEqual(t, status.FailedWords, 0)
+	compare()
+
+	// Words learnt by another process are found
+	other, err := Init(varnam.VSTPath, varnam.DictPath)
+	checkError(err)
+	checkError(other.Learn("പക", 0))
+	other.Close()
+
+	assertEqual(t, hasSuggestion(varnam.GetSuggestions(ctx, "പ"), "പക"), true)
+	_, found = index.find("പക")
+	assertEqual(t, found, true)
+
+	// Even if we write right after them
+	other, err = Init(varnam.VSTPath, varnam.DictPath)
+	checkError(err)
+	checkError(other.Learn("പകല", 0))
+	other.Close()
+	checkError(varnam.Learn("കലപ", 0))
+
+	assertEqual(t, hasSuggestion(varnam.GetSuggestions(ctx, "പ"), "പകല"), true)
+	_, found = index.find("പകല")
+	assertEqual(t, found, true)
+
+	// Base dictionaries added later are indexed too
+	basePath := makeBaseDictionary("dictionary-index-base.learnings", `
+		INSERT INTO words (word, weight, learned_on) VALUES ('മലമ', 10, 100);
+	`)
+	checkError(varnam.AddBaseDictionary(basePath, 1))
+	assertEqual(t, varnam.getBaseDictionaries()[0].index != nil, true)
+	assertEqual(t, hasSuggestion(varnam.GetSuggestions(ctx, "മല"), "മലമ"), true)
+
+	checkError(varnam.SetDictionaryIndex(false))
+	assertEqual(t, varnam.getDictionaryIndex() == nil, true)
+	assertEqual(t, varnam.getBaseDictionaries()[0].index == nil, true)
+	assertEqual(t, hasSuggestion(varnam.GetSuggestions(ctx, "മല"), "മലമ"), true)
+}
+
+// Learnings with words made of a few letters so that
+// most prefixes have many words starting with them
+func makeBenchmarkDictionary(name string, count int) *Varnam {
+	consonants := []string{"ക", "മ", "ല", "പ", "ത", "ന", "ര", "സ", "വ", "ച"}
+	signs := []string{"", "ാ", "ി", "ു", "െ"}
+
+	varnam := makeTestVarnam(name, simpleTestSymbols, 0)
+	varnam.SetCacheSize(0)
+
+	tx, err := varnam.dictConn.Begin()
+	checkError(err)
+
+	stmt, err := tx.Prepare("INSERT OR IGNORE INTO words (word, weight, learned_on) VALUES (?, ?, ?)")
+	checkError(err)
+
+	random := rand.New(rand.NewSource(1))
+
+	for i := 0; i < count; i++ {
+		word := ""
+		for length := 3 + random.Intn(6); length > 0; length-- {
+			word += consonants[random.Intn(len(consonants))] + signs[random.Intn(len(signs))]
+		}
+
+		_, err = stmt.Exec(word, random.Intn(100), random.Intn(1000))
+		checkError(err)
+	}
+
+	checkError(stmt.Close())
+	checkError(tx.Commit())
+
+	return varnam
+}
+
+// Tokens of an input like "kamalapathara" in an ambiguous
+// scheme, each letter can be with or without a vowel sign
+func makeBenchmarkTokens() []Token {
+	var tokens []Token
+
+	for i, consonant := range []string{"ക", "മ", "ല", "പ", "ത", "ര"} {
+		token := Token{tokenType: VARNAM_TOKEN_SYMBOL, position: i}
+		for _, sign := range []string{"", "ാ", "ി"} {
+			token.symbols = append(token.symbols, Symbol{Type: VARNAM_SYMBOL_CONSONANT, Value1: consonant + sign})
+		}
+		tokens = append(tokens, token)
+	}
+
+	return tokens
+}
+
+func BenchmarkDictionaryLookup(b *testing.B) {
+	varnam := makeBenchmarkDictionary("benchmark", 500000)
+	defer varnam.Close()
+
+	ctx := context.Background()
+	tokens := makeBenchmarkTokens()
+
+	lookup := func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			// Lookups modify tokens
+			copied := copyTokens(tokens)
+			result := varnam.getFromDictionary(ctx, &copied)
+			varnam.getMoreFromDictionary(ctx, append(result.exactMatches, result.partialMatches...))
+		}
+	}
+
+	b.Run("SQL", lookup)
+
+	checkError(varnam.SetDictionaryIndex(true))
+	b.Run("Index", lookup)
+}
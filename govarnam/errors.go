@@ -0,0 +1,159 @@
+package govarnam
+
+/**
+ * govarnam - An Indian language transliteration library
+ * Copyright Subin Siby <mail at subinsb (.) com>, 2021
+ * Licensed under AGPL-3.0-only. See LICENSE.txt
+ */
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+
+	sqlite3 "github.com/mattn/go-sqlite3"
+)
+
+// Error an error with a code saying what went wrong.
+// Codes are the same as the status codes of C library.
+// Check for a kind of error with errors.Is(err, ErrWordNotFound)
+type Error struct {
+	Code    int
+	Message string
+
+	// The underlying error, if any
+	Err error
+}
+
+func (err *Error) Error() string {
+	if err.Err != nil {
+		return err.Message + ": " + err.Err.Error()
+	}
+	return err.Message
+}
+
+func (err *Error) Unwrap() error {
+	return err.Err
+}
+
+// Is errors of same code are the same kind of error
+func (err *Error) Is(target error) bool {
+	targetErr, ok := target.(*Error)
+	return ok && targetErr.Code == err.Code
+}
+
+// Kinds of errors
+var (
+	ErrWordNotFound      = &Error{Code: VARNAM_WORD_NOT_FOUND, Message: "Word doesn't exist"}
+	ErrSingleConjunct    = &Error{Code: VARNAM_SINGLE_CONJUNCT, Message: "Can't learn a single conjunct"}
+	ErrNothingToLearn    = &Error{Code: VARNAM_NOTHING_TO_LEARN, Message: "Nothing to learn"}
+	ErrVSTSchemaMismatch = &Error{Code: VARNAM_VST_SCHEMA_MISMATCH, Message: "VST is corrupt or of a different schema"}
+	ErrDBLocked          = &Error{Code: VARNAM_DB_LOCKED, Message: "Database is locked by another process"}
+	ErrWordBlocked       = &Error{Code: VARNAM_WORD_BLOCKED, Message: "Word is blocked"}
+)
+
+// ErrorCode status code for an error, same as what C library returns
+func ErrorCode(err error) int {
+	if err == nil {
+		return VARNAM_SUCCESS
+	}
+
+	var varnamErr *Error
+	if errors.As(err, &varnamErr) {
+		return varnamErr.Code
+	}
+
+	if isCancelled(err) {
+		return VARNAM_CANCELLED
+	}
+
+	return VARNAM_ERROR
+}
+
+func isCancelled(err error) bool {
+	return errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
+
+// Give a kind to errors from learnings DB
+func dictError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	if isDBLocked(err) {
+		return &Error{Code: VARNAM_DB_LOCKED, Message: ErrDBLocked.Message, Err: err}
+	}
+
+	return err
+}
+
+// Give a kind to errors from VST. A missing table
+// or column is because of a corrupt or different VST.
+func vstError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var sqliteErr sqlite3.Error
+	if errors.As(err, &sqliteErr) {
+		if sqliteErr.Code == sqlite3.ErrCorrupt ||
+			sqliteErr.Code == sqlite3.ErrNotADB ||
+			strings.HasPrefix(sqliteErr.Error(), "no such table") ||
+			strings.HasPrefix(sqliteErr.Error(), "no such column") {
+			return &Error{Code: VARNAM_VST_SCHEMA_MISMATCH, Message: ErrVSTSchemaMismatch.Message, Err: err}
+		}
+	}
+
+	return dictError(err)
+}
+
+// Errors from lookups while transliterating. Lookups don't
+// return errors, they put it here & go on with what they have.
+type lookupErrors struct {
+	mutex sync.Mutex
+	err   error
+}
+
+type lookupErrorsContextKey struct{}
+
+// Attach a place to collect lookup errors to context
+func withLookupErrors(ctx context.Context) context.Context {
+	if _, ok := ctx.Value(lookupErrorsContextKey{}).(*lookupErrors); ok {
+		return ctx
+	}
+	return context.WithValue(ctx, lookupErrorsContextKey{}, &lookupErrors{})
+}
+
+// Remember a lookup error. Only the first one is kept.
+// Errors due to cancellation aren't errors.
+func (varnam *Varnam) lookupFailed(ctx context.Context, err error) {
+	if err == nil || isCancelled(err) {
+		return
+	}
+
+	collected, ok := ctx.Value(lookupErrorsContextKey{}).(*lookupErrors)
+	if !ok {
+		varnam.getLogger().Error("Lookup failed", "error", err)
+		return
+	}
+
+	collected.mutex.Lock()
+	if collected.err == nil {
+		collected.err = err
+	}
+	collected.mutex.Unlock()
+}
+
+// First lookup error that happened
+func getLookupError(ctx context.Context) error {
+	collected, ok := ctx.Value(lookupErrorsContextKey{}).(*lookupErrors)
+	if !ok {
+		return nil
+	}
+
+	collected.mutex.Lock()
+	defer collected.mutex.Unlock()
+
+	return collected.err
+}
@@ -0,0 +1,64 @@
+package govarnam
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"testing"
+)
+
+func TestErrorCode(t *testing.T) {
+	assertEqual(t, ErrorCode(nil), VARNAM_SUCCESS)
+	assertEqual(t, ErrorCode(fmt.Errorf("something")), VARNAM_ERROR)
+	assertEqual(t, ErrorCode(context.Canceled), VARNAM_CANCELLED)
+	assertEqual(t, ErrorCode(ErrNothingToLearn), VARNAM_NOTHING_TO_LEARN)
+
+	// Wrapped
+	err := fmt.Errorf("learning failed: %w", &Error{Code: VARNAM_DB_LOCKED, Message: "locked", Err: fmt.Errorf("busy")})
+	assertEqual(t, ErrorCode(err), VARNAM_DB_LOCKED)
+	assertEqual(t, errors.Is(err, ErrDBLocked), true)
+	assertEqual(t, errors.Is(err, ErrWordNotFound), false)
+	assertEqual(t, err.Error(), "learning failed: locked: busy")
+}
+
+func TestVSTSchemaMismatch(t *testing.T) {
+	learningsPath := path.Join(testTempDir, "schema-mismatch.learnings")
+
+	// Not a database
+	_, err := Init(makeFile("corrupt.vst", "not a VST"), learningsPath)
+	assertEqual(t, errors.Is(err, ErrVSTSchemaMismatch), true)
+
+	// Different schema
+	oldVSTPath := path.Join(testTempDir, "old.vst")
+	conn, err := openDB(oldVSTPath)
+	checkError(err)
+	_, err = conn.Exec("CREATE TABLE symbols (id INTEGER PRIMARY KEY, pattern TEXT, value1 TEXT); CREATE TABLE metadata (key TEXT UNIQUE, value TEXT)")
+	checkError(err)
+	conn.Close()
+
+	_, err = Init(oldVSTPath, learningsPath)
+	assertEqual(t, errors.Is(err, ErrVSTSchemaMismatch), true)
+	assertEqual(t, ErrorCode(err), VARNAM_VST_SCHEMA_MISMATCH)
+
+	// VST going bad after init is an error, not "no suggestions"
+	vstPath := path.Join(testTempDir, "schema-mismatch.vst")
+	vm, err := VMInit(vstPath)
+	checkError(err)
+	defer vm.Close()
+
+	checkError(vm.VMCreateToken("a", "അ", "", "", "", VARNAM_SYMBOL_VOWEL, VARNAM_MATCH_EXACT, 0, 0, false))
+
+	varnam, err := Init(vstPath, learningsPath)
+	checkError(err)
+	defer varnam.Close()
+
+	_, err = varnam.TransliterateWithOptions(context.Background(), "a", varnam.GetOptions())
+	checkError(err)
+
+	_, err = vm.vstConn.Exec("DROP TABLE symbols")
+	checkError(err)
+
+	_, err = varnam.TransliterateWithOptions(context.Background(), "a", varnam.GetOptions())
+	assertEqual(t, errors.Is(err, ErrVSTSchemaMismatch), true)
+}
@@ -0,0 +1,109 @@
+package govarnam
+
+/**
+ * govarnam - An Indian language transliteration library
+ * Copyright Subin Siby <mail at subinsb (.) com>, 2021
+ * Licensed under AGPL-3.0-only. See LICENSE.txt
+ */
+
+import (
+	"context"
+	sql "database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// AcceptSuggestion the user chose shown[chosenIndex] for input.
+// The word is learnt, and trained with input as pattern if the
+// tokenizer made it. Suggestions shown above it are remembered
+// as passed over for input, they're ranked lower for it after.
+// Symbols chosen in it are preferred in other words too.
+func (varnam *Varnam) AcceptSuggestion(input string, shown []Suggestion, chosenIndex int) error {
+	if chosenIndex < 0 || chosenIndex >= len(shown) {
+		return &Error{Code: VARNAM_MISUSE, Message: fmt.Sprintf("No suggestion %d in %d shown", chosenIndex, len(shown))}
+	}
+
+	chosen := shown[chosenIndex]
+
+	// Nothing to learn from a phrase the user added
+	if chosen.Source == VARNAM_SOURCE_SHORTCUT {
+		return nil
+	}
+
+	err := varnam.recordRejections(input, shown[:chosenIndex], chosen.Word)
+	if err != nil {
+		return err
+	}
+
+	err = varnam.learnSymbolPreferences(input, chosen.Word)
+	if err != nil {
+		return err
+	}
+
+	if chosen.Source == VARNAM_SOURCE_TOKENIZER {
+		return varnam.Train(input, chosen.Word)
+	}
+	return varnam.Learn(chosen.Word, 0)
+}
+
+// Remember that passed were skipped for chosen. chosen
+// isn't a rejection for input anymore.
+func (varnam *Varnam) recordRejections(input string, passed []Suggestion, chosen string) error {
+	ctx, cancelFunc := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelFunc()
+
+	err := varnam.writeDict(ctx, func(tx *sql.Tx) error {
+		// Same word can come from different sources
+		seen := map[string]bool{chosen: true}
+
+		for _, sug := range passed {
+			if seen[sug.Word] {
+				continue
+			}
+			seen[sug.Word] = true
+
+			_, err := tx.ExecContext(ctx, `INSERT INTO rejections(pattern, word, count, rejected_on) VALUES (?, ?, 1, strftime('%s', 'now'))
+				ON CONFLICT(pattern, word) DO UPDATE SET count = count + 1, rejected_on = excluded.rejected_on`, input, sug.Word)
+			if err != nil {
+				return err
+			}
+		}
+
+		_, err := tx.ExecContext(ctx, "DELETE FROM rejections WHERE pattern = ? AND word = ?", input, chosen)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	// Only the order of input's suggestions change
+	varnam.cache.invalidate(func(key resultCacheKey, entry resultCacheEntry) bool {
+		return strings.EqualFold(key.word, input)
+	})
+
+	return nil
+}
+
+// Times each word was passed over for input
+func (varnam *Varnam) getRejections(ctx context.Context, input string) map[string]int {
+	rejections := map[string]int{}
+
+	rows, err := varnam.dictConn.QueryContext(ctx, "SELECT word, count FROM rejections WHERE pattern = ?", input)
+	if err != nil {
+		varnam.lookupFailed(ctx, dictError(err))
+		return rejections
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			word  string
+			count int
+		)
+		rows.Scan(&word, &count)
+		rejections[word] = count
+	}
+
+	return rejections
+}
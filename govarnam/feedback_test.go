@@ -0,0 +1,75 @@
+package govarnam
+
+import (
+	"context"
+	"testing"
+)
+
+func TestAcceptSuggestion(t *testing.T) {
+	varnam := makeTestVarnam("feedback", kalamaTestSymbols, 100)
+	defer varnam.Close()
+
+	ctx := context.Background()
+
+	transliterate := func() TransliterationResult {
+		result, err := varnam.TransliterateWithOptions(ctx, "kalama", varnam.GetOptions())
+		checkError(err)
+		return result
+	}
+
+	shown := transliterate().TokenizerSuggestions
+	assertEqual(t, len(shown), 8)
+
+	chosen := shown[2].Word
+	checkError(varnam.AcceptSuggestion("kalama", shown, 2))
+
+	// Learnt & trained
+	result := transliterate()
+	assertEqual(t, result.Suggestions()[0].Word, chosen)
+	assertEqual(t, hasSuggestion(result.PatternDictionarySuggestions, chosen) || hasSuggestion(result.ExactWords, chosen), true)
+
+	position := func(sugs []Suggestion, word string) int {
+		for i, sug := range sugs {
+			if sug.Word == word {
+				return i
+			}
+		}
+		return -1
+	}
+
+	// Ones passed over go down
+	sugs := result.TokenizerSuggestions
+	assertEqual(t, position(sugs, shown[0].Word) > 0, true)
+	assertEqual(t, position(sugs, shown[1].Word) > 1, true)
+
+	// Only for that input
+	assertEqual(t, len(varnam.getRejections(ctx, "kala")), 0)
+
+	// Choosing a passed over word undoes it
+	checkError(varnam.AcceptSuggestion("kalama", sugs, position(sugs, shown[0].Word)))
+
+	rejections := varnam.getRejections(ctx, "kalama")
+	assertEqual(t, rejections[shown[0].Word], 0)
+	assertEqual(t, rejections[shown[1].Word], 1)
+	assertEqual(t, rejections[chosen], 1)
+
+	err := varnam.AcceptSuggestion("kalama", sugs, len(sugs))
+	assertEqual(t, ErrorCode(err), VARNAM_MISUSE)
+}
+
+func TestSortSuggestionsRejections(t *testing.T) {
+	heavy := Suggestion{Word: "കല", Weight: VARNAM_LEARNT_WORD_MIN_WEIGHT + 100, LearnedOn: 1}
+	barely := Suggestion{Word: "കള", Weight: VARNAM_LEARNT_WORD_MIN_WEIGHT + 1, LearnedOn: 2}
+
+	// Passed over once, still learnt far more
+	sugs := sortSuggestions([]Suggestion{barely, heavy}, map[string]int{"കല": 1})
+	assertEqual(t, sugs[0].Word, "കല")
+
+	// Weights being near, it goes down
+	sugs = sortSuggestions([]Suggestion{{Word: "കല", Weight: 40}, {Word: "കള", Weight: 35}}, map[string]int{"കല": 1})
+	assertEqual(t, sugs[0].Word, "കള")
+
+	// Penalty has a limit
+	assertEqual(t, rejectedWeight(100, 100), rejectedWeight(100, 3))
+	assertEqual(t, rejectedWeight(100, 100) > 0, true)
+}
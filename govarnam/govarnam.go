@@ -9,10 +9,9 @@ package govarnam
 import (
 	"context"
 	sql "database/sql"
-	"fmt"
-	"log"
 	"sort"
 	"strings"
+	"sync"
 	"time"
 	"unicode"
 	"unicode/utf8"
@@ -55,10 +54,26 @@ type Varnam struct {
 	vstConn  *sql.DB
 	dictConn *sql.DB
 
+	// Read-only dictionaries below user's learnings.
+	// See AddBaseDictionary()
+	BaseDictionaries []*DictionaryLayer
+
 	LangRules     LangRules
 	SchemeDetails SchemeDetails
 	Debug         bool
 
+	// See SetLogger()
+	logger Logger
+
+	// See CacheStats()
+	cache *varnamCache
+
+	// See SetDictionaryIndex()
+	dictIndex *dictionaryIndex
+
+	// Words of blocklist files, see LoadBlocklist()
+	blocklist map[string]bool
+
 	PatternWordPartializers []func(*Suggestion)
 
 	// Maximum suggestions to obtain from dictionary
@@ -81,6 +96,10 @@ type Varnam struct {
 	VSTMakerConfig VSTMakerConfig
 
 	// See setDefaultConfig() for the default values
+
+	// Guards config & layers when varnam is used from many goroutines.
+	// See UpdateOptions()
+	configMutex sync.RWMutex
 }
 
 // Suggestion suggestion
@@ -88,6 +107,19 @@ type Suggestion struct {
 	Word      string
 	Weight    int
 	LearnedOn int
+
+	// Where this came from, one of VARNAM_SOURCE_*.
+	// Only set in transliteration results.
+	Source int
+
+	// Count of input characters matched by the source. Less than
+	// input length when only a part matched a learnt word/pattern
+	// and the rest got tokenized.
+	MatchedLength int
+
+	// Weight scaled to 0-1 relative to the highest weighted
+	// suggestion of the transliteration
+	Score float64
 }
 
 // TransliterationResult result
@@ -115,12 +147,16 @@ type TransliterationResult struct {
 	// VARNAM_MATCH_EXACT results from tokenizer.
 	// No limit, mostly gives 1 or less than 3 outputs
 	GreedyTokenized []Suggestion
-}
 
-func (varnam *Varnam) log(msg string) {
-	if varnam.Debug {
-		fmt.Println(msg)
-	}
+	// Bitmask of VARNAM_STAGE_* that didn't finish in time,
+	// their suggestions are missing from above.
+	// 0 when transliteration completed.
+	MissingStages int
+
+	// Bitmask of VARNAM_STAGE_* that weren't run because input
+	// is longer than VARNAM_INPUT_MAX_LENGTH. Unlike MissingStages,
+	// they won't be there with more time either.
+	SkippedStages int
 }
 
 /**
@@ -129,114 +165,150 @@ func (varnam *Varnam) log(msg string) {
  */
 func (varnam *Varnam) tokensToSuggestions(ctx context.Context, tokensPointer *[]Token, partial bool, limit int) []Suggestion {
 	var results []Suggestion
-	tokens := *tokensPointer
 
 	select {
 	case <-ctx.Done():
 		return results
 
 	default:
-		tokens = removeLessWeightedSymbols(tokens)
+		return varnam.newTokenEnumerator(*tokensPointer, partial).next(limit)
+	}
+}
 
-		addWord := func(word []string, weight int) {
-			// TODO avoid division, performance improvement ?
-			weight = weight / 100
-			results = append(results, Suggestion{strings.Join(word, ""), weight, 0})
-		}
+// Makes words from tokens trying each possibility of
+// each token. Words are made in batches with next().
+type tokenEnumerator struct {
+	varnam  *Varnam
+	tokens  []Token
+	partial bool
+
+	// Tracks index of each token possibilities
+	// -----
+	// Suppose input is "vardhichu". We will try each possibilities of each token
+	// The index of these possibilities is tracked here
+	// [0 0 0 0] => വ ർ ധി ചു
+	// [0 0 0 1] => വ ർ ധി ച്ചു
+	// [0 0 1 0] => വ ർ ഥി ചു
+	// [0 0 1 1] => വ ർ ഥി ച്ചു
+	tokenPositions []int
+
+	// Last token with multiple possibilities
+	k int
+
+	// All possibilities tried
+	done bool
+}
 
-		// Tracks index of each token possibilities
-		// -----
-		// Suppose input is "vardhichu". We will try each possibilities of each token
-		// The index of these possibilities is tracked here
-		// [0 0 0 0] => വ ർ ധി ചു
-		// [0 0 0 1] => വ ർ ധി ച്ചു
-		// [0 0 1 0] => വ ർ ഥി ചു
-		// [0 0 1 1] => വ ർ ഥി ച്ചു
-		tokenPositions := make([]int, len(tokens))
-
-		// We go right to left.
-		// We try possibilities from the last character (k) where there are multiple possibilities.
-		// if it's over we shift the possibility on left, so on and on
-		k := len(tokens) - 1
-
-		i := k
-		for i >= 0 {
-			if tokens[i].tokenType == VARNAM_TOKEN_SYMBOL && len(tokens[i].symbols)-1 > tokenPositions[i] {
-				k = i
-				break
-			}
-			i--
+// Less weighted possibilities of tokens are removed in place
+func (varnam *Varnam) newTokenEnumerator(tokens []Token, partial bool) *tokenEnumerator {
+	tokens = removeLessWeightedSymbols(tokens)
+
+	// We go right to left.
+	// We try possibilities from the last character (k) where there are multiple possibilities.
+	// if it's over we shift the possibility on left, so on and on
+	k := len(tokens) - 1
+
+	i := k
+	for i >= 0 {
+		if tokens[i].tokenType == VARNAM_TOKEN_SYMBOL && len(tokens[i].symbols) > 1 {
+			k = i
+			break
 		}
+		i--
+	}
 
-		for len(results) < limit {
-			// One loop will make one word
-			word := make([]string, len(tokens))
-			weight := 0
+	return &tokenEnumerator{
+		varnam:         varnam,
+		tokens:         tokens,
+		partial:        partial,
+		tokenPositions: make([]int, len(tokens)),
+		k:              k,
+		done:           len(tokens) == 0,
+	}
+}
 
-			// i is the character position we're making
-			i := len(tokens) - 1
-			for i >= 0 {
-				t := tokens[i]
-				if t.tokenType == VARNAM_TOKEN_SYMBOL {
-					symbol := t.symbols[tokenPositions[i]]
-
-					var (
-						symbolValue  string
-						symbolWeight int
-					)
-
-					if i == 0 {
-						if partial {
-							// Since partial, the first character is not
-							// the first character of word
-							symbolValue = getSymbolValue(symbol, 1)
-							symbolWeight = getSymbolWeight(symbol)
-						} else {
-							symbolValue = getSymbolValue(symbol, 0)
-							symbolWeight = getSymbolWeight(symbol)
-						}
+// Make upto limit more words
+func (e *tokenEnumerator) next(limit int) []Suggestion {
+	var results []Suggestion
+
+	tokens := e.tokens
+	tokenPositions := e.tokenPositions
+	k := e.k
+
+	addWord := func(word []string, weight int) {
+		// TODO avoid division, performance improvement ?
+		weight = weight / 100
+		results = append(results, Suggestion{Word: strings.Join(word, ""), Weight: weight})
+	}
+
+	for len(results) < limit && !e.done {
+		// One loop will make one word
+		word := make([]string, len(tokens))
+		weight := 0
+
+		// i is the character position we're making
+		i := len(tokens) - 1
+		for i >= 0 {
+			t := tokens[i]
+			if t.tokenType == VARNAM_TOKEN_SYMBOL {
+				symbol := t.symbols[tokenPositions[i]]
+
+				var (
+					symbolValue  string
+					symbolWeight int
+				)
+
+				if i == 0 {
+					if e.partial {
+						// Since partial, the first character is not
+						// the first character of word
+						symbolValue = getSymbolValue(symbol, 1)
+						symbolWeight = getSymbolWeight(symbol)
 					} else {
-						symbolValue = getSymbolValue(symbol, i)
+						symbolValue = getSymbolValue(symbol, 0)
 						symbolWeight = getSymbolWeight(symbol)
 					}
-
-					word[i] = symbolValue
-					weight += symbolWeight
-				} else if t.tokenType == VARNAM_TOKEN_CHAR {
-					word[i] = t.character
+				} else {
+					symbolValue = getSymbolValue(symbol, i)
+					symbolWeight = getSymbolWeight(symbol)
 				}
-				i--
+
+				word[i] = symbolValue
+				weight += symbolWeight
+			} else if t.tokenType == VARNAM_TOKEN_CHAR {
+				word[i] = t.character
 			}
+			i--
+		}
 
-			// If no more possibilites, go to the next one
-			if tokenPositions[k] >= len(tokens[k].symbols)-1 {
-				// Reset the currently permuted position
-				tokenPositions[k] = 0
-
-				// Find the next place where there are more possibilities
-				i := k - 1
-				for i >= 0 {
-					if tokens[i].tokenType == VARNAM_TOKEN_SYMBOL && len(tokens[i].symbols)-1 > tokenPositions[i] {
-						// Set the newly gonna permuting position
-						tokenPositions[i]++
-						break
-					} else {
-						tokenPositions[i] = 0
-					}
-					i--
-				}
-				addWord(word, weight)
-				if i < 0 {
+		// If no more possibilites, go to the next one
+		if tokenPositions[k] >= len(tokens[k].symbols)-1 {
+			// Reset the currently permuted position
+			tokenPositions[k] = 0
+
+			// Find the next place where there are more possibilities
+			i := k - 1
+			for i >= 0 {
+				if tokens[i].tokenType == VARNAM_TOKEN_SYMBOL && len(tokens[i].symbols)-1 > tokenPositions[i] {
+					// Set the newly gonna permuting position
+					tokenPositions[i]++
 					break
+				} else {
+					tokenPositions[i] = 0
 				}
-			} else {
-				tokenPositions[k]++
-				addWord(word, weight)
+				i--
+			}
+			addWord(word, weight)
+			if i < 0 {
+				e.done = true
 			}
+		} else {
+			tokenPositions[k]++
+			addWord(word, weight)
 		}
-
-		return results
 	}
+
+	return results
 }
 
 func (varnam *Varnam) setDefaultConfig() {
@@ -259,127 +331,303 @@ func (varnam *Varnam) setDefaultConfig() {
 
 // SortSuggestions by weight and learned on time
 func SortSuggestions(sugs []Suggestion) []Suggestion {
+	return sortSuggestions(sugs, nil)
+}
+
+// Same as SortSuggestions, but words passed over for the input
+// (see AcceptSuggestion) lose some of their weight
+func sortSuggestions(sugs []Suggestion, rejections map[string]int) []Suggestion {
 	// TODO write tests
 	sort.SliceStable(sugs, func(i, j int) bool {
 		if (sugs[i].LearnedOn == 0 || sugs[j].LearnedOn == 0) && !(sugs[i].LearnedOn == 0 && sugs[j].LearnedOn == 0) {
 			return sugs[i].LearnedOn > sugs[j].LearnedOn
 		}
-		return sugs[i].Weight > sugs[j].Weight
+		return rejectedWeight(sugs[i].Weight, rejections[sugs[i].Word]) > rejectedWeight(sugs[j].Weight, rejections[sugs[j].Word])
 	})
 	return sugs
 }
 
+// Weight after being passed over count times
+func rejectedWeight(weight int, count int) float64 {
+	penalty := VARNAM_REJECTION_PENALTY * float64(count)
+	if penalty > VARNAM_REJECTION_MAX_PENALTY {
+		penalty = VARNAM_REJECTION_MAX_PENALTY
+	}
+	return float64(weight) * (1 - penalty)
+}
+
 // Returns tokens and all found suggestions
 func (varnam *Varnam) transliterate(ctx context.Context, word string) (
 	*[]Token,
 	TransliterationResult) {
+	tokens, result, err := varnam.transliterateWithDeadline(ctx, word, time.Time{})
+	if err != nil {
+		varnam.getLogger().Error("Transliteration failed", "error", err)
+	}
+	return tokens, result
+}
+
+// Results of stages that finish before deadline are returned.
+// Greedy tokenization is waited for till ctx is done.
+// A zero deadline means no deadline.
+// The error is of the lookups, not of ctx.
+func (varnam *Varnam) transliterateWithDeadline(ctx context.Context, word string, deadline time.Time) (
+	*[]Token,
+	TransliterationResult,
+	error) {
 	var (
 		result TransliterationResult
 	)
 
 	start := time.Now()
 
-	tokensPointerChan := make(chan *[]Token)
+	ctx = varnam.withOptions(ctx)
+	ctx = withLookupErrors(ctx)
+
+	ctx = varnam.withCheckedCache(ctx)
+
+	cacheKey := resultCacheKey{word, varnam.options(ctx)}
+
+	cached, generation, found := varnam.cache.getResult(cacheKey)
+
+	// Debugging needs every step to be taken
+	if found && ctx.Err() == nil && !varnam.Debug {
+		return &cached.tokens, cached.result, nil
+	}
+
+	// Stages are removed from this as they finish
+	result.MissingStages = VARNAM_STAGE_ALL
+
+	// Buffered so that the worker can send its result and exit even
+	// if nobody is receiving because the context got cancelled midway
+	tokensPointerChan := make(chan *[]Token, 1)
 	go varnam.channelTokenizeWord(ctx, word, VARNAM_MATCH_ALL, false, tokensPointerChan)
 
+	var tokensPointer *[]Token
+
 	select {
 	case <-ctx.Done():
-		return nil, result
+		return nil, result, getLookupError(ctx)
 
-	case tokensPointer := <-tokensPointerChan:
-		if len(*tokensPointer) == 0 {
-			return nil, result
+	case tokensPointer = <-tokensPointerChan:
+		// Channel gets closed without a value if cancelled
+		if tokensPointer == nil || ctx.Err() != nil {
+			return nil, result, getLookupError(ctx)
 		}
+	}
 
-		if varnam.Debug {
-			fmt.Println(*tokensPointer)
-		}
+	// Stages modify tokens
+	tokens := copyTokens(*tokensPointer)
+
+	result = varnam.transliterateTokens(ctx, word, tokensPointer, deadline)
+
+	varnam.logTimeTaken("transliteration", start)
+
+	// Only complete results are reusable
+	if result.MissingStages == 0 && ctx.Err() == nil && getLookupError(ctx) == nil {
+		varnam.cache.putResult(cacheKey, resultCacheEntry{tokens, result}, generation)
+	}
+
+	return tokensPointer, result, getLookupError(ctx)
+}
+
+// Find suggestions of word from its tokens. ctx should
+// have options & lookup errors attached already.
+// See transliterateWithDeadline() for how deadline works.
+func (varnam *Varnam) transliterateTokens(ctx context.Context, word string, tokensPointer *[]Token, deadline time.Time) TransliterationResult {
+	var (
+		result TransliterationResult
+	)
+
+	opts := varnam.options(ctx)
+
+	if len(*tokensPointer) == 0 {
+		return result
+	}
+
+	// Stages are removed from this as they finish
+	result.MissingStages = VARNAM_STAGE_ALL
+
+	// Context of the stages other than greedy tokenization
+	stagesCtx := ctx
+	if !deadline.IsZero() {
+		var cancel context.CancelFunc
+		stagesCtx, cancel = context.WithDeadline(ctx, deadline)
+		defer cancel()
+	}
+
+	varnam.debug("Tokenized", "tokens", *tokensPointer)
+
+	rejections := varnam.getRejections(ctx, word)
 
-		/* Channels make things faster, getting from DB is time-consuming */
+	/* Channels make things faster, getting from DB is time-consuming */
 
-		dictSugsChan := make(chan channelDictionaryResult)
-		patternDictSugsChan := make(chan channelDictionaryResult)
-		greedyTokenizedChan := make(chan []Suggestion)
+	// All channels here are buffered so that every worker can
+	// send its result and exit even if nobody is receiving anymore
+	// because the context got cancelled midway.
 
-		// Only exact tokens
-		exactTokens := make([]Token, len(*tokensPointer))
-		copy(exactTokens, *tokensPointer)
+	dictSugsChan := make(chan channelDictionaryResult, 1)
+	patternDictSugsChan := make(chan channelDictionaryResult, 1)
+	greedyTokenizedChan := make(chan []Suggestion, 1)
 
-		exactTokens = removeNonExactTokens(exactTokens)
+	// Only exact tokens
+	exactTokens := make([]Token, len(*tokensPointer))
+	copy(exactTokens, *tokensPointer)
 
-		if varnam.DictionaryMatchExact {
-			go varnam.channelGetFromDictionary(ctx, word, &exactTokens, dictSugsChan)
+	exactTokens = removeNonExactTokens(exactTokens)
+
+	go varnam.channelTokensToGreedySuggestions(ctx, &exactTokens, greedyTokenizedChan)
+	pending := VARNAM_STAGE_GREEDY_TOKENIZED
+
+	// Too long inputs are only greedy tokenized.
+	// Their tokens would make huge dictionary queries.
+	if utf8.RuneCountInString(word) <= VARNAM_INPUT_MAX_LENGTH {
+		if opts.DictionaryMatchExact {
+			go varnam.channelGetFromDictionary(stagesCtx, word, &exactTokens, dictSugsChan)
 		} else {
-			go varnam.channelGetFromDictionary(ctx, word, tokensPointer, dictSugsChan)
+			go varnam.channelGetFromDictionary(stagesCtx, word, tokensPointer, dictSugsChan)
 		}
 
-		go varnam.channelGetFromPatternDictionary(ctx, word, patternDictSugsChan)
-		go varnam.channelTokensToGreedySuggestions(ctx, &exactTokens, greedyTokenizedChan)
+		go varnam.channelGetFromPatternDictionary(stagesCtx, word, patternDictSugsChan)
+		pending |= VARNAM_STAGE_DICTIONARY | VARNAM_STAGE_PATTERN_DICTIONARY
+	} else {
+		// Tokenizer is started by dictionary stage
+		result.SkippedStages = VARNAM_STAGE_DICTIONARY | VARNAM_STAGE_PATTERN_DICTIONARY | VARNAM_STAGE_TOKENIZER
+		result.MissingStages &^= result.SkippedStages
+	}
+
+	// Started once dictionary results are in
+	var tokenizerSugsChan chan []Suggestion
 
-		tokenizerSugsChan := make(chan []Suggestion)
-		tokenizerSugsCalled := false
+	stagesDone := stagesCtx.Done()
 
+	// A stage's result is taken only if its context wasn't
+	// done before receiving it. Otherwise the DB queries of that
+	// stage may have got cancelled making the result incomplete.
+	for pending != 0 {
 		select {
 		case <-ctx.Done():
-			return nil, result
+			return result
+
+		case <-stagesDone:
+			// Deadline reached, only wait for greedy tokenization
+			pending &= VARNAM_STAGE_GREEDY_TOKENIZED
+			stagesDone = nil
+
+		// Add greedy tokenized suggestions. This will only give exact match (VARNAM_MATCH_EXACT) results
+		case greedyTokenizedResult, ok := <-greedyTokenizedChan:
+			greedyTokenizedChan = nil
+			pending &^= VARNAM_STAGE_GREEDY_TOKENIZED
+
+			if ok && ctx.Err() == nil {
+				result.GreedyTokenized = sortSuggestions(greedyTokenizedResult, rejections)
+				result.MissingStages &^= VARNAM_STAGE_GREEDY_TOKENIZED
+			}
+
+		case channelDictResult, ok := <-dictSugsChan:
+			dictSugsChan = nil
+			pending &^= VARNAM_STAGE_DICTIONARY
+
+			if !ok || stagesCtx.Err() != nil {
+				continue
+			}
 
-		case channelDictResult := <-dictSugsChan:
 			// From dictionary
-			result.ExactWords = channelDictResult.exactWords
-			result.ExactMatches = channelDictResult.exactMatches
-			result.DictionarySuggestions = channelDictResult.suggestions
-
-			select {
-			case <-ctx.Done():
-				return nil, result
-			case channelPatternDictResult := <-patternDictSugsChan:
-				// From patterns dictionary
-				result.ExactWords = append(result.ExactWords, channelPatternDictResult.exactWords...)
-				result.PatternDictionarySuggestions = SortSuggestions(channelPatternDictResult.suggestions)
-
-				if len(result.ExactMatches) == 0 || varnam.TokenizerSuggestionsAlways {
-					go varnam.channelTokensToSuggestions(ctx, tokensPointer, varnam.TokenizerSuggestionsLimit, tokenizerSugsChan)
-					tokenizerSugsCalled = true
-				}
+			result.ExactWords = append(result.ExactWords, channelDictResult.exactWords...)
+			result.ExactMatches = sortSuggestions(channelDictResult.exactMatches, rejections)
+			result.DictionarySuggestions = sortSuggestions(channelDictResult.suggestions, rejections)
+			result.MissingStages &^= VARNAM_STAGE_DICTIONARY
+
+			if len(result.ExactMatches) == 0 || opts.TokenizerSuggestionsAlways {
+				tokenizerSugsChan = make(chan []Suggestion, 1)
+				go varnam.channelTokensToSuggestions(stagesCtx, tokensPointer, opts.TokenizerSuggestionsLimit, tokenizerSugsChan)
+				pending |= VARNAM_STAGE_TOKENIZER
+			} else {
+				// Not needed
+				result.MissingStages &^= VARNAM_STAGE_TOKENIZER
+			}
 
-				select {
-				case <-ctx.Done():
-					return nil, result
+		case channelPatternDictResult, ok := <-patternDictSugsChan:
+			patternDictSugsChan = nil
+			pending &^= VARNAM_STAGE_PATTERN_DICTIONARY
 
-				// Add greedy tokenized suggestions. This will only give exact match (VARNAM_MATCH_EXACT) results
-				case greedyTokenizedResult := <-greedyTokenizedChan:
-					result.GreedyTokenized = SortSuggestions(greedyTokenizedResult)
+			if !ok || stagesCtx.Err() != nil {
+				continue
+			}
 
-					// Sort everything now
+			// From patterns dictionary
+			result.ExactWords = append(result.ExactWords, channelPatternDictResult.exactWords...)
+			result.PatternDictionarySuggestions = sortSuggestions(channelPatternDictResult.suggestions, rejections)
+			result.MissingStages &^= VARNAM_STAGE_PATTERN_DICTIONARY
 
-					result.ExactWords = SortSuggestions(result.ExactWords)
-					result.ExactMatches = SortSuggestions(result.ExactMatches)
-					result.DictionarySuggestions = SortSuggestions(result.DictionarySuggestions)
-					result.PatternDictionarySuggestions = SortSuggestions(result.PatternDictionarySuggestions)
+		case tokenizerSugs, ok := <-tokenizerSugsChan:
+			tokenizerSugsChan = nil
+			pending &^= VARNAM_STAGE_TOKENIZER
 
-					if tokenizerSugsCalled {
-						select {
-						case <-ctx.Done():
-							return nil, result
+			if ok && stagesCtx.Err() == nil {
+				result.TokenizerSuggestions = sortSuggestions(tokenizerSugs, rejections)
+				result.MissingStages &^= VARNAM_STAGE_TOKENIZER
+			}
+		}
+	}
 
-						case tokenizerSugs := <-tokenizerSugsChan:
-							result.TokenizerSuggestions = SortSuggestions(tokenizerSugs)
+	result.ExactWords = sortSuggestions(result.ExactWords, rejections)
 
-							if LOG_TIME_TAKEN {
-								log.Printf("%s took %v\n", "transliteration", time.Since(start))
-							}
+	// User made these for the input, they come first
+	if shortcuts := varnam.getShortcutSuggestions(ctx, word); len(shortcuts) > 0 {
+		result.ExactWords = append(shortcuts, result.ExactWords...)
+	}
 
-							return tokensPointer, result
-						}
+	varnam.removeBlockedWords(ctx, &result)
 
-					} else {
-						if LOG_TIME_TAKEN {
-							log.Printf("%s took %v\n", "transliteration", time.Since(start))
-						}
+	annotateResult(&result, utf8.RuneCountInString(word))
 
-						return tokensPointer, result
-					}
-				}
+	return result
+}
+
+// Set source, matched length & score of suggestions in result
+func annotateResult(result *TransliterationResult, inputLength int) {
+	sources := []struct {
+		sugs   []Suggestion
+		source int
+	}{
+		{result.ExactWords, VARNAM_SOURCE_EXACT_WORD},
+		{result.ExactMatches, VARNAM_SOURCE_EXACT_MATCH},
+		{result.DictionarySuggestions, VARNAM_SOURCE_DICTIONARY},
+		{result.PatternDictionarySuggestions, VARNAM_SOURCE_PATTERN_DICTIONARY},
+		{result.TokenizerSuggestions, VARNAM_SOURCE_TOKENIZER},
+		{result.GreedyTokenized, VARNAM_SOURCE_GREEDY_TOKENIZED},
+	}
+
+	maxWeight := 0
+
+	for _, s := range sources {
+		for i := range s.sugs {
+			sug := &s.sugs[i]
+
+			// Shortcuts are among exact words
+			if sug.Source != VARNAM_SOURCE_SHORTCUT {
+				sug.Source = s.source
+			}
+
+			// Partial matches have it set already
+			if sug.MatchedLength == 0 || sug.MatchedLength > inputLength {
+				sug.MatchedLength = inputLength
+			}
+
+			if sug.Weight > maxWeight {
+				maxWeight = sug.Weight
+			}
+		}
+	}
+
+	for _, s := range sources {
+		for i := range s.sugs {
+			sug := &s.sugs[i]
+			if maxWeight > 0 && sug.Weight > 0 {
+				sug.Score = float64(sug.Weight) / float64(maxWeight)
+			} else {
+				sug.Score = 0
 			}
 		}
 	}
@@ -400,9 +648,50 @@ func (varnam *Varnam) TransliterateAdvancedWithContext(ctx context.Context, word
 
 	default:
 		_, result := varnam.transliterate(ctx, word)
-		resultChannel <- result
-		close(resultChannel)
+
+		// Caller may have stopped receiving after cancelling
+		select {
+		case <-ctx.Done():
+			return
+		case resultChannel <- result:
+			close(resultChannel)
+		}
+	}
+}
+
+// TransliterateWithOptions transliterate with config overridden for
+// this call only. Safe to call from many goroutines at once.
+// Returns error if a lookup failed (see Error) or
+// ctx.Err() if the context got done before finishing.
+func (varnam *Varnam) TransliterateWithOptions(ctx context.Context, word string, opts Options) (TransliterationResult, error) {
+	ctx = context.WithValue(ctx, optionsContextKey{}, opts)
+
+	_, result, err := varnam.transliterateWithDeadline(ctx, word, time.Time{})
+	if err != nil {
+		return result, err
 	}
+	return result, ctx.Err()
+}
+
+// TransliterateWithDeadline transliterate, but stop waiting for
+// dictionary & tokenizer suggestions after deadline. Instead of
+// an empty result, whatever stages finished by then are returned.
+// Greedy tokenized output is always waited for since it's the
+// least to show, cancel ctx to stop that too.
+// See result.MissingStages for what's left out & result.SkippedStages
+// for what's not done at all for too long inputs.
+// Returns error if a lookup failed or ctx got done.
+func (varnam *Varnam) TransliterateWithDeadline(ctx context.Context, word string, deadline time.Time) (TransliterationResult, error) {
+	_, result, err := varnam.transliterateWithDeadline(ctx, word, deadline)
+	if err != nil {
+		return result, err
+	}
+	return result, ctx.Err()
+}
+
+// Suggestions all suggestions in the order Transliterate() gives
+func (result TransliterationResult) Suggestions() []Suggestion {
+	return flattenTR(result)
 }
 
 // Flatten TransliterationResult struct to a suggestion array
@@ -463,8 +752,14 @@ func (varnam *Varnam) TransliterateWithContext(ctx context.Context, word string,
 		return
 	default:
 		_, result := varnam.transliterate(ctx, word)
-		resultChannel <- flattenTR(result)
-		close(resultChannel)
+
+		// Caller may have stopped receiving after cancelling
+		select {
+		case <-ctx.Done():
+			return
+		case resultChannel <- flattenTR(result):
+			close(resultChannel)
+		}
 	}
 }
 
@@ -473,19 +768,28 @@ func (varnam *Varnam) TransliterateGreedyTokenized(word string) []Suggestion {
 	ctx := context.Background()
 
 	tokens := varnam.tokenizeWord(ctx, word, VARNAM_MATCH_EXACT, false)
-	return varnam.tokensToSuggestions(ctx, tokens, false, varnam.TokenizerSuggestionsLimit)
+	sugs := varnam.tokensToSuggestions(ctx, tokens, false, varnam.options(ctx).TokenizerSuggestionsLimit)
+	sugs = varnam.removeBlockedSuggestions(ctx, sugs)
+
+	annotateResult(&TransliterationResult{GreedyTokenized: sugs}, utf8.RuneCountInString(word))
+
+	return sugs
 }
 
 // ReverseTransliterate do a reverse transliteration
 func (varnam *Varnam) ReverseTransliterate(word string) ([]Suggestion, error) {
-	var results []Suggestion
-	ctx := context.Background()
+	ctx := withLookupErrors(context.Background())
+
+	results := varnam.reverseTransliterate(ctx, word, varnam.options(ctx).TokenizerSuggestionsLimit)
+
+	return results, getLookupError(ctx)
+}
 
+// Upto limit patterns of word, most likely first
+func (varnam *Varnam) reverseTransliterate(ctx context.Context, word string, limit int) []Suggestion {
 	tokens := varnam.splitTextByConjunct(ctx, word)
 
-	if varnam.Debug {
-		fmt.Println(tokens)
-	}
+	varnam.debug("Tokenized", "tokens", tokens)
 
 	for i := range tokens {
 		for j, symbol := range tokens[i].symbols {
@@ -494,19 +798,23 @@ func (varnam *Varnam) ReverseTransliterate(word string) ([]Suggestion, error) {
 		}
 	}
 
-	results = SortSuggestions(varnam.tokensToSuggestions(ctx, &tokens, false, varnam.TokenizerSuggestionsLimit))
-
-	return results, nil
+	return SortSuggestions(varnam.tokensToSuggestions(ctx, &tokens, false, limit))
 }
 
 // RegisterPatternWordPartializer A word partializer remove word ending
 // with proper alternative so that the word can be tokenized further.
 // Useful for malayalam to replace last chil letter with its root
 func (varnam *Varnam) RegisterPatternWordPartializer(cb func(*Suggestion)) {
+	varnam.configMutex.Lock()
+	defer varnam.configMutex.Unlock()
+
 	varnam.PatternWordPartializers = append(varnam.PatternWordPartializers, cb)
+
+	varnam.cache.clear()
 }
 
-// Init Initialize varnam. Dictionary will be created if it doesn't exist
+// Init Initialize varnam. Dictionary will be created if it doesn't exist.
+// Base dictionary & blocklists shipped along with the VST are loaded.
 func Init(vstPath string, dictPath string) (*Varnam, error) {
 	varnam := Varnam{}
 
@@ -519,6 +827,20 @@ func Init(vstPath string, dictPath string) (*Varnam, error) {
 		return nil, err
 	}
 
+	varnam.cache = newVarnamCache(varnam.dictConn, varnam.vstConn, VARNAM_CACHE_SIZE)
+
+	err = varnam.loadShippedBaseDictionary(vstPath)
+	if err != nil {
+		varnam.Close()
+		return nil, err
+	}
+
+	err = varnam.loadShippedBlocklists(vstPath)
+	if err != nil {
+		varnam.Close()
+		return nil, err
+	}
+
 	varnam.setDefaultConfig()
 
 	return &varnam, nil
@@ -551,6 +873,20 @@ func InitFromID(schemeID string) (*Varnam, error) {
 		return nil, err
 	}
 
+	varnam.cache = newVarnamCache(varnam.dictConn, varnam.vstConn, VARNAM_CACHE_SIZE)
+
+	err = varnam.loadShippedBaseDictionary(vstPath)
+	if err != nil {
+		varnam.Close()
+		return nil, err
+	}
+
+	err = varnam.loadShippedBlocklists(vstPath)
+	if err != nil {
+		varnam.Close()
+		return nil, err
+	}
+
 	varnam.setDefaultConfig()
 
 	return &varnam, nil
@@ -561,8 +897,12 @@ func (varnam *Varnam) Close() error {
 	if varnam.vstConn != nil {
 		varnam.vstConn.Close()
 	}
+	// Holds a connection of dictConn
+	varnam.cache.close()
+	varnam.dictIndex.close()
 	if varnam.dictConn != nil {
 		varnam.dictConn.Close()
 	}
+	varnam.closeBaseDictionaries()
 	return nil
 }
@@ -2,10 +2,14 @@ package govarnam
 
 import (
 	"context"
+	"errors"
 	"log"
 	"os"
+	"os/exec"
 	"path"
+	"runtime"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 )
@@ -59,11 +63,11 @@ func TestMLLearn(t *testing.T) {
 	varnam := getVarnamInstance("ml")
 
 	// Non language word. Should give error
-	assertEqual(t, varnam.Learn("Шаблон", 0) != nil, true)
+	assertEqual(t, errors.Is(varnam.Learn("Шаблон", 0), ErrNothingToLearn), true)
 
 	// Varnam will find the first word to find. Here it will be just "ഉ".
 	// Since it's single conjunct, will produce an error
-	assertEqual(t, varnam.Learn("ഉaള്ളിൽ", 0) != nil, true)
+	assertEqual(t, errors.Is(varnam.Learn("ഉaള്ളിൽ", 0), ErrSingleConjunct), true)
 
 	assertEqual(t, varnam.Learn("Шаблонഉള്ളിൽ", 0) != nil, true)
 
@@ -103,7 +107,14 @@ func TestMLLearn(t *testing.T) {
 	// varnam.Debug(true)
 	sugs := varnam.TransliterateAdvanced("malayala").DictionarySuggestions
 
-	assertEqual(t, sugs[0], Suggestion{"മലയാളം", VARNAM_LEARNT_WORD_MIN_WEIGHT, sugs[0].LearnedOn})
+	assertEqual(t, sugs[0], Suggestion{
+		Word:          "മലയാളം",
+		Weight:        VARNAM_LEARNT_WORD_MIN_WEIGHT,
+		LearnedOn:     sugs[0].LearnedOn,
+		Source:        VARNAM_SOURCE_DICTIONARY,
+		MatchedLength: 8,
+		Score:         sugs[0].Score,
+	})
 
 	// Check the time learnt is right (UTC) ?
 	learnedOn := time.Unix(int64(sugs[1].LearnedOn), 0)
@@ -112,7 +123,14 @@ func TestMLLearn(t *testing.T) {
 		t.Errorf("Learn time %v (%v) not in between %v and %v", learnedOn, sugs[1].LearnedOn, start1SecondBefore, end1SecondAfter)
 	}
 
-	assertEqual(t, sugs[1], Suggestion{"മലയാളത്തിൽ", VARNAM_LEARNT_WORD_MIN_WEIGHT, sugs[1].LearnedOn})
+	assertEqual(t, sugs[1], Suggestion{
+		Word:          "മലയാളത്തിൽ",
+		Weight:        VARNAM_LEARNT_WORD_MIN_WEIGHT,
+		LearnedOn:     sugs[1].LearnedOn,
+		Source:        VARNAM_SOURCE_DICTIONARY,
+		MatchedLength: 8,
+		Score:         sugs[1].Score,
+	})
 
 	// Learn the word again
 	// This word will now be at the top
@@ -121,7 +139,14 @@ func TestMLLearn(t *testing.T) {
 	checkError(err)
 
 	sug := varnam.TransliterateAdvanced("malayala").DictionarySuggestions[0]
-	assertEqual(t, sug, Suggestion{"മലയാളത്തിൽ", VARNAM_LEARNT_WORD_MIN_WEIGHT + 1, sug.LearnedOn})
+	assertEqual(t, sug, Suggestion{
+		Word:          "മലയാളത്തിൽ",
+		Weight:        VARNAM_LEARNT_WORD_MIN_WEIGHT + 1,
+		LearnedOn:     sug.LearnedOn,
+		Source:        VARNAM_SOURCE_DICTIONARY,
+		MatchedLength: 8,
+		Score:         sug.Score,
+	})
 
 	// Subsequent pattern can be smaller now (no need of "thth")
 	assertEqual(t, varnam.TransliterateAdvanced("malayalathil").ExactWords[0].Word, "മലയാളത്തിൽ")
@@ -193,6 +218,39 @@ func TestMLTrain(t *testing.T) {
 
 	err = varnam.Unlearn("computer")
 	assertEqual(t, err.Error(), "nothing to unlearn")
+	assertEqual(t, errors.Is(err, ErrWordNotFound), true)
+}
+
+func TestMLSuggestionSource(t *testing.T) {
+	varnam := getVarnamInstance("ml")
+
+	err := varnam.Train("india", "ഇന്ത്യ")
+	checkError(err)
+
+	// Only "india" matched the pattern, rest got tokenized
+	result := varnam.TransliterateAdvanced("indiayil")
+
+	sug := result.PatternDictionarySuggestions[0]
+	assertEqual(t, sug.Source, VARNAM_SOURCE_PATTERN_DICTIONARY)
+	assertEqual(t, sug.MatchedLength, len("india"))
+
+	assertEqual(t, result.GreedyTokenized[0].Source, VARNAM_SOURCE_GREEDY_TOKENIZED)
+	assertEqual(t, result.GreedyTokenized[0].MatchedLength, len("indiayil"))
+
+	highest := 0.0
+	for _, sug := range result.Suggestions() {
+		if sug.Score < 0 || sug.Score > 1 {
+			t.Errorf("Score %v of %s is not in 0-1", sug.Score, sug.Word)
+		}
+		if sug.Score > highest {
+			highest = sug.Score
+		}
+	}
+	assertEqual(t, highest, 1.0)
+
+	result = varnam.TransliterateAdvanced("india")
+	assertEqual(t, result.ExactWords[0].Source, VARNAM_SOURCE_EXACT_WORD)
+	assertEqual(t, result.ExactWords[0].MatchedLength, len("india"))
 }
 
 func TestAnyCharacterInputWillWorkFine(t *testing.T) {
@@ -418,10 +476,14 @@ func TestMLExportAndImport(t *testing.T) {
 	`)
 	varnam.Import(filePath)
 
-	assertEqual(t, varnam.TransliterateAdvanced("algeria").ExactWords[0], Suggestion{
-		Word:      "അൾജീരിയ",
-		Weight:    VARNAM_LEARNT_WORD_MIN_WEIGHT + 25,
-		LearnedOn: 1531131220,
+	sug := varnam.TransliterateAdvanced("algeria").ExactWords[0]
+	assertEqual(t, sug, Suggestion{
+		Word:          "അൾജീരിയ",
+		Weight:        VARNAM_LEARNT_WORD_MIN_WEIGHT + 25,
+		LearnedOn:     1531131220,
+		Source:        VARNAM_SOURCE_EXACT_WORD,
+		MatchedLength: 7,
+		Score:         sug.Score,
 	})
 }
 
@@ -509,3 +571,221 @@ func TestMLNativePartialWordsInInput(t *testing.T) {
 	assertEqual(t, varnam.TransliterateAdvanced("puസ്ത").DictionarySuggestions[0].Word, "പുസ്തകം")
 	assertEqual(t, varnam.TransliterateAdvanced("ആലippazham").DictionarySuggestions[0].Word, "ആലിപ്പഴം")
 }
+
+func TestMLBaseDictionary(t *testing.T) {
+	basePath := makeBaseDictionary("ml-base.learnings", `
+		INSERT INTO words (word, weight, learned_on) VALUES ('മലയാളം', 30, 100);
+	`)
+
+	varnam, err := Init(getVarnamInstance("ml").VSTPath, path.Join(testTempDir, "ml-base-user.learnings"))
+	checkError(err)
+	defer varnam.Close()
+
+	err = varnam.AddBaseDictionary(basePath, 1)
+	checkError(err)
+
+	assertEqual(t, varnam.TransliterateAdvanced("malayalam").ExactWords[0].Word, "മലയാളം")
+
+	// Unlearning a base word suppresses it for this user
+	err = varnam.Unlearn("മലയാളം")
+	checkError(err)
+	assertEqual(t, len(varnam.TransliterateAdvanced("malayalam").ExactWords), 0)
+
+	// Learning it again brings it back
+	err = varnam.Learn("മലയാളം", 0)
+	checkError(err)
+	assertEqual(t, varnam.TransliterateAdvanced("malayalam").ExactWords[0].Word, "മലയാളം")
+}
+
+// Runs in a separate process spawned by TestMLLearnMultiProcess
+func TestMLLearnHelperProcess(t *testing.T) {
+	dictPath := os.Getenv("GOVARNAM_TEST_HELPER_LEARNINGS")
+	if dictPath == "" {
+		return
+	}
+
+	varnam, err := Init(getVarnamInstance("ml").VSTPath, dictPath)
+	checkError(err)
+	defer varnam.Close()
+
+	for i := 0; i < 20; i++ {
+		checkError(varnam.Learn("മലയാളം", 0))
+		checkError(varnam.Train("malayalam", "മലയാളം"))
+		checkError(varnam.Unlearn("കേരളം"))
+
+		_, err = varnam.LearnMany([]WordInfo{{0, "കേരളം", 0, 0}})
+		checkError(err)
+	}
+}
+
+func TestMLLearnMultiProcess(t *testing.T) {
+	if os.Getenv("GOVARNAM_TEST_HELPER_LEARNINGS") != "" {
+		return
+	}
+
+	dictPath := path.Join(testTempDir, "ml-multi-process.learnings")
+
+	varnam, err := Init(getVarnamInstance("ml").VSTPath, dictPath)
+	checkError(err)
+	defer varnam.Close()
+
+	processes := 4
+
+	var cmds []*exec.Cmd
+	for i := 0; i < processes; i++ {
+		cmd := exec.Command(os.Args[0], "-test.run=^TestMLLearnHelperProcess$")
+		cmd.Env = append(os.Environ(), "GOVARNAM_TEST_HELPER_LEARNINGS="+dictPath)
+		checkError(cmd.Start())
+		cmds = append(cmds, cmd)
+	}
+
+	// Read while the others are writing
+	for i := 0; i < 20; i++ {
+		varnam.TransliterateAdvanced("malayalam")
+	}
+
+	for _, cmd := range cmds {
+		assertEqual(t, cmd.Wait(), nil)
+	}
+
+	// Each Learn & Train increments weight by one
+	wordInfo, err := varnam.getWordInfo("മലയാളം")
+	checkError(err)
+	assertEqual(t, wordInfo.weight, VARNAM_LEARNT_WORD_MIN_WEIGHT-1+processes*20*2)
+}
+
+// Run with -race
+func TestMLConcurrentUse(t *testing.T) {
+	varnam, err := Init(getVarnamInstance("ml").VSTPath, path.Join(testTempDir, "ml-concurrent.learnings"))
+	checkError(err)
+	defer varnam.Close()
+
+	words := []string{"മലയാളം", "കേരളം", "ആലപ്പുഴ", "പുസ്തകം"}
+	inputs := []string{"malayalam", "keralam", "aalappuzha", "pusthakam", "mala"}
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			opts := varnam.GetOptions()
+			opts.TokenizerSuggestionsLimit = i + 1
+
+			for j := 0; j < 10; j++ {
+				input := inputs[(i+j)%len(inputs)]
+
+				result, err := varnam.TransliterateWithOptions(context.Background(), input, opts)
+				checkError(err)
+				assertEqual(t, len(result.TokenizerSuggestions) <= i+1, true)
+
+				varnam.Transliterate(input)
+				varnam.GetSuggestions(context.Background(), "മല")
+
+				checkError(varnam.Learn(words[(i+j)%len(words)], 0))
+			}
+		}(i)
+	}
+
+	// Config changes while transliterating
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		for j := 0; j < 10; j++ {
+			varnam.UpdateOptions(func(opts *Options) {
+				opts.DictionarySuggestionsLimit = j + 1
+				opts.DictionaryMatchExact = j%2 == 0
+			})
+		}
+	}()
+
+	wg.Wait()
+
+	assertEqual(t, varnam.GetOptions().DictionarySuggestionsLimit, 10)
+	assertEqual(t, len(varnam.TransliterateAdvanced("malayalam").ExactWords) > 0, true)
+}
+
+// Wait till goroutines started by a test are gone
+func waitForGoroutines(t *testing.T, baseline int) {
+	deadline := time.Now().Add(5 * time.Second)
+	for runtime.NumGoroutine() > baseline {
+		if time.Now().After(deadline) {
+			buf := make([]byte, 1<<20)
+			t.Fatalf("Goroutines leaked: %d > %d\n%s", runtime.NumGoroutine(), baseline, buf[:runtime.Stack(buf, true)])
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestMLCancelNoLeak(t *testing.T) {
+	varnam := getVarnamInstance("ml")
+
+	// Warm up so that DB connections are open already
+	varnam.TransliterateAdvanced("malayalam")
+
+	baseline := runtime.NumGoroutine()
+
+	for i := 0; i < 50; i++ {
+		// Cancel at different stages of the pipeline
+		ctx, cancel := context.WithTimeout(context.Background(), time.Duration(i*20)*time.Microsecond)
+
+		// Nobody reads from these channels
+		go varnam.TransliterateWithContext(ctx, "malayalam", make(chan []Suggestion))
+		go varnam.TransliterateAdvancedWithContext(ctx, "malayalam", make(chan TransliterationResult))
+
+		varnam.TransliterateWithOptions(ctx, "malayalam", varnam.GetOptions())
+
+		<-ctx.Done()
+		cancel()
+	}
+
+	waitForGoroutines(t, baseline)
+}
+
+func TestMLTransliterateWithDeadline(t *testing.T) {
+	varnam := getVarnamInstance("ml")
+
+	// Enough time
+	result, err := varnam.TransliterateWithDeadline(context.Background(), "malayalam", time.Now().Add(time.Minute))
+	checkError(err)
+	assertEqual(t, result.MissingStages, 0)
+	assertEqual(t, flattenTR(result)[0].Word, varnam.Transliterate("malayalam")[0].Word)
+
+	// Complete result is cached, deadline doesn't matter
+	result, err = varnam.TransliterateWithDeadline(context.Background(), "malayalam", time.Now())
+	checkError(err)
+	assertEqual(t, result.MissingStages, 0)
+
+	varnam.SetCacheSize(0)
+	defer varnam.SetCacheSize(VARNAM_CACHE_SIZE)
+
+	// Deadline already passed, greedy tokenized is still given
+	result, err = varnam.TransliterateWithDeadline(context.Background(), "malayalam", time.Now())
+	checkError(err)
+	assertEqual(t, result.MissingStages, VARNAM_STAGE_DICTIONARY|VARNAM_STAGE_PATTERN_DICTIONARY|VARNAM_STAGE_TOKENIZER)
+	assertEqual(t, result.GreedyTokenized[0].Word, "മലയലം")
+	assertEqual(t, len(result.ExactWords), 0)
+
+	// Cancelled gives nothing
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	result, err = varnam.TransliterateWithDeadline(ctx, "malayalam", time.Now().Add(time.Minute))
+	assertEqual(t, err, context.Canceled)
+	assertEqual(t, result.MissingStages, VARNAM_STAGE_ALL)
+	assertEqual(t, len(result.GreedyTokenized), 0)
+
+	// Too long input is only greedy tokenized
+	long := strings.Repeat("mala", VARNAM_INPUT_MAX_LENGTH)
+	result, err = varnam.TransliterateWithDeadline(context.Background(), long, time.Now().Add(time.Minute))
+	checkError(err)
+	assertEqual(t, result.MissingStages, 0)
+	assertEqual(t, result.SkippedStages, VARNAM_STAGE_DICTIONARY|VARNAM_STAGE_PATTERN_DICTIONARY|VARNAM_STAGE_TOKENIZER)
+	assertEqual(t, result.GreedyTokenized[0].Word, strings.Repeat("മല", VARNAM_INPUT_MAX_LENGTH))
+
+	// Pattern longer than any symbol's doesn't make a huge query
+	symbols := varnam.findLongestPatternMatchSymbols(context.Background(), []rune(long), VARNAM_MATCH_ALL, VARNAM_TOKEN_ACCEPT_IF_STARTS_WITH)
+	assertEqual(t, len(symbols) > 0, true)
+	assertEqual(t, strings.HasPrefix(long, symbols[0].Pattern), true)
+}
@@ -1,13 +1,16 @@
 package govarnam
 
 import (
+	"context"
 	"log"
 	"os"
 	"path"
 	"reflect"
 	"runtime/debug"
+	"strings"
 	"sync"
 	"testing"
+	"time"
 )
 
 var varnamInstances = map[string]*Varnam{}
@@ -96,6 +99,44 @@ func TestEnv(t *testing.T) {
 	assertEqual(t, fileExists(path.Join(testTempDir, "ml.vst.learnings")), true)
 }
 
+func TestPatternDictionaryMatchedLength(t *testing.T) {
+	varnam := makeTestVarnam("matched-length", simpleTestSymbols, 0)
+	defer varnam.Close()
+
+	// Native characters are more bytes than characters
+	checkError(varnam.Train("കmla", "കമല"))
+
+	result, err := varnam.TransliterateWithOptions(context.Background(), "കmlapa", varnam.GetOptions())
+	checkError(err)
+
+	found := false
+	for _, sug := range result.PatternDictionarySuggestions {
+		if sug.Word == "കമലപ" {
+			found = true
+			assertEqual(t, sug.MatchedLength, 4)
+		}
+	}
+	assertEqual(t, found, true)
+}
+
+func TestTransliterateLongInput(t *testing.T) {
+	varnam := makeTestVarnam("long-input", simpleTestSymbols, 0)
+	defer varnam.Close()
+
+	long := strings.Repeat("ka", VARNAM_INPUT_MAX_LENGTH)
+
+	// Skipped stages aren't missing, more time won't get them
+	result, err := varnam.TransliterateWithDeadline(context.Background(), long, time.Now().Add(time.Minute))
+	checkError(err)
+	assertEqual(t, result.MissingStages, 0)
+	assertEqual(t, result.SkippedStages, VARNAM_STAGE_DICTIONARY|VARNAM_STAGE_PATTERN_DICTIONARY|VARNAM_STAGE_TOKENIZER)
+	assertEqual(t, result.GreedyTokenized[0].Word, strings.Repeat("ക", VARNAM_INPUT_MAX_LENGTH))
+
+	result, err = varnam.TransliterateWithDeadline(context.Background(), "kaka", time.Now().Add(time.Minute))
+	checkError(err)
+	assertEqual(t, result.SkippedStages, 0)
+}
+
 func TestMain(m *testing.M) {
 	schemeDetails, err := GetAllSchemeDetails()
 
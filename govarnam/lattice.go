@@ -0,0 +1,112 @@
+package govarnam
+
+/**
+ * govarnam - An Indian language transliteration library
+ * Copyright Subin Siby <mail at subinsb (.) com>, 2021
+ * Licensed under AGPL-3.0-only. See LICENSE.txt
+ */
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// LatticeToken a part of input & what it can be written as.
+// UIs can show these per syllable and let the user
+// switch one of them, see BuildWord().
+type LatticeToken struct {
+	// Input characters this token is made from,
+	// Start inclusive & End exclusive (in runes)
+	Start int
+	End   int
+	Input string
+
+	// Values this token can have, most likely first.
+	// Non-language characters have only themselves.
+	Alternatives []LatticeAlternative
+}
+
+// LatticeAlternative a value a token can have
+type LatticeAlternative struct {
+	Value  string
+	Weight int
+}
+
+// TokenLattice tokens of word with all their alternatives
+func (varnam *Varnam) TokenLattice(ctx context.Context, word string) ([]LatticeToken, error) {
+	var lattice []LatticeToken
+
+	ctx = varnam.withOptions(ctx)
+	ctx = withLookupErrors(ctx)
+
+	runes := []rune(word)
+	tokens := *varnam.tokenizeWord(ctx, word, VARNAM_MATCH_ALL, false)
+
+	if err := getLookupError(ctx); err != nil {
+		return lattice, err
+	}
+	if ctx.Err() != nil {
+		return lattice, ctx.Err()
+	}
+
+	start := 0
+	for i, token := range tokens {
+		latticeToken := LatticeToken{
+			Start: start,
+			End:   token.position + 1,
+			Input: string(runes[start : token.position+1]),
+		}
+
+		if token.tokenType == VARNAM_TOKEN_SYMBOL {
+			// Same value can come from different patterns
+			seen := map[string]bool{}
+
+			for _, symbol := range token.symbols {
+				value := getSymbolValue(symbol, i)
+				if seen[value] {
+					continue
+				}
+				seen[value] = true
+
+				latticeToken.Alternatives = append(latticeToken.Alternatives, LatticeAlternative{
+					Value:  value,
+					Weight: getSymbolWeight(symbol),
+				})
+			}
+		} else {
+			latticeToken.Alternatives = []LatticeAlternative{{Value: token.character}}
+		}
+
+		lattice = append(lattice, latticeToken)
+		start = token.position + 1
+	}
+
+	return lattice, nil
+}
+
+// BuildWord make word from lattice with the alternative at
+// choices[i] for lattice[i]. Tokens without a choice get
+// their first alternative.
+func (varnam *Varnam) BuildWord(lattice []LatticeToken, choices []int) (string, error) {
+	if len(choices) > len(lattice) {
+		return "", &Error{Code: VARNAM_MISUSE, Message: fmt.Sprintf("%d choices given for %d tokens", len(choices), len(lattice))}
+	}
+
+	var word []string
+
+	for i, token := range lattice {
+		choice := 0
+		if i < len(choices) {
+			choice = choices[i]
+		}
+
+		if choice < 0 || choice >= len(token.Alternatives) {
+			return "", &Error{Code: VARNAM_MISUSE, Message: fmt.Sprintf("No alternative %d for token %q", choice, token.Input)}
+		}
+
+		word = append(word, token.Alternatives[choice].Value)
+	}
+
+	return strings.Join(word, ""), nil
+}
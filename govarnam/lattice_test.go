@@ -0,0 +1,51 @@
+package govarnam
+
+import (
+	"context"
+	"testing"
+)
+
+func TestTokenLattice(t *testing.T) {
+	varnam := makeTestVarnam("lattice", kalaTestSymbols, 0)
+	defer varnam.Close()
+
+	lattice, err := varnam.TokenLattice(context.Background(), "kala!")
+	checkError(err)
+
+	assertEqual(t, len(lattice), 3)
+
+	assertEqual(t, lattice[0].Input, "ka")
+	assertEqual(t, len(lattice[0].Alternatives), 1)
+
+	assertEqual(t, lattice[1].Start, 2)
+	assertEqual(t, lattice[1].End, 4)
+	assertEqual(t, lattice[1].Input, "la")
+	assertEqual(t, len(lattice[1].Alternatives), 2)
+	assertEqual(t, lattice[1].Alternatives[0].Value, "ല")
+	assertEqual(t, lattice[1].Alternatives[1].Value, "ള")
+
+	// Non-language character
+	assertEqual(t, lattice[2].Input, "!")
+	assertEqual(t, lattice[2].Alternatives[0].Value, "!")
+
+	word, err := varnam.BuildWord(lattice, nil)
+	checkError(err)
+	assertEqual(t, word, "കല!")
+
+	// Switch just one syllable
+	word, err = varnam.BuildWord(lattice, []int{0, 1})
+	checkError(err)
+	assertEqual(t, word, "കള!")
+
+	_, err = varnam.BuildWord(lattice, []int{0, 2})
+	assertEqual(t, ErrorCode(err), VARNAM_MISUSE)
+
+	_, err = varnam.BuildWord(lattice, []int{0, 0, 0, 0})
+	assertEqual(t, ErrorCode(err), VARNAM_MISUSE)
+
+	// Cancelled
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = varnam.TokenLattice(ctx, "kala")
+	assertEqual(t, err, context.Canceled)
+}
@@ -0,0 +1,268 @@
+package govarnam
+
+/**
+ * govarnam - An Indian language transliteration library
+ * Copyright Subin Siby <mail at subinsb (.) com>, 2021
+ * Licensed under AGPL-3.0-only. See LICENSE.txt
+ */
+
+import (
+	"context"
+	sql "database/sql"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// DictionaryLayer is a read-only dictionary stacked below
+// the user's learnings. A system or organization wide curated
+// word list can be shipped as one without copying it into
+// every user's learnings file.
+//
+// It is a learnings file made by varnam itself (Learn, Import)
+// so that the words in it are already normalized.
+type DictionaryLayer struct {
+	Path string
+
+	// Weights of words from this layer are multiplied by this
+	// before merging with other layers. Use a value less than 1
+	// to let user's learnings rank above the base dictionary.
+	WeightScale float64
+
+	conn *sql.DB
+
+	// See SetDictionaryIndex()
+	index *dictionaryIndex
+}
+
+func (layer *DictionaryLayer) scaleWeight(weight int) int {
+	return int(float64(weight) * layer.WeightScale)
+}
+
+// Curated dictionary shipped along with the VST, if there's one
+func (varnam *Varnam) loadShippedBaseDictionary(vstPath string) error {
+	baseDictPath := findBaseDictionaryPath(vstPath, varnam.SchemeDetails.LangCode)
+	if !fileExists(baseDictPath) {
+		return nil
+	}
+	return varnam.AddBaseDictionary(baseDictPath, VARNAM_BASE_DICTIONARY_WEIGHT_SCALE)
+}
+
+// AddBaseDictionary stack a read-only dictionary below user's learnings.
+// Layers added first have higher priority when merging.
+func (varnam *Varnam) AddBaseDictionary(dictPath string, weightScale float64) error {
+	if !fileExists(dictPath) {
+		return fmt.Errorf("Couldn't find base dictionary %q", dictPath)
+	}
+
+	conn, err := openDB("file:" + dictPath + "?mode=ro&" + busyTimeoutParam())
+	if err != nil {
+		return err
+	}
+
+	// Make sure it's a learnings DB
+	_, err = conn.Exec("SELECT word FROM words_fts LIMIT 1; SELECT pattern FROM patterns LIMIT 1")
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("%q is not a learnings file: %s", dictPath, err.Error())
+	}
+
+	layer := &DictionaryLayer{
+		Path:        dictPath,
+		WeightScale: weightScale,
+		conn:        conn,
+	}
+
+	if varnam.getDictionaryIndex() != nil {
+		layer.index, err = newDictionaryIndex(conn, false)
+		if err != nil {
+			conn.Close()
+			return dictError(err)
+		}
+	}
+
+	varnam.configMutex.Lock()
+	varnam.BaseDictionaries = append(varnam.BaseDictionaries, layer)
+	varnam.configMutex.Unlock()
+
+	varnam.cache.clear()
+
+	return nil
+}
+
+func (varnam *Varnam) closeBaseDictionaries() {
+	for _, layer := range varnam.BaseDictionaries {
+		if layer.conn != nil {
+			layer.conn.Close()
+		}
+	}
+}
+
+// Find which of the given words the user has unlearnt
+// from base dictionaries
+func (varnam *Varnam) getSuppressedWords(ctx context.Context, words []string) map[string]bool {
+	suppressed := map[string]bool{}
+
+	if len(words) == 0 {
+		return suppressed
+	}
+
+	var (
+		placeholders []string
+		vals         []interface{}
+	)
+
+	for _, word := range words {
+		placeholders = append(placeholders, "?")
+		vals = append(vals, word)
+	}
+
+	rows, err := varnam.dictConn.QueryContext(ctx, "SELECT word FROM suppressions WHERE word IN ("+strings.Join(placeholders, ", ")+")", vals...)
+	if err != nil {
+		varnam.lookupFailed(ctx, dictError(err))
+		return suppressed
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var word string
+		rows.Scan(&word)
+		suppressed[word] = true
+	}
+
+	return suppressed
+}
+
+// Check if word is in any of the base dictionaries
+func (varnam *Varnam) inBaseDictionaries(word string) bool {
+	for _, layer := range varnam.getBaseDictionaries() {
+		var count int
+		layer.conn.QueryRow("SELECT COUNT(*) FROM words WHERE word = ?", word).Scan(&count)
+
+		if count > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Remove suppressed words from results of a base dictionary
+func (varnam *Varnam) removeSuppressedResults(ctx context.Context, results []searchDictionaryResult) []searchDictionaryResult {
+	var words []string
+	for i := range results {
+		words = append(words, results[i].word)
+	}
+
+	suppressed := varnam.getSuppressedWords(ctx, words)
+	if len(suppressed) == 0 {
+		return results
+	}
+
+	var filtered []searchDictionaryResult
+	for i := range results {
+		if !suppressed[results[i].word] {
+			filtered = append(filtered, results[i])
+		}
+	}
+	return filtered
+}
+
+// Remove suppressed words from suggestions
+func (varnam *Varnam) removeSuppressedSuggestions(ctx context.Context, sugs []Suggestion) []Suggestion {
+	if len(varnam.getBaseDictionaries()) == 0 {
+		return sugs
+	}
+
+	var words []string
+	for i := range sugs {
+		words = append(words, sugs[i].Word)
+	}
+
+	suppressed := varnam.getSuppressedWords(ctx, words)
+	if len(suppressed) == 0 {
+		return sugs
+	}
+
+	var filtered []Suggestion
+	for i := range sugs {
+		if !suppressed[sugs[i].Word] {
+			filtered = append(filtered, sugs[i])
+		}
+	}
+	return filtered
+}
+
+// Merge dictionary search results from all layers.
+// Same word from different layers is combined into one
+// having the highest weight.
+func mergeSearchDictionaryResults(results []searchDictionaryResult, searchType searchDictionaryType, limit int) []searchDictionaryResult {
+	var merged []searchDictionaryResult
+	seen := map[string]int{}
+
+	for _, item := range results {
+		key := item.word
+		if searchType == searchMatches {
+			key = item.match
+		}
+
+		if i, ok := seen[key]; ok {
+			if item.weight > merged[i].weight {
+				merged[i].weight = item.weight
+			}
+			if item.learnedOn > merged[i].learnedOn {
+				merged[i].learnedOn = item.learnedOn
+			}
+			continue
+		}
+
+		seen[key] = len(merged)
+		merged = append(merged, item)
+	}
+
+	if searchType == searchStartingWith {
+		sort.SliceStable(merged, func(i, j int) bool {
+			return merged[i].weight > merged[j].weight
+		})
+
+		if len(merged) > limit {
+			merged = merged[:limit]
+		}
+	}
+
+	return merged
+}
+
+// Merge pattern dictionary results from all layers
+func mergePatternDictionarySuggestions(results []PatternDictionarySuggestion, inputLength int, limit int) []PatternDictionarySuggestion {
+	var merged []PatternDictionarySuggestion
+	seen := map[string]int{}
+
+	for _, item := range results {
+		if i, ok := seen[item.Sug.Word]; ok {
+			// Pattern matching input better
+			if patternDictionaryLess(item, merged[i], inputLength) {
+				merged[i].Length = item.Length
+			}
+			if item.Sug.Weight > merged[i].Sug.Weight {
+				merged[i].Sug.Weight = item.Sug.Weight
+			}
+			if item.Sug.LearnedOn > merged[i].Sug.LearnedOn {
+				merged[i].Sug.LearnedOn = item.Sug.LearnedOn
+			}
+			continue
+		}
+
+		seen[item.Sug.Word] = len(merged)
+		merged = append(merged, item)
+	}
+
+	sort.SliceStable(merged, func(i, j int) bool {
+		return patternDictionaryLess(merged[i], merged[j], inputLength)
+	})
+
+	if len(merged) > limit {
+		merged = merged[:limit]
+	}
+
+	return merged
+}
@@ -0,0 +1,112 @@
+package govarnam
+
+import (
+	"context"
+	"os"
+	"path"
+	"testing"
+)
+
+// Make a learnings file to be used as base dictionary
+func makeBaseDictionary(name string, query string) string {
+	dictPath := path.Join(testTempDir, name)
+
+	base := Varnam{}
+	err := base.InitDict(dictPath)
+	checkError(err)
+	defer base.Close()
+
+	_, err = base.dictConn.Exec(query)
+	checkError(err)
+
+	return dictPath
+}
+
+func TestBaseDictionary(t *testing.T) {
+	basePath := makeBaseDictionary("base.learnings", `
+		INSERT INTO words (id, word, weight, learned_on) VALUES
+			(1, 'മലയാളം', 100, 100),
+			(2, 'മലയാളി', 60, 100),
+			(3, 'മലപ്പുറം', 20, 100);
+		INSERT INTO patterns (pattern, word_id) VALUES
+			('malappuram', 3);
+	`)
+
+	varnam := Varnam{}
+	varnam.SchemeDetails.LangCode = "ml"
+	varnam.DictionarySuggestionsLimit = 5
+	varnam.PatternDictionarySuggestionsLimit = 5
+
+	err := varnam.InitDict(path.Join(testTempDir, "user.learnings"))
+	checkError(err)
+	defer varnam.Close()
+
+	_, err = varnam.dictConn.Exec("INSERT INTO words (word, weight, learned_on) VALUES ('മലയാളം', 40, 200), ('മലയോരം', 35, 200)")
+	checkError(err)
+
+	err = varnam.AddBaseDictionary(basePath, 0.5)
+	checkError(err)
+
+	assertEqual(t, varnam.AddBaseDictionary(path.Join(testTempDir, "non-existing.learnings"), 1) != nil, true)
+
+	// Words from both layers, same word merged
+	sugs := varnam.GetSuggestions(context.Background(), "മല")
+	assertEqual(t, len(sugs), 4)
+	assertEqual(t, sugs[0].Word, "മലയാളം")
+	assertEqual(t, sugs[0].Weight, 50)
+	assertEqual(t, sugs[1].Word, "മലയോരം")
+	assertEqual(t, sugs[2].Word, "മലയാളി")
+	assertEqual(t, sugs[2].Weight, 30)
+
+	patternSugs := varnam.getFromPatternDictionary(context.Background(), "malappuram")
+	assertEqual(t, len(patternSugs), 1)
+	assertEqual(t, patternSugs[0].Sug.Word, "മലപ്പുറം")
+	assertEqual(t, patternSugs[0].Sug.Weight, (20+VARNAM_LEARNT_WORD_MIN_WEIGHT)/2)
+
+	// Suppressed words of base dictionary are hidden
+	_, err = varnam.dictConn.Exec("INSERT INTO suppressions (word) VALUES ('മലയാളി'), ('മലപ്പുറം')")
+	checkError(err)
+
+	sugs = varnam.GetSuggestions(context.Background(), "മല")
+	assertEqual(t, len(sugs), 2)
+
+	patternSugs = varnam.getFromPatternDictionary(context.Background(), "malappuram")
+	assertEqual(t, len(patternSugs), 0)
+
+	// Base dictionary is not written to
+	base := Varnam{}
+	err = base.InitDict(basePath)
+	checkError(err)
+	defer base.Close()
+
+	var count int
+	base.dictConn.QueryRow("SELECT COUNT(*) FROM words").Scan(&count)
+	assertEqual(t, count, 3)
+}
+
+func TestShippedBaseDictionary(t *testing.T) {
+	// Own directory, other test VSTs are of ml too
+	vstDir := path.Join(testTempDir, "shipped-base")
+	checkError(os.MkdirAll(vstDir, 0755))
+
+	vstPath := path.Join(vstDir, "ml.vst")
+
+	vm, err := VMInit(vstPath)
+	checkError(err)
+	checkError(vm.VMCreateToken("ka", "ക", "", "", "", VARNAM_SYMBOL_CONSONANT, VARNAM_MATCH_EXACT, 0, 0, false))
+	checkError(vm.VMSetSchemeDetails(SchemeDetails{Identifier: "ml", LangCode: "ml", DisplayName: "Malayalam"}))
+	vm.Close()
+
+	makeBaseDictionary("shipped-base/ml.base.learnings", `
+		INSERT INTO words (id, word, weight, learned_on) VALUES
+			(1, 'കമല', 100, 100);
+	`)
+
+	// Loaded by Init() too, not just InitFromID()
+	varnam, err := Init(vstPath, path.Join(vstDir, "user.learnings"))
+	checkError(err)
+	defer varnam.Close()
+
+	assertEqual(t, len(varnam.getBaseDictionaries()), 1)
+	assertEqual(t, hasSuggestion(varnam.GetSuggestions(context.Background(), "ക"), "കമല"), true)
+}
@@ -12,7 +12,6 @@ import (
 	sql "database/sql"
 	"encoding/json"
 	"fmt"
-	"log"
 	"math"
 	"os"
 	"strconv"
@@ -43,15 +42,6 @@ type exportFormat struct {
 }
 
 func (varnam *Varnam) languageSpecificSanitization(word string) string {
-	if varnam.SchemeDetails.LangCode == "ml" {
-		/* Malayalam has got two ways to write chil letters. Converting the old style to new atomic chil one */
-		word = strings.Replace(word, "ന്‍", "ൻ", -1)
-		word = strings.Replace(word, "ണ്‍", "ൺ", -1)
-		word = strings.Replace(word, "ല്‍", "ൽ", -1)
-		word = strings.Replace(word, "ള്‍", "ൾ", -1)
-		word = strings.Replace(word, "ര്‍", "ർ", -1)
-	}
-
 	if varnam.SchemeDetails.LangCode == "hi" {
 		/* Hindi's DANDA (Purna viram) */
 		word = strings.Replace(word, "।", "", -1)
@@ -62,24 +52,8 @@ func (varnam *Varnam) languageSpecificSanitization(word string) string {
 
 // Sanitize a word, remove unwanted characters before learning
 func (varnam *Varnam) sanitizeWord(word string) string {
-	// Remove leading & trailing whitespaces
-	word = strings.TrimSpace(word)
-
-	word = varnam.languageSpecificSanitization(word)
-
-	// Remove leading ZWJ & ZWNJ
-	firstChar, size := getFirstCharacter(word)
-	if firstChar == ZWJ || firstChar == ZWNJ {
-		word = word[size:]
-	}
-
-	// Remove trailing ZWNJ
-	lastChar, size := getLastCharacter(word)
-	if lastChar == ZWNJ {
-		word = word[0 : len(word)-size]
-	}
-
-	return word
+	word = varnam.normalizeWord(word)
+	return varnam.languageSpecificSanitization(word)
 }
 
 // Learn a word. If already exist, increases weight
@@ -88,11 +62,11 @@ func (varnam *Varnam) Learn(word string, weight int) error {
 	conjuncts := varnam.splitWordByConjunct(word)
 
 	if len(conjuncts) == 0 {
-		return fmt.Errorf("Nothing to learn")
+		return ErrNothingToLearn
 	}
 
 	if len(conjuncts) == 1 {
-		return fmt.Errorf("Can't learn a single conjunct")
+		return ErrSingleConjunct
 	}
 
 	// reconstruct word
@@ -102,91 +76,118 @@ func (varnam *Varnam) Learn(word string, weight int) error {
 		weight = VARNAM_LEARNT_WORD_MIN_WEIGHT - 1
 	}
 
-	query := "INSERT OR IGNORE INTO words(word, weight, learned_on) VALUES (trim(?), ?, strftime('%s', 'now'))"
-
-	bgContext := context.Background()
-
-	ctx, cancelFunc := context.WithTimeout(bgContext, 5*time.Second)
+	ctx, cancelFunc := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancelFunc()
 
-	stmt, err := varnam.dictConn.PrepareContext(ctx, query)
+	blocked, err := varnam.findBlockedWords(ctx, []string{word})
 	if err != nil {
-		return err
+		return dictError(err)
 	}
-	defer stmt.Close()
-
-	_, err = stmt.ExecContext(ctx, word, weight)
-	if err != nil {
-		return err
+	if blocked[word] {
+		return ErrWordBlocked
 	}
 
-	query = "UPDATE words SET weight = weight + 1, learned_on = strftime('%s', 'now') WHERE word = ?"
-	ctx, cancelFunc = context.WithTimeout(bgContext, 5*time.Second)
-	defer cancelFunc()
+	err = varnam.writeDict(ctx, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, "INSERT OR IGNORE INTO words(word, weight, learned_on) VALUES (trim(?), ?, strftime('%s', 'now'))", word, weight)
+		if err != nil {
+			return err
+		}
 
-	stmt, err = varnam.dictConn.PrepareContext(ctx, query)
-	if err != nil {
-		return err
-	}
-	defer stmt.Close()
+		_, err = tx.ExecContext(ctx, "UPDATE words SET weight = weight + 1, learned_on = strftime('%s', 'now') WHERE word = ?", word)
+		if err != nil {
+			return err
+		}
+
+		// Learning again undoes an unlearn of base dictionary word
+		_, err = tx.ExecContext(ctx, "DELETE FROM suppressions WHERE word = ?", word)
+		if err != nil {
+			return err
+		}
 
-	_, err = stmt.ExecContext(ctx, word)
+		varnam.syncDictionaryIndex(ctx, tx, []string{word})
+		return nil
+	})
 	if err != nil {
 		return err
 	}
 
+	varnam.invalidateCachedWords([]string{word}, varnam.getPatternsOfWords([]string{word}))
+
 	return nil
 }
 
 // Unlearn a word, remove from words DB and pattern if there is
 func (varnam *Varnam) Unlearn(word string) error {
-	conjuncts := varnam.splitWordByConjunct(strings.TrimSpace(word))
+	word = varnam.normalizeWord(word)
+	conjuncts := varnam.splitWordByConjunct(word)
+
+	ctx, cancelFunc := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelFunc()
 
 	if len(conjuncts) == 0 {
 		// Word must be english ? See if that's the case
-		stmt, err := varnam.dictConn.Prepare("DELETE FROM patterns WHERE pattern = ?")
+		err := varnam.writeDict(ctx, func(tx *sql.Tx) error {
+			result, err := tx.ExecContext(ctx, "DELETE FROM patterns WHERE pattern = ?", word)
+			if err != nil {
+				return err
+			}
+
+			affected, err := result.RowsAffected()
+			if err != nil {
+				return err
+			}
+
+			if affected == 0 {
+				return &Error{Code: VARNAM_WORD_NOT_FOUND, Message: "nothing to unlearn"}
+			}
+			return nil
+		})
 		if err != nil {
 			return err
 		}
-		defer stmt.Close()
 
-		result, err := stmt.Exec(word)
+		varnam.invalidateCachedWords(nil, []string{word})
+
+		return nil
+	}
+
+	// Base dictionaries are read-only, remember the removal
+	suppress := varnam.inBaseDictionaries(word)
+
+	// Gets deleted along with the word
+	patterns := varnam.getPatternsOfWords([]string{word})
+
+	err := varnam.writeDict(ctx, func(tx *sql.Tx) error {
+		// foreign_keys pragma is a no-op inside a transaction,
+		// so patterns are removed explicitly instead of ON DELETE CASCADE
+		_, err := tx.ExecContext(ctx, "DELETE FROM patterns WHERE word_id IN (SELECT id FROM words WHERE word = ?)", word)
 		if err != nil {
 			return err
 		}
 
-		affected, err := result.RowsAffected()
+		_, err = tx.ExecContext(ctx, "DELETE FROM words WHERE word = ?", word)
 		if err != nil {
 			return err
 		}
 
-		if affected == 0 {
-			return fmt.Errorf("nothing to unlearn")
+		if suppress {
+			_, err = tx.ExecContext(ctx, "INSERT OR IGNORE INTO suppressions (word) VALUES (?)", word)
+			if err != nil {
+				return err
+			}
 		}
-		return nil
-	}
-
-	varnam.dictConn.Exec("PRAGMA foreign_keys = ON")
-
-	query := "DELETE FROM words WHERE word = ?"
-	stmt, err := varnam.dictConn.Prepare(query)
-	if err != nil {
-		return err
-	}
-	defer stmt.Close()
 
-	_, err = stmt.Exec(word)
+		varnam.syncDictionaryIndex(ctx, tx, []string{word})
+		return nil
+	})
 	if err != nil {
 		return err
 	}
 
-	// No need to remove from `patterns` since FOREIGN KEY ON DELETE CASCADE will work
+	varnam.invalidateCachedWords([]string{word}, patterns)
 
-	if varnam.Debug {
-		fmt.Printf("Removed %s\n", word)
-	}
+	varnam.debug("Removed", "word", word)
 
-	varnam.dictConn.Exec("PRAGMA foreign_keys = OFF")
 	return nil
 }
 
@@ -199,6 +200,12 @@ func (varnam *Varnam) LearnMany(words []WordInfo) (LearnStatus, error) {
 		updationValues []string
 		updationArgs   []interface{}
 
+		learntWords []string
+
+		// Words that can be learnt if not blocked
+		learnable      []WordInfo
+		learnableWords []string
+
 		learnStatus LearnStatus = LearnStatus{len(words), 0}
 	)
 
@@ -208,13 +215,13 @@ func (varnam *Varnam) LearnMany(words []WordInfo) (LearnStatus, error) {
 		conjuncts := varnam.splitWordByConjunct(word)
 
 		if len(conjuncts) == 0 {
-			log.Printf("Nothing to learn from %s", word)
+			varnam.getLogger().Warn("Nothing to learn", "word", word)
 			learnStatus.FailedWords++
 			continue
 		}
 
 		if len(conjuncts) == 1 {
-			log.Printf("Can't learn a single conjunct: %s", word)
+			varnam.getLogger().Warn("Can't learn a single conjunct", "word", word)
 			learnStatus.FailedWords++
 			continue
 		}
@@ -229,11 +236,29 @@ func (varnam *Varnam) LearnMany(words []WordInfo) (LearnStatus, error) {
 			weight--
 		}
 
+		learnable = append(learnable, WordInfo{0, word, weight, 0})
+		learnableWords = append(learnableWords, word)
+	}
+
+	blocked, err := varnam.findBlockedWords(context.Background(), learnableWords)
+	if err != nil {
+		return learnStatus, dictError(err)
+	}
+
+	for _, wordInfo := range learnable {
+		if blocked[wordInfo.word] {
+			varnam.getLogger().Warn("Can't learn a blocked word", "word", wordInfo.word)
+			learnStatus.FailedWords++
+			continue
+		}
+
 		insertionValues = append(insertionValues, "(trim(?), ?, strftime('%s', 'now'))")
-		insertionArgs = append(insertionArgs, word, weight)
+		insertionArgs = append(insertionArgs, wordInfo.word, wordInfo.weight)
 
 		updationValues = append(updationValues, "word = ?")
-		updationArgs = append(updationArgs, word)
+		updationArgs = append(updationArgs, wordInfo.word)
+
+		learntWords = append(learntWords, wordInfo.word)
 	}
 
 	if len(insertionArgs) == 0 {
@@ -245,40 +270,50 @@ func (varnam *Varnam) LearnMany(words []WordInfo) (LearnStatus, error) {
 		strings.Join(insertionValues, ", "),
 	)
 
-	stmt, err := varnam.dictConn.Prepare(query)
+	// There is a limit on number of OR that can be done
+	// Reference: https://stackoverflow.com/questions/9570197/sqlite-expression-maximum-depth-limit
+	depthLimit, err := getDBLimit(varnam.dictConn, sqlite3.SQLITE_LIMIT_EXPR_DEPTH)
 	if err != nil {
 		return learnStatus, err
 	}
+	depthLimit--
 
-	_, err = stmt.Exec(insertionArgs...)
-	if err != nil {
-		return learnStatus, err
-	}
+	err = varnam.writeDict(context.Background(), func(tx *sql.Tx) error {
+		_, err := tx.Exec(query, insertionArgs...)
+		if err != nil {
+			return err
+		}
 
-	// There is a limit on number of OR that can be done
-	// Reference: https://stackoverflow.com/questions/9570197/sqlite-expression-maximum-depth-limit
-	depthLimit := sqlite3Conn.GetLimit(sqlite3.SQLITE_LIMIT_EXPR_DEPTH) - 1
+		values := updationValues
+		args := updationArgs
 
-	for len(updationValues) > 0 {
-		lastIndex := int(math.Min(float64(depthLimit), float64(len(updationValues))))
+		for len(values) > 0 {
+			lastIndex := int(math.Min(float64(depthLimit), float64(len(values))))
+			where := strings.Join(values[0:lastIndex], " OR ")
 
-		query = "UPDATE words SET weight = weight + 1, learned_on = strftime('%s', 'now') WHERE " + strings.Join(updationValues[0:lastIndex], " OR ")
+			_, err = tx.Exec("UPDATE words SET weight = weight + 1, learned_on = strftime('%s', 'now') WHERE "+where, args[0:lastIndex]...)
+			if err != nil {
+				return err
+			}
 
-		stmt, err = varnam.dictConn.Prepare(query)
-		if err != nil {
-			return learnStatus, err
-		}
-		defer stmt.Close()
+			_, err = tx.Exec("DELETE FROM suppressions WHERE "+where, args[0:lastIndex]...)
+			if err != nil {
+				return err
+			}
 
-		_, err = stmt.Exec(updationArgs[0:lastIndex]...)
-		if err != nil {
-			return learnStatus, err
+			values = values[lastIndex:]
+			args = args[lastIndex:]
 		}
 
-		updationValues = updationValues[lastIndex:]
-		updationArgs = updationArgs[lastIndex:]
+		varnam.syncDictionaryIndex(context.Background(), tx, learntWords)
+		return nil
+	})
+	if err != nil {
+		return learnStatus, err
 	}
 
+	varnam.invalidateCachedWords(learntWords, varnam.getPatternsOfWords(learntWords))
+
 	return learnStatus, nil
 }
 
@@ -300,18 +335,18 @@ func (varnam *Varnam) Train(pattern string, word string) error {
 	ctx, cancelFunc := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancelFunc()
 
-	query := "INSERT OR IGNORE INTO patterns(pattern, word_id) VALUES (?, ?)"
-	stmt, err := varnam.dictConn.PrepareContext(ctx, query)
-	if err != nil {
+	err = varnam.writeDict(ctx, func(tx *sql.Tx) error {
+		// A generated pattern becomes a trained one
+		_, err := tx.ExecContext(ctx, `INSERT INTO patterns(pattern, word_id) VALUES (?, ?)
+			ON CONFLICT(pattern, word_id) DO UPDATE SET generated = 0`, pattern, wordInfo.id)
 		return err
-	}
-	defer stmt.Close()
-
-	_, err = stmt.ExecContext(ctx, pattern, wordInfo.id)
+	})
 	if err != nil {
 		return err
 	}
 
+	varnam.invalidateCachedWords(nil, []string{pattern})
+
 	return nil
 }
 
@@ -335,7 +370,7 @@ func (varnam *Varnam) getWordInfo(word string) (*WordInfo, error) {
 	if wordExists {
 		return &wordInfo, nil
 	}
-	return nil, fmt.Errorf("Word doesn't exist")
+	return nil, ErrWordNotFound
 }
 
 // LearnFromFile Learn all words in a file
@@ -348,8 +383,11 @@ func (varnam *Varnam) LearnFromFile(filePath string) (LearnStatus, error) {
 	}
 	defer file.Close()
 
-	limitVariableNumber := sqlite3Conn.GetLimit(sqlite3.SQLITE_LIMIT_VARIABLE_NUMBER)
-	log.Printf("default SQLITE_LIMIT_VARIABLE_NUMBER: %d", limitVariableNumber)
+	limitVariableNumber, err := getDBLimit(varnam.dictConn, sqlite3.SQLITE_LIMIT_VARIABLE_NUMBER)
+	if err != nil {
+		return learnStatus, err
+	}
+	varnam.debug("Default SQLITE_LIMIT_VARIABLE_NUMBER", "limit", limitVariableNumber)
 
 	// We have 2 fields per item, word and weight
 	insertsPerTransaction := int(float64(limitVariableNumber) / 2)
@@ -406,9 +444,7 @@ func (varnam *Varnam) LearnFromFile(filePath string) (LearnStatus, error) {
 
 			fileFormatDetermined = true
 
-			if varnam.Debug {
-				fmt.Println("Frequency report :", frequencyReport)
-			}
+			varnam.debug("Learning from file", "frequencyReport", frequencyReport)
 		} else if frequencyReport {
 			number, numberErr := strconv.Atoi(curWord)
 			if word == "" {
@@ -444,7 +480,7 @@ func (varnam *Varnam) LearnFromFile(filePath string) (LearnStatus, error) {
 			count = 0
 			words = []WordInfo{}
 
-			fmt.Printf("Processed %d words\n", insertions)
+			varnam.getLogger().Info("Learning from file", "processed", insertions)
 		}
 	}
 
@@ -459,7 +495,7 @@ func (varnam *Varnam) LearnFromFile(filePath string) (LearnStatus, error) {
 		learnStatus.FailedWords += learnStatusBatch.FailedWords
 
 		insertions += len(words)
-		fmt.Printf("Processed %d words\n", insertions)
+		varnam.getLogger().Info("Learning from file", "processed", insertions)
 	}
 
 	if err := scanner.Err(); err != nil {
@@ -496,15 +532,15 @@ func (varnam *Varnam) TrainFromFile(filePath string) (LearnStatus, error) {
 			err := varnam.Train(wordsInLine[0], wordsInLine[1])
 			if err != nil {
 				learnStatus.FailedWords++
-				fmt.Printf("Couldn't train %s => %s (%s) \n", wordsInLine[0], wordsInLine[1], err.Error())
+				varnam.getLogger().Warn("Couldn't train", "pattern", wordsInLine[0], "word", wordsInLine[1], "error", err)
 			}
 		} else if lineCount > 2 {
-			fmt.Printf("Line %d is not in correct format \n", lineCount+1)
+			varnam.getLogger().Warn("Line is not in correct format", "line", lineCount+1)
 		}
 
 		lineCount++
 		if lineCount%500 == 0 {
-			fmt.Printf("Trained %d words\n", lineCount)
+			varnam.getLogger().Info("Training from file", "processed", lineCount)
 		}
 	}
 
@@ -563,7 +599,8 @@ func (varnam *Varnam) Export(filePath string, wordsPerFile int) error {
 	patternsCount := -1
 	wordsCount := -1
 
-	countRows, err := varnam.dictConn.Query("SELECT COUNT(*) AS patternsCount FROM patterns UNION SELECT COUNT(*) AS wordsCount FROM words")
+	// Generated patterns can be made again, they aren't exported
+	countRows, err := varnam.dictConn.Query("SELECT COUNT(*) AS patternsCount FROM patterns WHERE generated = 0 UNION SELECT COUNT(*) AS wordsCount FROM words")
 	if err != nil {
 		return err
 	}
@@ -579,10 +616,7 @@ func (varnam *Varnam) Export(filePath string, wordsPerFile int) error {
 
 	totalPages := int(math.Ceil(float64(wordsCount) / float64(wordsPerFile)))
 
-	if varnam.Debug {
-		log.Printf("Words: %d. Patterns: %d", wordsCount, patternsCount)
-		log.Printf("Pages: %d", totalPages)
-	}
+	varnam.debug("Exporting", "words", wordsCount, "patterns", patternsCount, "pages", totalPages)
 
 	page := 1
 	for page <= totalPages {
@@ -602,7 +636,7 @@ func (varnam *Varnam) Export(filePath string, wordsPerFile int) error {
 				SELECT word FROM words WHERE words.id = patterns.word_id
 			) AS w
 			FROM patterns
-			WHERE patterns.word_id IN (
+			WHERE patterns.generated = 0 AND patterns.word_id IN (
 				SELECT id FROM words WHERE word IN (
 					SELECT w FROM (
 						` + wordsTableQuery + `
@@ -634,6 +668,14 @@ func (varnam *Varnam) Export(filePath string, wordsPerFile int) error {
 	return nil
 }
 
+// Words in export files made by older versions may not be normalized
+func (varnam *Varnam) normalizeImportedWord(word interface{}) interface{} {
+	if wordString, ok := word.(string); ok {
+		return varnam.normalizeWord(wordString)
+	}
+	return word
+}
+
 // Import learnings from file
 func (varnam *Varnam) Import(filePath string) error {
 	if !fileExists(filePath) {
@@ -649,8 +691,19 @@ func (varnam *Varnam) Import(filePath string) error {
 		return fmt.Errorf("Parsing JSON failed, err: %s", err.Error())
 	}
 
-	limitVariableNumber := sqlite3Conn.GetLimit(sqlite3.SQLITE_LIMIT_VARIABLE_NUMBER)
-	log.Printf("default SQLITE_LIMIT_VARIABLE_NUMBER: %d", limitVariableNumber)
+	// Even a failed import may have added some words
+	defer varnam.cache.clear()
+
+	dbData, err := varnam.removeBlockedImports(context.Background(), dbData)
+	if err != nil {
+		return dictError(err)
+	}
+
+	limitVariableNumber, err := getDBLimit(varnam.dictConn, sqlite3.SQLITE_LIMIT_VARIABLE_NUMBER)
+	if err != nil {
+		return err
+	}
+	varnam.debug("Default SQLITE_LIMIT_VARIABLE_NUMBER", "limit", limitVariableNumber)
 
 	insertsPerTransaction := int(math.Min(
 		float64(limitVariableNumber)/4, // We have 4 fields per item
@@ -666,7 +719,7 @@ func (varnam *Varnam) Import(filePath string) error {
 	count := 0
 	for i, item := range dbData.WordsDict {
 		values = append(values, "(trim(?), ?, ?)")
-		args = append(args, item["w"], item["c"], item["l"])
+		args = append(args, varnam.normalizeImportedWord(item["w"]), item["c"], item["l"])
 
 		count++
 		if count == insertsPerTransaction || i == len(dbData.WordsDict)-1 {
@@ -691,7 +744,7 @@ func (varnam *Varnam) Import(filePath string) error {
 			insertions += count
 			count = 0
 
-			fmt.Printf("Inserted %d words\n", insertions)
+			varnam.getLogger().Info("Importing", "words", insertions)
 		}
 	}
 
@@ -707,7 +760,7 @@ func (varnam *Varnam) Import(filePath string) error {
 	count = 0
 	for i, item := range dbData.PatternsDict {
 		values = append(values, "(?, (SELECT id FROM words WHERE word = ?))")
-		args = append(args, item["p"], item["w"])
+		args = append(args, item["p"], varnam.normalizeImportedWord(item["w"]))
 
 		count++
 		if count == insertsPerTransaction || i == len(dbData.WordsDict)-1 {
@@ -732,7 +785,7 @@ func (varnam *Varnam) Import(filePath string) error {
 			insertions += count
 			count = 0
 
-			fmt.Printf("Inserted %d patterns\n", insertions)
+			varnam.getLogger().Info("Importing", "patterns", insertions)
 		}
 	}
 
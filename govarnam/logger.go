@@ -0,0 +1,157 @@
+package govarnam
+
+/**
+ * govarnam - An Indian language transliteration library
+ * Copyright Subin Siby <mail at subinsb (.) com>, 2021
+ * Licensed under AGPL-3.0-only. See LICENSE.txt
+ */
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Logger receives diagnostics from varnam. args are key value pairs.
+// *slog.Logger satisfies this, so can be used directly.
+// Varnam doesn't log anywhere unless a logger is set.
+type Logger interface {
+	Debug(msg string, args ...interface{})
+	Info(msg string, args ...interface{})
+	Warn(msg string, args ...interface{})
+	Error(msg string, args ...interface{})
+}
+
+// LogFunc use a function as Logger. It gets the level (VARNAM_LOG_*)
+// and the message with args appended as key=value
+type LogFunc func(level int, message string)
+
+// Debug log at VARNAM_LOG_DEBUG
+func (f LogFunc) Debug(msg string, args ...interface{}) {
+	f(VARNAM_LOG_DEBUG, formatLogMessage(msg, args))
+}
+
+// Info log at VARNAM_LOG_INFO
+func (f LogFunc) Info(msg string, args ...interface{}) {
+	f(VARNAM_LOG_INFO, formatLogMessage(msg, args))
+}
+
+// Warn log at VARNAM_LOG_WARN
+func (f LogFunc) Warn(msg string, args ...interface{}) {
+	f(VARNAM_LOG_WARN, formatLogMessage(msg, args))
+}
+
+// Error log at VARNAM_LOG_ERROR
+func (f LogFunc) Error(msg string, args ...interface{}) {
+	f(VARNAM_LOG_ERROR, formatLogMessage(msg, args))
+}
+
+func formatLogMessage(msg string, args []interface{}) string {
+	var builder strings.Builder
+	builder.WriteString(msg)
+
+	for i := 0; i < len(args); i += 2 {
+		if i+1 < len(args) {
+			fmt.Fprintf(&builder, " %v=%v", args[i], args[i+1])
+		} else {
+			fmt.Fprintf(&builder, " %v", args[i])
+		}
+	}
+
+	return builder.String()
+}
+
+// LogLevelName name of a VARNAM_LOG_* level
+func LogLevelName(level int) string {
+	switch {
+	case level >= VARNAM_LOG_ERROR:
+		return "ERROR"
+	case level >= VARNAM_LOG_WARN:
+		return "WARN"
+	case level >= VARNAM_LOG_INFO:
+		return "INFO"
+	}
+	return "DEBUG"
+}
+
+// NewTextLogger a logger writing a line per message to w.
+// Messages below minLevel (VARNAM_LOG_*) are skipped.
+func NewTextLogger(w io.Writer, minLevel int) Logger {
+	var mutex sync.Mutex
+
+	return LogFunc(func(level int, message string) {
+		if level < minLevel {
+			return
+		}
+
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		fmt.Fprintf(w, "%s %s\n", LogLevelName(level), message)
+	})
+}
+
+type nopLogger struct{}
+
+func (nopLogger) Debug(msg string, args ...interface{}) {}
+func (nopLogger) Info(msg string, args ...interface{})  {}
+func (nopLogger) Warn(msg string, args ...interface{})  {}
+func (nopLogger) Error(msg string, args ...interface{}) {}
+
+var (
+	defaultLogger      Logger = nopLogger{}
+	defaultLoggerMutex sync.RWMutex
+)
+
+// SetDefaultLogger logger used by varnam instances that don't have
+// one set with SetLogger() and by functions outside an instance
+func SetDefaultLogger(logger Logger) {
+	if logger == nil {
+		logger = nopLogger{}
+	}
+
+	defaultLoggerMutex.Lock()
+	defaultLogger = logger
+	defaultLoggerMutex.Unlock()
+}
+
+func getDefaultLogger() Logger {
+	defaultLoggerMutex.RLock()
+	defer defaultLoggerMutex.RUnlock()
+
+	return defaultLogger
+}
+
+// SetLogger logger for this instance. nil to use the default logger.
+func (varnam *Varnam) SetLogger(logger Logger) {
+	varnam.configMutex.Lock()
+	varnam.logger = logger
+	varnam.configMutex.Unlock()
+}
+
+func (varnam *Varnam) getLogger() Logger {
+	varnam.configMutex.RLock()
+	logger := varnam.logger
+	varnam.configMutex.RUnlock()
+
+	if logger == nil {
+		return getDefaultLogger()
+	}
+	return logger
+}
+
+// Debug messages are only made if varnam.Debug is on
+func (varnam *Varnam) debug(msg string, args ...interface{}) {
+	if varnam.Debug {
+		varnam.getLogger().Debug(msg, args...)
+	}
+}
+
+// Set GOVARNAM_LOG_TIME_TAKEN to log time taken by each step
+func (varnam *Varnam) logTimeTaken(step string, start time.Time) {
+	if LOG_TIME_TAKEN {
+		varnam.getLogger().Debug(step+" took", "duration", time.Since(start))
+	}
+}
@@ -0,0 +1,104 @@
+package govarnam
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"path"
+	"strings"
+	"sync"
+	"testing"
+)
+
+type logRecord struct {
+	level   int
+	message string
+}
+
+type recordingLogger struct {
+	mutex   sync.Mutex
+	records []logRecord
+}
+
+func (l *recordingLogger) logFunc() LogFunc {
+	return func(level int, message string) {
+		l.mutex.Lock()
+		l.records = append(l.records, logRecord{level, message})
+		l.mutex.Unlock()
+	}
+}
+
+func (l *recordingLogger) has(level int, prefix string) bool {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	for _, record := range l.records {
+		if record.level == level && strings.HasPrefix(record.message, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func TestLogFunc(t *testing.T) {
+	recorder := &recordingLogger{}
+	logger := recorder.logFunc()
+
+	logger.Info("Importing", "words", 10)
+	logger.Warn("Odd", "key")
+	logger.Error("Failed")
+
+	assertEqual(t, recorder.records[0], logRecord{VARNAM_LOG_INFO, "Importing words=10"})
+	assertEqual(t, recorder.records[1], logRecord{VARNAM_LOG_WARN, "Odd key"})
+	assertEqual(t, recorder.records[2], logRecord{VARNAM_LOG_ERROR, "Failed"})
+}
+
+func TestTextLogger(t *testing.T) {
+	var out bytes.Buffer
+
+	logger := NewTextLogger(&out, VARNAM_LOG_WARN)
+	logger.Debug("hidden")
+	logger.Info("hidden")
+	logger.Warn("Nothing to learn", "word", "a")
+	logger.Error("Failed", "error", "busy")
+
+	assertEqual(t, out.String(), "WARN Nothing to learn word=a\nERROR Failed error=busy\n")
+}
+
+func TestVarnamLogger(t *testing.T) {
+	vstPath := path.Join(testTempDir, "logger.vst")
+	vm, err := VMInit(vstPath)
+	checkError(err)
+	checkError(vm.VMCreateToken("a", "അ", "", "", "", VARNAM_SYMBOL_VOWEL, VARNAM_MATCH_EXACT, 0, 0, false))
+	vm.Close()
+
+	varnam, err := Init(vstPath, path.Join(testTempDir, "logger.learnings"))
+	checkError(err)
+	defer varnam.Close()
+
+	recorder := &recordingLogger{}
+	varnam.SetLogger(recorder.logFunc())
+
+	// Debug messages only when asked for
+	_, err = varnam.TransliterateWithOptions(context.Background(), "a", varnam.GetOptions())
+	checkError(err)
+	assertEqual(t, recorder.has(VARNAM_LOG_DEBUG, "Tokenized"), false)
+
+	varnam.Debug = true
+	_, err = varnam.TransliterateWithOptions(context.Background(), "a", varnam.GetOptions())
+	varnam.Debug = false
+	checkError(err)
+	assertEqual(t, recorder.has(VARNAM_LOG_DEBUG, "Tokenized"), true)
+
+	err = varnam.Learn("", 0)
+	assertEqual(t, errors.Is(err, ErrNothingToLearn), true)
+
+	varnam.LearnMany([]WordInfo{{word: ""}})
+	assertEqual(t, recorder.has(VARNAM_LOG_WARN, "Nothing to learn"), true)
+
+	// Back to default, which discards
+	varnam.SetLogger(nil)
+	recorder.records = nil
+	varnam.LearnMany([]WordInfo{{word: ""}})
+	assertEqual(t, len(recorder.records), 0)
+}
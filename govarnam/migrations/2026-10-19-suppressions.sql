@@ -0,0 +1,6 @@
+-- Words from base dictionaries the user has unlearnt.
+-- Base dictionaries are read-only, so the removal is recorded here.
+
+CREATE TABLE IF NOT EXISTS suppressions (
+  word TEXT PRIMARY KEY
+);
@@ -0,0 +1,10 @@
+-- Suggestions the user passed over for an input,
+-- choosing one ranked below them. See AcceptSuggestion()
+
+CREATE TABLE IF NOT EXISTS rejections (
+  pattern TEXT NOT NULL COLLATE NOCASE,
+  word TEXT NOT NULL,
+  count INTEGER DEFAULT 1,
+  rejected_on INTEGER,
+  PRIMARY KEY(pattern, word)
+);
@@ -0,0 +1,9 @@
+-- Times the user chose a symbol (pattern => value) in words
+-- they accepted. See learnSymbolPreferences()
+
+CREATE TABLE IF NOT EXISTS symbol_preferences (
+  pattern TEXT NOT NULL,
+  value TEXT NOT NULL,
+  count INTEGER DEFAULT 1,
+  PRIMARY KEY(pattern, value)
+);
@@ -0,0 +1,4 @@
+-- Patterns made by GeneratePatterns() & not trained by
+-- the user. Training a generated pattern unmarks it.
+
+ALTER TABLE patterns ADD COLUMN generated INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,6 @@
+-- Words that should never be suggested or learnt.
+-- See BlockWord()
+
+CREATE TABLE IF NOT EXISTS blocklist (
+  word TEXT PRIMARY KEY
+);
@@ -0,0 +1,7 @@
+-- Phrases typed with a short input.
+-- See AddShortcut()
+
+CREATE TABLE IF NOT EXISTS shortcuts (
+  input TEXT PRIMARY KEY,
+  phrase TEXT NOT NULL
+);
@@ -8,7 +8,6 @@ package govarnam
 
 import (
 	"io/fs"
-	"log"
 	"path/filepath"
 )
 
@@ -53,7 +52,7 @@ func GetAllSchemeDetails() ([]SchemeDetails, error) {
 			schemeDetails = append(schemeDetails, varnam.SchemeDetails)
 			varnam.Close()
 		} else {
-			log.Println(err)
+			varnam.getLogger().Warn("Couldn't load VST", "path", vstPath, "error", err)
 		}
 	}
 
@@ -0,0 +1,263 @@
+package govarnam
+
+/**
+ * govarnam - An Indian language transliteration library
+ * Copyright Subin Siby <mail at subinsb (.) com>, 2021
+ * Licensed under AGPL-3.0-only. See LICENSE.txt
+ */
+
+import (
+	"context"
+	sql "database/sql"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// Unicode normalization of native script text.
+// Words that go in (learn, import, search) and symbol values
+// read from VST pass through here. Suggestions are made of
+// symbol values, so that the same word is never stored or
+// shown in different encodings.
+
+func isJoiner(r rune) bool {
+	return r == '\u200C' || r == '\u200D'
+}
+
+// Convert alternate encodings of the same letter to one
+func (varnam *Varnam) normalizeLanguageVariants(word string) string {
+	if varnam.SchemeDetails.LangCode == "ml" {
+		/* Malayalam has got two ways to write chil letters. Converting the old style to new atomic chil one */
+		word = strings.Replace(word, "ന്‍", "ൻ", -1)
+		word = strings.Replace(word, "ണ്‍", "ൺ", -1)
+		word = strings.Replace(word, "ല്‍", "ൽ", -1)
+		word = strings.Replace(word, "ള്‍", "ൾ", -1)
+		word = strings.Replace(word, "ര്‍", "ർ", -1)
+		word = strings.Replace(word, "ക്‍", "ൿ", -1)
+	}
+
+	return word
+}
+
+// ZWJ & ZWNJ only make sense right after a letter.
+// Remove the ones at the beginning of text or after a space
+// and collapse repeated joiners into one.
+func normalizeJoiners(input string) string {
+	var (
+		result strings.Builder
+		prev   rune = ' '
+	)
+
+	for _, r := range input {
+		if isJoiner(r) && (unicode.IsSpace(prev) || isJoiner(prev)) {
+			continue
+		}
+		result.WriteRune(r)
+		prev = r
+	}
+
+	return result.String()
+}
+
+// Remove invisible characters and extra spaces
+func normalizeWhitespace(input string) string {
+	input = strings.Map(func(r rune) rune {
+		switch r {
+		case '\u200B', '\uFEFF':
+			// Zero width space, BOM
+			return -1
+		}
+		return r
+	}, input)
+
+	return strings.Join(strings.Fields(input), " ")
+}
+
+// Normalize text without touching its whitespace.
+// Used for text given to varnam.
+func (varnam *Varnam) normalizeText(text string) string {
+	text = norm.NFC.String(text)
+	text = varnam.normalizeLanguageVariants(text)
+	return normalizeJoiners(text)
+}
+
+// Normalize a value of symbol read from VST. A value can
+// be just a joiner, they're kept.
+func (varnam *Varnam) normalizeSymbolValue(value string) string {
+	return varnam.normalizeLanguageVariants(norm.NFC.String(value))
+}
+
+// Normalize a full word. Used before storing & searching words.
+func (varnam *Varnam) normalizeWord(word string) string {
+	word = normalizeWhitespace(word)
+	word = varnam.normalizeText(word)
+
+	// Remove trailing ZWNJ
+	lastChar, size := getLastCharacter(word)
+	if lastChar == ZWNJ {
+		word = word[0 : len(word)-size]
+	}
+
+	return word
+}
+
+// Makes sure learnings DB words are normalized.
+// Runs once for every VARNAM_NORMALIZATION_VERSION
+func (varnam *Varnam) migrateNormalization() error {
+	var version string
+	err := varnam.dictConn.QueryRow("SELECT value FROM metadata WHERE key = ?", VARNAM_METADATA_NORMALIZATION_VERSION).Scan(&version)
+	if err != nil && err != sql.ErrNoRows {
+		return err
+	}
+
+	if version == strconv.Itoa(VARNAM_NORMALIZATION_VERSION) {
+		return nil
+	}
+
+	merged, err := varnam.NormalizeDictionary()
+	if err != nil {
+		return err
+	}
+
+	if merged != 0 {
+		varnam.getLogger().Info("Normalized learnings", "merged", merged)
+	}
+
+	_, err = varnam.dictConn.Exec(
+		"INSERT OR REPLACE INTO metadata (key, value) VALUES (?, ?)",
+		VARNAM_METADATA_NORMALIZATION_VERSION,
+		strconv.Itoa(VARNAM_NORMALIZATION_VERSION),
+	)
+	return err
+}
+
+// NormalizeDictionary normalize all words in learnings DB.
+// Words that become the same after normalization are merged into one,
+// keeping the highest weight, latest learned time and all their patterns.
+// Returns the number of words merged.
+func (varnam *Varnam) NormalizeDictionary() (int, error) {
+	ctx, cancelFunc := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancelFunc()
+
+	rows, err := varnam.dictConn.QueryContext(ctx, "SELECT id, word, IFNULL(weight, 0), IFNULL(learned_on, 0) FROM words ORDER BY id ASC")
+	if err != nil {
+		return 0, err
+	}
+
+	// normalized word => words with that normalized form
+	groups := map[string][]WordInfo{}
+	var order []string
+
+	for rows.Next() {
+		var item WordInfo
+		err = rows.Scan(&item.id, &item.word, &item.weight, &item.learnedOn)
+		if err != nil {
+			// Nothing is written yet
+			rows.Close()
+			return 0, err
+		}
+
+		normalized := varnam.normalizeWord(item.word)
+		if _, ok := groups[normalized]; !ok {
+			order = append(order, normalized)
+		}
+		groups[normalized] = append(groups[normalized], item)
+	}
+	rows.Close()
+
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	tx, err := varnam.dictConn.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	merged := 0
+
+	for _, normalized := range order {
+		items := groups[normalized]
+
+		if len(items) == 1 && items[0].word == normalized {
+			continue
+		}
+
+		if normalized == "" {
+			// Nothing left of the word
+			for _, item := range items {
+				_, err = tx.ExecContext(ctx, "DELETE FROM patterns WHERE word_id = ?", item.id)
+				if err == nil {
+					_, err = tx.ExecContext(ctx, "DELETE FROM words WHERE id = ?", item.id)
+				}
+				if err != nil {
+					tx.Rollback()
+					return 0, err
+				}
+			}
+			merged += len(items)
+			continue
+		}
+
+		// Prefer the row already in normalized form, else the heaviest
+		keeper := 0
+		for i, item := range items {
+			if item.word == normalized {
+				keeper = i
+				break
+			}
+			if item.weight > items[keeper].weight {
+				keeper = i
+			}
+		}
+
+		weight := items[keeper].weight
+		learnedOn := items[keeper].learnedOn
+
+		for i, item := range items {
+			if i == keeper {
+				continue
+			}
+
+			if item.weight > weight {
+				weight = item.weight
+			}
+			if item.learnedOn > learnedOn {
+				learnedOn = item.learnedOn
+			}
+
+			// Move patterns to the word being kept
+			_, err = tx.ExecContext(ctx, "INSERT OR IGNORE INTO patterns (pattern, word_id, generated) SELECT pattern, ?, generated FROM patterns WHERE word_id = ?", items[keeper].id, item.id)
+			if err == nil {
+				_, err = tx.ExecContext(ctx, "DELETE FROM patterns WHERE word_id = ?", item.id)
+			}
+			if err == nil {
+				_, err = tx.ExecContext(ctx, "DELETE FROM words WHERE id = ?", item.id)
+			}
+			if err != nil {
+				tx.Rollback()
+				return 0, err
+			}
+
+			merged++
+		}
+
+		_, err = tx.ExecContext(ctx, "UPDATE words SET word = ?, weight = ?, learned_on = ? WHERE id = ?", normalized, weight, learnedOn, items[keeper].id)
+		if err != nil {
+			tx.Rollback()
+			return 0, err
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return 0, err
+	}
+
+	varnam.cache.clear()
+
+	return merged, nil
+}
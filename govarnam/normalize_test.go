@@ -0,0 +1,121 @@
+package govarnam
+
+import (
+	"context"
+	"path"
+	"testing"
+)
+
+func TestNormalizeWord(t *testing.T) {
+	varnam := Varnam{}
+	varnam.SchemeDetails.LangCode = "ml"
+
+	// Old style chil
+	assertEqual(t, varnam.normalizeWord("അവന്"+ZWJ), "അവൻ")
+	assertEqual(t, varnam.normalizeWord("വാക്"+ZWJ), "വാൿ")
+
+	// Two part vowel signs
+	assertEqual(t, varnam.normalizeWord("\u0D15\u0D46\u0D3E\u0D1F\u0D3F"), "\u0D15\u0D4A\u0D1F\u0D3F")
+	assertEqual(t, varnam.normalizeWord("\u0D15\u0D47\u0D3E\u0D1F"), "\u0D15\u0D4B\u0D1F")
+	assertEqual(t, varnam.normalizeWord("\u0D15\u0D46\u0D57\u0D36\u0D32\u0D02"), "\u0D15\u0D4C\u0D36\u0D32\u0D02")
+
+	// ZWJ, ZWNJ
+	assertEqual(t, varnam.normalizeWord(ZWNJ+"മല"), "മല")
+	assertEqual(t, varnam.normalizeWord("മല"+ZWNJ), "മല")
+	assertEqual(t, varnam.normalizeWord("താഴ്"+ZWNJ+ZWNJ+"വര"), "താഴ്"+ZWNJ+"വര")
+	assertEqual(t, varnam.normalizeWord("താഴ്"+ZWNJ+" "+ZWJ+"വര"), "താഴ്"+ZWNJ+" വര")
+
+	// Whitespace
+	assertEqual(t, varnam.normalizeWord("  മല​ "), "മല")
+	assertEqual(t, varnam.normalizeWord("നല്ല  \t ദിവസം"), "നല്ല ദിവസം")
+
+	// Latin is untouched
+	assertEqual(t, varnam.normalizeWord("malayalam"), "malayalam")
+}
+
+func TestNormalizeNukta(t *testing.T) {
+	varnam := Varnam{}
+	varnam.SchemeDetails.LangCode = "hi"
+
+	// Precomposed nukta letter is decomposed
+	assertEqual(t, varnam.normalizeWord("\u095B\u0930\u093E"), "\u091C\u093C\u0930\u093E")
+
+	// Nukta comes before virama
+	assertEqual(t, varnam.normalizeWord("\u0915\u094D\u093C"), "\u0915\u093C\u094D")
+
+	// These nukta letters are composed
+	assertEqual(t, varnam.normalizeWord("\u0928\u093C"), "\u0929")
+
+	// Vowel signs of other scripts too
+	assertEqual(t, varnam.normalizeWord("\u0D9A\u0DD9\u0DCF"), "\u0D9A\u0DDC")
+}
+
+func TestNormalizeSymbolValues(t *testing.T) {
+	varnam := makeTestVarnam("normalize-symbols", []Symbol{
+		{Pattern: "ko", Value1: "\u0D15\u0D46\u0D3E"},
+		{Pattern: "_", Value1: ZWNJ},
+		{Pattern: "la", Value1: "ല"},
+	}, 0)
+	defer varnam.Close()
+
+	// Words are made of values normalized when read
+	result, err := varnam.TransliterateWithOptions(context.Background(), "kola", varnam.GetOptions())
+	checkError(err)
+	assertEqual(t, result.GreedyTokenized[0].Word, "\u0D15\u0D4A\u0D32")
+
+	// Joiners in values are kept
+	result, err = varnam.TransliterateWithOptions(context.Background(), "ko_la", varnam.GetOptions())
+	checkError(err)
+	assertEqual(t, result.GreedyTokenized[0].Word, "\u0D15\u0D4A"+ZWNJ+"\u0D32")
+}
+
+func TestNormalizeDictionary(t *testing.T) {
+	varnam := Varnam{}
+	varnam.SchemeDetails.LangCode = "ml"
+
+	err := varnam.InitDict(path.Join(testTempDir, "normalize.learnings"))
+	checkError(err)
+	defer varnam.Close()
+
+	_, err = varnam.dictConn.Exec(`
+		INSERT INTO words (id, word, weight, learned_on) VALUES
+			(1, 'അവൻ', 40, 100),
+			(2, 'അവന്` + ZWJ + `', 50, 200),
+			(3, '` + "\u0D15\u0D46\u0D3E\u0D1F\u0D3F" + `', 30, 300),
+			(4, 'മല', 30, 300);
+		INSERT INTO patterns (pattern, word_id) VALUES
+			('avan', 2),
+			('kodi', 3);
+	`)
+	checkError(err)
+
+	merged, err := varnam.NormalizeDictionary()
+	checkError(err)
+	assertEqual(t, merged, 1)
+
+	var (
+		count  int
+		weight int
+		wordID int
+	)
+
+	varnam.dictConn.QueryRow("SELECT COUNT(*) FROM words").Scan(&count)
+	assertEqual(t, count, 3)
+
+	varnam.dictConn.QueryRow("SELECT weight FROM words WHERE word = ?", "അവൻ").Scan(&weight)
+	assertEqual(t, weight, 50)
+
+	// Pattern of the merged word is moved
+	varnam.dictConn.QueryRow("SELECT word_id FROM patterns WHERE pattern = ?", "avan").Scan(&wordID)
+	assertEqual(t, wordID, 1)
+
+	varnam.dictConn.QueryRow("SELECT word_id FROM patterns WHERE pattern = ?", "kodi").Scan(&wordID)
+	assertEqual(t, wordID, 3)
+
+	varnam.dictConn.QueryRow("SELECT COUNT(*) FROM words WHERE word = ?", "\u0D15\u0D4A\u0D1F\u0D3F").Scan(&count)
+	assertEqual(t, count, 1)
+
+	// FTS index is kept in sync
+	results := varnam.searchDictionary(context.Background(), []string{"\u0D15\u0D4A"}, searchMatches)
+	assertEqual(t, len(results), 1)
+}
@@ -0,0 +1,95 @@
+package govarnam
+
+/**
+ * govarnam - An Indian language transliteration library
+ * Copyright Subin Siby <mail at subinsb (.) com>, 2021
+ * Licensed under AGPL-3.0-only. See LICENSE.txt
+ */
+
+import (
+	"context"
+)
+
+// Options config used while transliterating.
+// Get the current config with GetOptions() and
+// override it for one call with TransliterateWithOptions()
+type Options struct {
+	// See Varnam struct for what these do
+	DictionarySuggestionsLimit        int
+	PatternDictionarySuggestionsLimit int
+	TokenizerSuggestionsLimit         int
+	TokenizerSuggestionsAlways        bool
+	DictionaryMatchExact              bool
+	IndicDigits                       bool
+}
+
+type optionsContextKey struct{}
+
+// GetOptions get a snapshot of the current config
+func (varnam *Varnam) GetOptions() Options {
+	varnam.configMutex.RLock()
+	defer varnam.configMutex.RUnlock()
+
+	return varnam.getOptionsUnlocked()
+}
+
+func (varnam *Varnam) getOptionsUnlocked() Options {
+	return Options{
+		DictionarySuggestionsLimit:        varnam.DictionarySuggestionsLimit,
+		PatternDictionarySuggestionsLimit: varnam.PatternDictionarySuggestionsLimit,
+		TokenizerSuggestionsLimit:         varnam.TokenizerSuggestionsLimit,
+		TokenizerSuggestionsAlways:        varnam.TokenizerSuggestionsAlways,
+		DictionaryMatchExact:              varnam.DictionaryMatchExact,
+		IndicDigits:                       varnam.LangRules.IndicDigits,
+	}
+}
+
+// UpdateOptions change config while other goroutines may be
+// using varnam. Setting the struct fields directly is only
+// safe before varnam is shared.
+func (varnam *Varnam) UpdateOptions(update func(*Options)) {
+	varnam.configMutex.Lock()
+	defer varnam.configMutex.Unlock()
+
+	opts := varnam.getOptionsUnlocked()
+	update(&opts)
+
+	varnam.DictionarySuggestionsLimit = opts.DictionarySuggestionsLimit
+	varnam.PatternDictionarySuggestionsLimit = opts.PatternDictionarySuggestionsLimit
+	varnam.TokenizerSuggestionsLimit = opts.TokenizerSuggestionsLimit
+	varnam.TokenizerSuggestionsAlways = opts.TokenizerSuggestionsAlways
+	varnam.DictionaryMatchExact = opts.DictionaryMatchExact
+	varnam.LangRules.IndicDigits = opts.IndicDigits
+}
+
+// Attach a snapshot of current config to context so that
+// one transliteration uses the same config throughout,
+// even if it gets changed midway.
+func (varnam *Varnam) withOptions(ctx context.Context) context.Context {
+	if _, ok := ctx.Value(optionsContextKey{}).(Options); ok {
+		return ctx
+	}
+	return context.WithValue(ctx, optionsContextKey{}, varnam.GetOptions())
+}
+
+// Config to use for the current call
+func (varnam *Varnam) options(ctx context.Context) Options {
+	if opts, ok := ctx.Value(optionsContextKey{}).(Options); ok {
+		return opts
+	}
+	return varnam.GetOptions()
+}
+
+func (varnam *Varnam) getPatternWordPartializers() []func(*Suggestion) {
+	varnam.configMutex.RLock()
+	defer varnam.configMutex.RUnlock()
+
+	return varnam.PatternWordPartializers
+}
+
+func (varnam *Varnam) getBaseDictionaries() []*DictionaryLayer {
+	varnam.configMutex.RLock()
+	defer varnam.configMutex.RUnlock()
+
+	return varnam.BaseDictionaries
+}
@@ -0,0 +1,209 @@
+package govarnam
+
+/**
+ * govarnam - An Indian language transliteration library
+ * Copyright Subin Siby <mail at subinsb (.) com>, 2021
+ * Licensed under AGPL-3.0-only. See LICENSE.txt
+ */
+
+import (
+	"context"
+	sql "database/sql"
+	"strings"
+	"time"
+)
+
+// Words whose patterns are inserted in a transaction
+const generatePatternsBatchSize = 500
+
+// PatternGenerationStatus result of GeneratePatterns()
+type PatternGenerationStatus struct {
+	Words int
+
+	// Patterns added, ones already there aren't counted
+	Patterns int
+}
+
+type generatedPattern struct {
+	pattern string
+	wordID  int
+}
+
+// Loose spellings of pattern, how people type it casually.
+// Lowercase & repeated letters made single first:
+// malayaaLam => malayalam. Then also with sounds written
+// in more than one way made one, like phonetic keys:
+// vaarththa => varta, kampyooTTar => kampyutar
+func loosePatterns(pattern string) []string {
+	var loose []rune
+
+	for _, r := range strings.ToLower(pattern) {
+		if len(loose) > 0 && loose[len(loose)-1] == r {
+			continue
+		}
+		loose = append(loose, r)
+	}
+
+	return []string{string(loose), normalizePhoneticKey(pattern)}
+}
+
+// Upto perWord patterns to add for word. Each pattern
+// reverse transliteration gives is followed by its loose
+// spellings. Patterns differing by case are the same.
+func (varnam *Varnam) makePatterns(ctx context.Context, word string, perWord int) []string {
+	var patterns []string
+	seen := map[string]bool{}
+
+	add := func(pattern string) {
+		key := strings.ToLower(pattern)
+		// Phonetic spelling of only symbols is empty
+		if pattern != "" && len(patterns) < perWord && !seen[key] {
+			seen[key] = true
+			patterns = append(patterns, pattern)
+		}
+	}
+
+	for _, sug := range varnam.reverseTransliterate(ctx, word, perWord) {
+		add(sug.Word)
+		for _, pattern := range loosePatterns(sug.Word) {
+			add(pattern)
+		}
+	}
+
+	return patterns
+}
+
+// GeneratePatterns add upto perWord likely patterns of each
+// learnt word to patterns dictionary so that they can be
+// found by typing those. They're marked as generated,
+// see DeleteGeneratedPatterns().
+func (varnam *Varnam) GeneratePatterns(ctx context.Context, perWord int) (PatternGenerationStatus, error) {
+	var status PatternGenerationStatus
+
+	if perWord <= 0 {
+		return status, &Error{Code: VARNAM_MISUSE, Message: "Patterns per word should be more than 0"}
+	}
+
+	type wordItem struct {
+		id   int
+		word string
+	}
+
+	var words []wordItem
+
+	rows, err := varnam.dictConn.QueryContext(ctx, "SELECT id, word FROM words ORDER BY id")
+	if err != nil {
+		return status, dictError(err)
+	}
+
+	for rows.Next() {
+		var item wordItem
+		rows.Scan(&item.id, &item.word)
+		words = append(words, item)
+	}
+	rows.Close()
+
+	if err := rows.Err(); err != nil {
+		return status, dictError(err)
+	}
+
+	// Whatever was added before failing is in dictionary
+	defer varnam.cache.clear()
+
+	lookupCtx := withLookupErrors(varnam.withOptions(ctx))
+
+	for start := 0; start < len(words); start += generatePatternsBatchSize {
+		end := start + generatePatternsBatchSize
+		if end > len(words) {
+			end = len(words)
+		}
+
+		var generated []generatedPattern
+
+		for _, item := range words[start:end] {
+			for _, pattern := range varnam.makePatterns(lookupCtx, item.word, perWord) {
+				generated = append(generated, generatedPattern{pattern, item.id})
+			}
+		}
+
+		if err := getLookupError(lookupCtx); err != nil {
+			return status, err
+		}
+		if ctx.Err() != nil {
+			return status, ctx.Err()
+		}
+
+		added, err := varnam.insertGeneratedPatterns(ctx, generated)
+		if err != nil {
+			return status, err
+		}
+
+		status.Words += end - start
+		status.Patterns += added
+
+		varnam.getLogger().Info("Generating patterns", "words", status.Words, "patterns", status.Patterns)
+	}
+
+	return status, nil
+}
+
+// Insert patterns not there already, returns how many were
+func (varnam *Varnam) insertGeneratedPatterns(ctx context.Context, generated []generatedPattern) (int, error) {
+	added := 0
+
+	writeCtx, cancelFunc := context.WithTimeout(ctx, 5*time.Second)
+	defer cancelFunc()
+
+	err := varnam.writeDict(writeCtx, func(tx *sql.Tx) error {
+		// Retried writes start over
+		added = 0
+
+		stmt, err := tx.PrepareContext(writeCtx, "INSERT OR IGNORE INTO patterns(pattern, word_id, generated) VALUES (?, ?, 1)")
+		if err != nil {
+			return err
+		}
+		defer stmt.Close()
+
+		for _, item := range generated {
+			result, err := stmt.ExecContext(writeCtx, item.pattern, item.wordID)
+			if err != nil {
+				return err
+			}
+
+			affected, _ := result.RowsAffected()
+			added += int(affected)
+		}
+
+		return nil
+	})
+
+	return added, err
+}
+
+// DeleteGeneratedPatterns remove patterns added by
+// GeneratePatterns() that weren't trained since
+func (varnam *Varnam) DeleteGeneratedPatterns() (int, error) {
+	deleted := 0
+
+	ctx, cancelFunc := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelFunc()
+
+	err := varnam.writeDict(ctx, func(tx *sql.Tx) error {
+		result, err := tx.ExecContext(ctx, "DELETE FROM patterns WHERE generated = 1")
+		if err != nil {
+			return err
+		}
+
+		affected, _ := result.RowsAffected()
+		deleted = int(affected)
+
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	varnam.cache.clear()
+
+	return deleted, nil
+}
@@ -0,0 +1,164 @@
+package govarnam
+
+import (
+	"context"
+	"path"
+	"strings"
+	"testing"
+)
+
+func TestGeneratePatterns(t *testing.T) {
+	vstPath := path.Join(testTempDir, "patterns.vst")
+
+	vm, err := VMInit(vstPath)
+	checkError(err)
+	checkError(vm.VMCreateToken("pa", "പ", "", "", "", VARNAM_SYMBOL_CONSONANT, VARNAM_MATCH_EXACT, 0, 0, false))
+	checkError(vm.VMCreateToken("LLa", "ള", "", "", "", VARNAM_SYMBOL_CONSONANT, VARNAM_MATCH_EXACT, 0, 0, false))
+	vm.Close()
+
+	varnam, err := Init(vstPath, path.Join(testTempDir, "patterns.learnings"))
+	checkError(err)
+	defer varnam.Close()
+
+	ctx := context.Background()
+
+	_, err = varnam.GeneratePatterns(ctx, 0)
+	assertEqual(t, err != nil, true)
+
+	checkError(varnam.Learn("പള", 0))
+
+	status, err := varnam.GeneratePatterns(ctx, 5)
+	checkError(err)
+	assertEqual(t, status.Words, 1)
+
+	// paLLa & its loose spelling
+	assertEqual(t, status.Patterns, 2)
+
+	// Pattern typed fully gives exact words
+	result, err := varnam.TransliterateWithOptions(ctx, "pala", varnam.GetOptions())
+	checkError(err)
+	assertEqual(t, hasSuggestion(result.ExactWords, "പള"), true)
+
+	// Already there
+	status, err = varnam.GeneratePatterns(ctx, 5)
+	checkError(err)
+	assertEqual(t, status.Patterns, 0)
+
+	// Not trained yet
+	cases, err := varnam.GetTrainedPatterns(ctx)
+	checkError(err)
+	assertEqual(t, len(cases), 0)
+
+	checkError(varnam.Train("pala", "പള"))
+
+	deleted, err := varnam.DeleteGeneratedPatterns()
+	checkError(err)
+	assertEqual(t, deleted, 1)
+
+	// Trained one stays
+	result, err = varnam.TransliterateWithOptions(ctx, "pala", varnam.GetOptions())
+	checkError(err)
+	assertEqual(t, hasSuggestion(result.ExactWords, "പള"), true)
+
+	cases, err = varnam.GetTrainedPatterns(ctx)
+	checkError(err)
+	assertEqual(t, len(cases), 1)
+}
+
+func TestLoosePatterns(t *testing.T) {
+	for pattern, loose := range map[string]string{
+		"malayaaLam":  "malayalam malayalam",
+		"vaarththa":   "varththa varta",
+		"kampyooTTar": "kampyotar kampyutar",
+		"wadhoo":      "wadho vadu",
+	} {
+		assertEqual(t, strings.Join(loosePatterns(pattern), " "), loose)
+	}
+}
+
+func TestGenerateLoosePatterns(t *testing.T) {
+	varnam := makeTestVarnam("loose-patterns", []Symbol{
+		{Pattern: "oo", Value1: "ഊ", Value2: "ൂ", Type: VARNAM_SYMBOL_VOWEL},
+		{Pattern: "wa", Value1: "വ"},
+		{Pattern: "tha", Value1: "ത"},
+	}, 0)
+	defer varnam.Close()
+
+	ctx := context.Background()
+
+	checkError(varnam.Learn("ഊവത", 0))
+
+	status, err := varnam.GeneratePatterns(ctx, 5)
+	checkError(err)
+
+	// oowatha, owatha & uvata
+	assertEqual(t, status.Patterns, 3)
+
+	// Typed with u for oo, v for w & without aspiration.
+	// Then a part of it & its other loose spelling.
+	for _, input := range []string{"uvata", "uvat", "owatha"} {
+		result, err := varnam.TransliterateWithOptions(ctx, input, varnam.GetOptions())
+		checkError(err)
+		assertEqual(t, hasSuggestion(append(result.ExactWords, result.PatternDictionarySuggestions...), "ഊവത"), true)
+	}
+}
+
+func TestPatternDictionaryLookup(t *testing.T) {
+	varnam := Varnam{}
+	varnam.PatternDictionarySuggestionsLimit = 10
+
+	err := varnam.InitDict(path.Join(testTempDir, "pattern-lookup.learnings"))
+	checkError(err)
+	defer varnam.Close()
+
+	_, err = varnam.dictConn.Exec(`
+		INSERT INTO words (id, word, weight, learned_on) VALUES
+			(1, 'മല', 5, 100),
+			(2, 'മലയാളം', 50, 100),
+			(3, 'മലയാളി', 50, 200),
+			(4, 'മലപ്പുറം', 80, 100),
+			(5, 'മാല', 90, 100);
+		INSERT INTO patterns (pattern, word_id) VALUES
+			('mala', 1),
+			('malayalam', 2),
+			('malayali', 3),
+			('malappuram', 4),
+			('maala', 5),
+			('m', 5);
+	`)
+	checkError(err)
+
+	// Both parts of the query should search in index
+	query, args := makePatternDictionaryQuery("malay", 10)
+	rows, err := varnam.dictConn.Query("EXPLAIN QUERY PLAN "+query, args...)
+	checkError(err)
+
+	for rows.Next() {
+		var (
+			id, parent, notUsed int
+			detail              string
+		)
+		checkError(rows.Scan(&id, &parent, &notUsed, &detail))
+		assertEqual(t, strings.HasPrefix(detail, "SCAN patterns"), false)
+		assertEqual(t, strings.HasPrefix(detail, "SCAN TABLE patterns"), false)
+	}
+	checkError(rows.Err())
+	rows.Close()
+
+	sugs := varnam.getFromPatternDictionary(context.Background(), "malay")
+	var words []string
+	for _, sug := range sugs {
+		words = append(words, sug.Sug.Word)
+	}
+
+	// Longest pattern input starts with first, then
+	// completions by weight & recency
+	assertEqual(t, strings.Join(words, " "), "മല മാല മലയാളി മലയാളം")
+
+	// Completions don't push out patterns input starts with
+	varnam.PatternDictionarySuggestionsLimit = 2
+	sugs = varnam.getFromPatternDictionary(context.Background(), "malappuramil")
+	assertEqual(t, len(sugs), 2)
+	assertEqual(t, sugs[0].Sug.Word, "മലപ്പുറം")
+	assertEqual(t, sugs[1].Sug.Word, "മല")
+}
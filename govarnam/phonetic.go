@@ -0,0 +1,295 @@
+package govarnam
+
+/**
+ * govarnam - An Indian language transliteration library
+ * Copyright Subin Siby <mail at subinsb (.) com>, 2021
+ * Licensed under AGPL-3.0-only. See LICENSE.txt
+ */
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// Phonetic keys are patterns of a word in VST made
+// loose, so that a native word & the different ways
+// it can be typed in Latin end up with the same key.
+// Eg: വാർത്ത & "vaartha", "vartha" are all "varta".
+
+// Sounds written in more than one way
+var phoneticKeyReplacer = strings.NewReplacer("ee", "i", "oo", "u", "w", "v")
+
+// Consonants after which h makes them aspirated
+const phoneticKeyAspirated = "bdgjkpt"
+
+// Make patterns into key. Case, symbols like ~,
+// aspiration & doubled letters are left out.
+func normalizePhoneticKey(pattern string) string {
+	pattern = phoneticKeyReplacer.Replace(strings.ToLower(pattern))
+
+	var key []byte
+
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		if c < 'a' || c > 'z' {
+			continue
+		}
+
+		var last byte
+		if len(key) > 0 {
+			last = key[len(key)-1]
+		}
+
+		if c == 'h' && strings.IndexByte(phoneticKeyAspirated, last) != -1 {
+			continue
+		}
+		// Long vowels & doubled consonants
+		if c == last {
+			continue
+		}
+
+		key = append(key, c)
+	}
+
+	return string(key)
+}
+
+// Most likely pattern of value in VST.
+// Empty if there's no symbol with it.
+func (varnam *Varnam) findValuePattern(ctx context.Context, value string) string {
+	var pattern string
+
+	// Accept condition is left out, a symbol
+	// is pronounced the same wherever it is
+	rows, err := varnam.vstConn.QueryContext(ctx, "SELECT pattern FROM symbols WHERE value1 = ? OR value2 = ? ORDER BY match_type ASC, weight DESC, priority DESC LIMIT 1", value, value)
+	if err != nil {
+		varnam.lookupFailed(ctx, vstError(err))
+		return pattern
+	}
+	defer rows.Close()
+
+	if rows.Next() {
+		rows.Scan(&pattern)
+	}
+
+	if err := rows.Err(); err != nil {
+		varnam.lookupFailed(ctx, vstError(err))
+	}
+
+	return pattern
+}
+
+// PhoneticKey key of a native word to match with keys of
+// Latin inputs, see InputPhoneticKey(). Characters not of
+// the language are left out, give a word at a time.
+func (varnam *Varnam) PhoneticKey(ctx context.Context, word string) (string, error) {
+	ctx = varnam.withOptions(ctx)
+	ctx = withLookupErrors(ctx)
+
+	var patterns strings.Builder
+
+	for _, token := range varnam.splitTextByConjunct(ctx, varnam.normalizeText(word)) {
+		if token.tokenType == VARNAM_TOKEN_SYMBOL {
+			patterns.WriteString(token.symbols[0].Pattern)
+			continue
+		}
+
+		// Symbols that can only be at some place in
+		// a word, like chillu in between, are chars
+		runes := []rune(token.character)
+		if len(runes) > 0 && unicode.In(runes[0], &varnam.LangRules.UnicodeBlock) {
+			patterns.WriteString(varnam.findValuePattern(ctx, token.character))
+		}
+	}
+
+	if err := getLookupError(ctx); err != nil {
+		return "", err
+	}
+	if ctx.Err() != nil {
+		return "", ctx.Err()
+	}
+	return normalizePhoneticKey(patterns.String()), nil
+}
+
+// InputPhoneticKey key of a Latin input to match with keys
+// of native words, see PhoneticKey(). Each part of input is
+// taken as the most likely symbol for it, & the symbol as
+// its most likely pattern. So "A" & "aa" for ആ are the same.
+func (varnam *Varnam) InputPhoneticKey(ctx context.Context, input string) (string, error) {
+	ctx = varnam.withOptions(ctx)
+	ctx = withLookupErrors(ctx)
+
+	var patterns strings.Builder
+
+	for i, token := range *varnam.tokenizeWord(ctx, input, VARNAM_MATCH_ALL, false) {
+		if token.tokenType != VARNAM_TOKEN_SYMBOL || len(token.symbols) == 0 {
+			continue
+		}
+
+		symbol := token.symbols[0]
+
+		pattern := varnam.findValuePattern(ctx, getSymbolValue(symbol, i))
+		if pattern == "" {
+			pattern = symbol.Pattern
+		}
+		patterns.WriteString(pattern)
+	}
+
+	if err := getLookupError(ctx); err != nil {
+		return "", err
+	}
+	if ctx.Err() != nil {
+		return "", ctx.Err()
+	}
+	return normalizePhoneticKey(patterns.String()), nil
+}
+
+// PhoneticIndex native words by their phonetic keys to
+// find them from Latin inputs, even if typed a bit
+// differently. Safe to use from many goroutines.
+//
+//	key, _ := varnam.PhoneticKey(ctx, "വാർത്ത")
+//	index.Add(key, "വാർത്ത")
+//	key, _ = varnam.InputPhoneticKey(ctx, "vaartha")
+//	index.Lookup(key, 1)
+type PhoneticIndex struct {
+	mutex sync.RWMutex
+
+	words map[string][]string
+
+	// Keys too short or long to be near
+	// a key are skipped in lookups this way
+	keysByLength map[int][]string
+}
+
+// PhoneticMatch a word found in PhoneticIndex
+type PhoneticMatch struct {
+	Word string
+	Key  string
+
+	// Edits to key looked up to make Key
+	Distance int
+}
+
+// NewPhoneticIndex make an empty index
+func NewPhoneticIndex() *PhoneticIndex {
+	return &PhoneticIndex{
+		words:        map[string][]string{},
+		keysByLength: map[int][]string{},
+	}
+}
+
+// Add word with key. A word can have more than one key.
+func (index *PhoneticIndex) Add(key string, word string) {
+	if key == "" {
+		return
+	}
+
+	index.mutex.Lock()
+	defer index.mutex.Unlock()
+
+	words, found := index.words[key]
+	if !found {
+		index.keysByLength[len(key)] = append(index.keysByLength[len(key)], key)
+	}
+
+	for _, existing := range words {
+		if existing == word {
+			return
+		}
+	}
+	index.words[key] = append(words, word)
+}
+
+// Len count of keys in index
+func (index *PhoneticIndex) Len() int {
+	index.mutex.RLock()
+	defer index.mutex.RUnlock()
+
+	return len(index.words)
+}
+
+// Lookup words with keys at most maxDistance edits
+// (insert, delete, change a letter) away from key.
+// Closest ones first.
+func (index *PhoneticIndex) Lookup(key string, maxDistance int) []PhoneticMatch {
+	var matches []PhoneticMatch
+
+	if key == "" {
+		return matches
+	}
+
+	index.mutex.RLock()
+	defer index.mutex.RUnlock()
+
+	for length := len(key) - maxDistance; length <= len(key)+maxDistance; length++ {
+		for _, indexedKey := range index.keysByLength[length] {
+			distance := phoneticKeyDistance(key, indexedKey, maxDistance)
+			if distance > maxDistance {
+				continue
+			}
+
+			for _, word := range index.words[indexedKey] {
+				matches = append(matches, PhoneticMatch{word, indexedKey, distance})
+			}
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].Distance != matches[j].Distance {
+			return matches[i].Distance < matches[j].Distance
+		}
+		return matches[i].Key < matches[j].Key
+	})
+
+	return matches
+}
+
+// Levenshtein distance of keys. Stops once it's more
+// than maxDistance & returns maxDistance + 1.
+func phoneticKeyDistance(a string, b string, maxDistance int) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		rowMin := current[0]
+
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+
+			current[j] = previous[j-1] + cost
+			if previous[j]+1 < current[j] {
+				current[j] = previous[j] + 1
+			}
+			if current[j-1]+1 < current[j] {
+				current[j] = current[j-1] + 1
+			}
+
+			if current[j] < rowMin {
+				rowMin = current[j]
+			}
+		}
+
+		if rowMin > maxDistance {
+			return maxDistance + 1
+		}
+
+		previous, current = current, previous
+	}
+
+	if previous[len(b)] > maxDistance {
+		return maxDistance + 1
+	}
+	return previous[len(b)]
+}
@@ -0,0 +1,110 @@
+package govarnam
+
+import (
+	"context"
+	"path"
+	"testing"
+)
+
+func TestNormalizePhoneticKey(t *testing.T) {
+	for pattern, key := range map[string]string{
+		"vaarththa": "varta",
+		"Aana":      "ana",
+		"r~":        "r",
+		"kee":       "ki",
+		"khoo":      "ku",
+		"chha":      "cha",
+		"shwa":      "shva",
+		"":          "",
+	} {
+		assertEqual(t, normalizePhoneticKey(pattern), key)
+	}
+}
+
+func TestPhoneticKeyDistance(t *testing.T) {
+	assertEqual(t, phoneticKeyDistance("varta", "varta", 2), 0)
+	assertEqual(t, phoneticKeyDistance("varta", "vart", 2), 1)
+	assertEqual(t, phoneticKeyDistance("varta", "barta", 2), 1)
+	assertEqual(t, phoneticKeyDistance("varta", "vrt", 2), 2)
+	assertEqual(t, phoneticKeyDistance("varta", "kala", 2), 3)
+	assertEqual(t, phoneticKeyDistance("", "ka", 2), 2)
+}
+
+func TestPhoneticKey(t *testing.T) {
+	vstPath := path.Join(testTempDir, "phonetic.vst")
+
+	vm, err := VMInit(vstPath)
+	checkError(err)
+	for _, symbol := range []Symbol{
+		{Pattern: "aa", Value1: "ആ", Value2: "ാ", Type: VARNAM_SYMBOL_VOWEL},
+		{Pattern: "A", Value1: "ആ", Value2: "ാ", Type: VARNAM_SYMBOL_VOWEL},
+		{Pattern: "va", Value1: "വ", Type: VARNAM_SYMBOL_CONSONANT},
+		{Pattern: "vaa", Value1: "വാ", Type: VARNAM_SYMBOL_CONSONANT_VOWEL},
+		{Pattern: "tha", Value1: "ത", Type: VARNAM_SYMBOL_CONSONANT},
+		{Pattern: "tha", Value1: "ഥ", Type: VARNAM_SYMBOL_CONSONANT, MatchType: VARNAM_MATCH_POSSIBILITY},
+		{Pattern: "th", Value1: "ത്", Type: VARNAM_SYMBOL_DEAD_CONSONANT},
+		{Pattern: "ra", Value1: "ര", Type: VARNAM_SYMBOL_CONSONANT},
+		{Pattern: "r", Value1: "ര്", Type: VARNAM_SYMBOL_DEAD_CONSONANT},
+		{Pattern: "r", Value1: "ർ", Type: VARNAM_SYMBOL_DEAD_CONSONANT, AcceptCondition: VARNAM_TOKEN_ACCEPT_IF_ENDS_WITH},
+		{Pattern: "ka", Value1: "ക", Type: VARNAM_SYMBOL_CONSONANT},
+		{Pattern: "la", Value1: "ല", Type: VARNAM_SYMBOL_CONSONANT},
+		{Pattern: "~", Value1: "്", Value2: "്", Type: VARNAM_SYMBOL_VIRAMA},
+	} {
+		if symbol.MatchType == 0 {
+			symbol.MatchType = VARNAM_MATCH_EXACT
+		}
+		checkError(vm.VMCreateToken(symbol.Pattern, symbol.Value1, symbol.Value2, "", "", symbol.Type, symbol.MatchType, 0, symbol.AcceptCondition, false))
+	}
+	checkError(vm.VMSetSchemeDetails(SchemeDetails{Identifier: "ml", LangCode: "ml", DisplayName: "Malayalam"}))
+	vm.Close()
+
+	varnam, err := Init(vstPath, path.Join(testTempDir, "phonetic.learnings"))
+	checkError(err)
+	defer varnam.Close()
+
+	ctx := context.Background()
+
+	phoneticKey := func(word string) string {
+		key, err := varnam.PhoneticKey(ctx, word)
+		checkError(err)
+		return key
+	}
+	inputPhoneticKey := func(input string) string {
+		key, err := varnam.InputPhoneticKey(ctx, input)
+		checkError(err)
+		return key
+	}
+
+	// Chillu in between is looked up as a char,
+	// virama is the same as chillu
+	assertEqual(t, phoneticKey("വാർത്ത"), "varta")
+	assertEqual(t, phoneticKey("വാര്ത്ത"), "varta")
+	assertEqual(t, phoneticKey("വാർത്ത, "), "varta")
+
+	for _, input := range []string{"vaartha", "vartha", "vaarththa"} {
+		assertEqual(t, inputPhoneticKey(input), "varta")
+	}
+	assertEqual(t, inputPhoneticKey("kala"), phoneticKey("കല"))
+	assertEqual(t, inputPhoneticKey("A"), inputPhoneticKey("aa"))
+
+	index := NewPhoneticIndex()
+	for _, word := range []string{"വാർത്ത", "വാര്ത്ത", "കല", "കാല"} {
+		index.Add(phoneticKey(word), word)
+	}
+	index.Add(phoneticKey("വാർത്ത"), "വാർത്ത")
+	assertEqual(t, index.Len(), 2)
+
+	matches := index.Lookup(inputPhoneticKey("vaartha"), 0)
+	assertEqual(t, len(matches), 2)
+	assertEqual(t, matches[0], PhoneticMatch{"വാർത്ത", "varta", 0})
+	assertEqual(t, matches[1], PhoneticMatch{"വാര്ത്ത", "varta", 0})
+
+	// Typed a bit differently
+	matches = index.Lookup(inputPhoneticKey("varth"), 1)
+	assertEqual(t, len(matches), 2)
+	assertEqual(t, matches[0].Distance, 1)
+
+	assertEqual(t, len(index.Lookup(inputPhoneticKey("ka"), 1)), 0)
+	assertEqual(t, len(index.Lookup(inputPhoneticKey("ka"), 2)), 2)
+	assertEqual(t, len(index.Lookup("", 2)), 0)
+}
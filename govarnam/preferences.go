@@ -0,0 +1,166 @@
+package govarnam
+
+/**
+ * govarnam - An Indian language transliteration library
+ * Copyright Subin Siby <mail at subinsb (.) com>, 2021
+ * Licensed under AGPL-3.0-only. See LICENSE.txt
+ */
+
+import (
+	"context"
+	sql "database/sql"
+	"sort"
+	"time"
+)
+
+type symbolPreferenceKey struct {
+	pattern string
+	value   string
+}
+
+// Remember which symbol the user chose for each part of input
+// that could've been written differently. The symbols are found
+// by aligning conjuncts of word with tokens of input.
+// Nothing is learnt if they can't be aligned.
+func (varnam *Varnam) learnSymbolPreferences(input string, word string) error {
+	ctx, cancelFunc := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelFunc()
+
+	ctx = withLookupErrors(varnam.withOptions(ctx))
+
+	tokens := *varnam.tokenizeWord(ctx, input, VARNAM_MATCH_ALL, false)
+	conjuncts := varnam.splitTextByConjunct(ctx, varnam.normalizeText(word))
+
+	if err := getLookupError(ctx); err != nil {
+		return err
+	}
+
+	if len(tokens) != len(conjuncts) {
+		varnam.debug("Can't align word with input", "word", word, "input", input)
+		return nil
+	}
+
+	var chosen []symbolPreferenceKey
+
+	for i, token := range tokens {
+		if token.tokenType != VARNAM_TOKEN_SYMBOL {
+			continue
+		}
+
+		values := map[string]bool{}
+		var match *Symbol
+
+		for j, symbol := range token.symbols {
+			value := getSymbolValue(symbol, i)
+			values[value] = true
+
+			if match == nil && value == conjuncts[i].character {
+				match = &token.symbols[j]
+			}
+		}
+
+		// Nothing to choose from
+		if len(values) < 2 || match == nil {
+			continue
+		}
+
+		chosen = append(chosen, symbolPreferenceKey{match.Pattern, match.Value1})
+	}
+
+	if len(chosen) == 0 {
+		return nil
+	}
+
+	err := varnam.writeDict(ctx, func(tx *sql.Tx) error {
+		for _, key := range chosen {
+			_, err := tx.ExecContext(ctx, `INSERT INTO symbol_preferences(pattern, value) VALUES (?, ?)
+				ON CONFLICT(pattern, value) DO UPDATE SET count = MIN(count + 1, ?)`, key.pattern, key.value, VARNAM_SYMBOL_PREFERENCE_MAX_COUNT)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// Tokens & everything made from them changes
+	varnam.cache.clear()
+
+	return nil
+}
+
+// Symbol preferences of learnings. They're kept in cache
+// till it's invalidated, tokenizing is done on every lookup.
+func (varnam *Varnam) getSymbolPreferences(ctx context.Context) (map[symbolPreferenceKey]int, error) {
+	preferences, generation, found := varnam.cache.getPreferences()
+	if found {
+		return preferences, nil
+	}
+
+	rows, err := varnam.dictConn.QueryContext(ctx, "SELECT pattern, value, count FROM symbol_preferences")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	preferences = map[symbolPreferenceKey]int{}
+	for rows.Next() {
+		var (
+			key   symbolPreferenceKey
+			count int
+		)
+		if err := rows.Scan(&key.pattern, &key.value, &count); err != nil {
+			return nil, err
+		}
+
+		// Learnt before counts were capped
+		if count > VARNAM_SYMBOL_PREFERENCE_MAX_COUNT {
+			count = VARNAM_SYMBOL_PREFERENCE_MAX_COUNT
+		}
+		preferences[key] = count
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	varnam.cache.putPreferences(preferences, generation)
+
+	return preferences, nil
+}
+
+// Set how many times the user chose each symbol of tokens. A
+// token's symbols are reordered by weight if the user chose any.
+func (varnam *Varnam) applySymbolPreferences(ctx context.Context, tokens []Token) {
+	ctx = varnam.withCheckedCache(ctx)
+
+	preferences, err := varnam.getSymbolPreferences(ctx)
+	if err != nil {
+		varnam.lookupFailed(ctx, dictError(err))
+		return
+	}
+
+	if len(preferences) == 0 {
+		return
+	}
+
+	for i := range tokens {
+		symbols := tokens[i].symbols
+		preferred := false
+
+		for j := range symbols {
+			count, ok := preferences[symbolPreferenceKey{symbols[j].Pattern, symbols[j].Value1}]
+			if ok {
+				symbols[j].preference = count
+				preferred = true
+			}
+		}
+
+		if preferred {
+			sort.SliceStable(symbols, func(a, b int) bool {
+				return getSymbolWeight(symbols[a]) > getSymbolWeight(symbols[b])
+			})
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package govarnam
+
+import (
+	"context"
+	"testing"
+)
+
+func TestSymbolPreferences(t *testing.T) {
+	varnam := makeTestVarnam("preferences", kalaTestSymbols, 50)
+	defer varnam.Close()
+
+	ctx := context.Background()
+
+	greedy := func(word string) string {
+		result, err := varnam.TransliterateWithOptions(ctx, word, varnam.GetOptions())
+		checkError(err)
+		return result.GreedyTokenized[0].Word
+	}
+
+	accept := func(word string) {
+		result, err := varnam.TransliterateWithOptions(ctx, "kala", varnam.GetOptions())
+		checkError(err)
+
+		shown := result.TokenizerSuggestions
+		for i, sug := range shown {
+			if sug.Word == word {
+				checkError(varnam.AcceptSuggestion("kala", shown, i))
+				return
+			}
+		}
+		t.Fatalf("%s not in %v", word, suggestionWords(shown))
+	}
+
+	// Exact match weighs 200, possibility 50
+	for i := 0; i < 3; i++ {
+		accept("കള")
+	}
+	assertEqual(t, greedy("lala"), "ലല")
+
+	accept("കള")
+
+	// Applies to new words too
+	assertEqual(t, greedy("lala"), "ളള")
+
+	// Exact match is still there
+	result, err := varnam.TransliterateWithOptions(ctx, "lala", varnam.GetOptions())
+	checkError(err)
+	assertEqual(t, hasSuggestion(result.GreedyTokenized, "ലല"), true)
+
+	lattice, err := varnam.TokenLattice(ctx, "lala")
+	checkError(err)
+	assertEqual(t, lattice[0].Alternatives[0].Value, "ള")
+	assertEqual(t, lattice[0].Alternatives[0].Weight, 50+4*VARNAM_SYMBOL_PREFERENCE_WEIGHT)
+
+	// Only symbols that had alternatives are learnt
+	var count int
+	checkError(varnam.dictConn.QueryRow("SELECT COUNT(*) FROM symbol_preferences").Scan(&count))
+	assertEqual(t, count, 1)
+
+	// Counts are capped, so that the other one can get ahead again
+	for i := 0; i < 2*VARNAM_SYMBOL_PREFERENCE_MAX_COUNT; i++ {
+		accept("കള")
+	}
+	checkError(varnam.dictConn.QueryRow("SELECT count FROM symbol_preferences WHERE value = ?", "ള").Scan(&count))
+	assertEqual(t, count, VARNAM_SYMBOL_PREFERENCE_MAX_COUNT)
+
+	for i := 0; i < 4; i++ {
+		accept("കല")
+	}
+	assertEqual(t, greedy("lala"), "ലല")
+
+	// Preferences are kept in memory, changes by
+	// others are found like for learnt words
+	other, err := Init(varnam.VSTPath, varnam.DictPath)
+	checkError(err)
+	defer other.Close()
+
+	_, err = other.dictConn.Exec("UPDATE symbol_preferences SET count = ? WHERE value = ?", VARNAM_SYMBOL_PREFERENCE_MAX_COUNT, "ള")
+	checkError(err)
+	_, err = other.dictConn.Exec("DELETE FROM symbol_preferences WHERE value = ?", "ല")
+	checkError(err)
+
+	assertEqual(t, greedy("lala"), "ളള")
+}
@@ -0,0 +1,203 @@
+package govarnam
+
+/**
+ * govarnam - An Indian language transliteration library
+ * Copyright Subin Siby <mail at subinsb (.) com>, 2021
+ * Licensed under AGPL-3.0-only. See LICENSE.txt
+ */
+
+import (
+	"context"
+	"strings"
+)
+
+// UnreachableWord a word that can't be made by typing in the scheme
+type UnreachableWord struct {
+	Word string
+
+	// The conjunct which can't be made. Either no symbol in scheme
+	// has it, or typing the pattern didn't give it at that place.
+	Conjunct string
+
+	// Pattern tried, from ReverseTransliterate(). Empty if
+	// there was none.
+	Pattern string
+}
+
+// ReachabilityReport how many of the words can be typed
+type ReachabilityReport struct {
+	Total       int
+	Reachable   int
+	Unreachable []UnreachableWord
+}
+
+// Coverage fraction of words that can be typed
+func (report ReachabilityReport) Coverage() float64 {
+	if report.Total == 0 {
+		return 0
+	}
+	return float64(report.Reachable) / float64(report.Total)
+}
+
+// CheckReachability find which of the words can't be made by
+// typing. A word can be made if a pattern ReverseTransliterate()
+// gives for it is tokenized back to the word. Words for which
+// lookups failed are taken as can't be made.
+func (varnam *Varnam) CheckReachability(ctx context.Context, words []string) (ReachabilityReport, error) {
+	var report ReachabilityReport
+
+	ctx = varnam.withOptions(ctx)
+	ctx = varnam.withCheckedCache(ctx)
+
+	for _, word := range words {
+		if ctx.Err() != nil {
+			return report, ctx.Err()
+		}
+
+		word = varnam.normalizeWord(word)
+		if word == "" {
+			continue
+		}
+
+		report.Total++
+
+		// Lookup errors of each word on its own
+		wordCtx := context.WithValue(ctx, lookupErrorsContextKey{}, &lookupErrors{})
+
+		unreachable := varnam.checkWordReachable(wordCtx, word)
+
+		if ctx.Err() != nil {
+			return report, ctx.Err()
+		}
+
+		if err := getLookupError(wordCtx); err != nil {
+			varnam.getLogger().Warn("Couldn't check if word can be typed", "word", word, "error", err)
+			unreachable = &UnreachableWord{Word: word}
+		}
+
+		if unreachable == nil {
+			report.Reachable++
+		} else {
+			report.Unreachable = append(report.Unreachable, *unreachable)
+		}
+	}
+
+	return report, nil
+}
+
+// CheckDictionaryReachability CheckReachability() of all learnt words
+func (varnam *Varnam) CheckDictionaryReachability(ctx context.Context) (ReachabilityReport, error) {
+	var words []string
+
+	rows, err := varnam.dictConn.QueryContext(ctx, "SELECT word FROM words ORDER BY id")
+	if err != nil {
+		return ReachabilityReport{}, dictError(err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var word string
+		rows.Scan(&word)
+		words = append(words, word)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return ReachabilityReport{}, dictError(err)
+	}
+
+	return varnam.CheckReachability(ctx, words)
+}
+
+// nil if word can be typed. ctx should have
+// options & lookup errors attached already.
+func (varnam *Varnam) checkWordReachable(ctx context.Context, word string) *UnreachableWord {
+	conjuncts := varnam.splitTextByConjunct(ctx, word)
+
+	for _, conjunct := range conjuncts {
+		if conjunct.tokenType == VARNAM_TOKEN_CHAR {
+			return &UnreachableWord{Word: word, Conjunct: conjunct.character}
+		}
+	}
+
+	patterns := varnam.reverseTransliterate(ctx, word, varnam.options(ctx).TokenizerSuggestionsLimit)
+
+	if len(patterns) == 0 {
+		return &UnreachableWord{Word: word, Conjunct: word}
+	}
+
+	// Where typing the best pattern went wrong
+	var failedAt int
+
+	for i, pattern := range patterns {
+		made, matched := varnam.matchTokenization(ctx, pattern.Word, word)
+		if made {
+			return nil
+		}
+
+		if i == 0 {
+			failedAt = matched
+		}
+	}
+
+	unreachable := UnreachableWord{Word: word, Pattern: patterns[0].Word}
+
+	// Conjunct having the byte at failedAt
+	offset := 0
+	for _, conjunct := range conjuncts {
+		offset += len(conjunct.character)
+		if offset > failedAt {
+			unreachable.Conjunct = conjunct.character
+			break
+		}
+	}
+
+	// Pattern made all of word, but had more after it
+	if unreachable.Conjunct == "" {
+		unreachable.Conjunct = conjuncts[len(conjuncts)-1].character
+	}
+
+	return &unreachable
+}
+
+// Whether tokenizer can make word from pattern. If not, length
+// of the longest prefix of word (in bytes) it can make.
+func (varnam *Varnam) matchTokenization(ctx context.Context, pattern string, word string) (bool, int) {
+	tokens := removeLessWeightedSymbols(*varnam.tokenizeWord(ctx, pattern, VARNAM_MATCH_ALL, false))
+
+	// Lengths of word made so far in different ways
+	made := map[int]bool{0: true}
+	longest := 0
+
+	for i, token := range tokens {
+		var values []string
+
+		if token.tokenType == VARNAM_TOKEN_SYMBOL {
+			for _, symbol := range token.symbols {
+				values = append(values, getSymbolValue(symbol, i))
+			}
+		} else {
+			values = append(values, token.character)
+		}
+
+		next := map[int]bool{}
+		for length := range made {
+			for _, value := range values {
+				if value != "" && strings.HasPrefix(word[length:], value) {
+					next[length+len(value)] = true
+
+					if length+len(value) > longest {
+						longest = length + len(value)
+					}
+				}
+			}
+		}
+
+		if len(next) == 0 {
+			return false, longest
+		}
+		made = next
+	}
+
+	return made[len(word)], longest
+}
@@ -0,0 +1,59 @@
+package govarnam
+
+import (
+	"context"
+	"testing"
+)
+
+func TestReachability(t *testing.T) {
+	// Weight 0, tokenizer leaves out ള
+	varnam := makeTestVarnam("reachability", kalaTestSymbols, 0)
+	defer varnam.Close()
+
+	ctx := context.Background()
+
+	report, err := varnam.CheckReachability(ctx, []string{"കല", "കലക", "കഷ", "കള", ""})
+	checkError(err)
+
+	assertEqual(t, report.Total, 4)
+	assertEqual(t, report.Reachable, 2)
+	assertEqual(t, report.Coverage(), 0.5)
+
+	assertEqual(t, len(report.Unreachable), 2)
+
+	// No symbol has it
+	assertEqual(t, report.Unreachable[0].Word, "കഷ")
+	assertEqual(t, report.Unreachable[0].Conjunct, "ഷ")
+	assertEqual(t, report.Unreachable[0].Pattern, "")
+
+	// Typing "kala" doesn't give it
+	assertEqual(t, report.Unreachable[1].Word, "കള")
+	assertEqual(t, report.Unreachable[1].Conjunct, "ള")
+	assertEqual(t, report.Unreachable[1].Pattern, "kala")
+
+	checkError(varnam.Learn("കലക", 0))
+	checkError(varnam.Learn("കളക", 0))
+
+	report, err = varnam.CheckDictionaryReachability(ctx)
+	checkError(err)
+
+	assertEqual(t, report.Total, 2)
+	assertEqual(t, report.Reachable, 1)
+	assertEqual(t, report.Unreachable[0].Word, "കളക")
+
+	// Words that couldn't be looked up can't be typed,
+	// the others are still checked
+	vm, err := VMInit(varnam.VSTPath)
+	checkError(err)
+	_, err = vm.vstConn.Exec("DROP TABLE symbols")
+	checkError(err)
+	vm.Close()
+
+	report, err = varnam.CheckReachability(ctx, []string{"കല", "കള"})
+	checkError(err)
+
+	assertEqual(t, report.Total, 2)
+	assertEqual(t, report.Reachable, 0)
+	assertEqual(t, len(report.Unreachable), 2)
+	assertEqual(t, report.Unreachable[0], UnreachableWord{Word: "കല"})
+}
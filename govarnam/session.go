@@ -0,0 +1,335 @@
+package govarnam
+
+/**
+ * govarnam - An Indian language transliteration library
+ * Copyright Subin Siby <mail at subinsb (.) com>, 2021
+ * Licensed under AGPL-3.0-only. See LICENSE.txt
+ */
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// Session composition state of an input method. Keys typed are
+// given with Append() & Backspace(), the word chosen from
+// Candidates() is given to Commit(). Tokens & dictionary matches
+// of the input typed so far are reused on every keystroke instead
+// of transliterating the whole input again.
+// Use a session per input field.
+type Session struct {
+	varnam *Varnam
+
+	mutex sync.Mutex
+
+	input string
+
+	// Tokens of tokenizedInput. Tokens at the start
+	// are reused when input changes at the end.
+	tokens         []Token
+	tokenizedInput string
+
+	// Dictionary prefix matches of this composition
+	dictCache *dictionaryCache
+
+	result     TransliterationResult
+	candidates []Suggestion
+}
+
+// NewSession start a composition
+func (varnam *Varnam) NewSession() *Session {
+	return &Session{
+		varnam:    varnam,
+		dictCache: newDictionaryCache(),
+	}
+}
+
+// Input text typed so far
+func (session *Session) Input() string {
+	session.mutex.Lock()
+	defer session.mutex.Unlock()
+
+	return session.input
+}
+
+// Preedit text to show while composing. It's the first
+// candidate, or the input itself if there are none.
+func (session *Session) Preedit() string {
+	session.mutex.Lock()
+	defer session.mutex.Unlock()
+
+	return session.preedit()
+}
+
+func (session *Session) preedit() string {
+	if len(session.candidates) > 0 {
+		return session.candidates[0].Word
+	}
+	return session.input
+}
+
+// Candidates suggestions for the input,
+// in the order Transliterate() gives
+func (session *Session) Candidates() []Suggestion {
+	session.mutex.Lock()
+	defer session.mutex.Unlock()
+
+	return append([]Suggestion(nil), session.candidates...)
+}
+
+// Result suggestions for the input in detail
+func (session *Session) Result() TransliterationResult {
+	session.mutex.Lock()
+	defer session.mutex.Unlock()
+
+	return session.result
+}
+
+// Append text typed to the input & update candidates
+func (session *Session) Append(ctx context.Context, text string) error {
+	session.mutex.Lock()
+	defer session.mutex.Unlock()
+
+	session.input += text
+
+	return session.update(ctx)
+}
+
+// Backspace remove the last conjunct of preedit
+// from input & update candidates
+func (session *Session) Backspace(ctx context.Context) error {
+	session.mutex.Lock()
+	defer session.mutex.Unlock()
+
+	if session.input == "" {
+		return nil
+	}
+
+	runes := []rune(session.input)
+
+	// Remove the pattern that made the last conjunct.
+	// If it can't be found, just the last character.
+	removeLength := 1
+
+	if len(session.candidates) > 0 {
+		conjuncts := session.varnam.splitTextByConjunct(ctx, session.preedit())
+
+		if len(conjuncts) > 0 {
+			for _, symbol := range conjuncts[len(conjuncts)-1].symbols {
+				patternLength := utf8.RuneCountInString(symbol.Pattern)
+
+				if patternLength > removeLength && patternLength <= len(runes) && strings.HasSuffix(session.input, symbol.Pattern) {
+					removeLength = patternLength
+				}
+			}
+		}
+	}
+
+	session.input = string(runes[:len(runes)-removeLength])
+
+	return session.update(ctx)
+}
+
+// Commit the candidate at index as the word the user wanted.
+// The word is learnt. If greedy tokenization doesn't give the
+// word for the input, input is trained as a pattern for it.
+// Candidates above it are ranked lower for the input after &
+// its symbols are preferred, see AcceptSuggestion().
+// Phrases of shortcuts are committed without learning.
+// Session is reset for the next word.
+func (session *Session) Commit(index int) (string, error) {
+	session.mutex.Lock()
+	defer session.mutex.Unlock()
+
+	if index < 0 || index >= len(session.candidates) {
+		return "", &Error{Code: VARNAM_MISUSE, Message: "No such candidate"}
+	}
+
+	word := session.candidates[index].Word
+
+	// Phrases of shortcuts aren't learnt
+	if session.candidates[index].Source == VARNAM_SOURCE_SHORTCUT {
+		session.reset()
+		return word, nil
+	}
+
+	reproducible := false
+	for _, sug := range session.result.GreedyTokenized {
+		if sug.Word == word {
+			reproducible = true
+			break
+		}
+	}
+
+	err := session.varnam.recordRejections(session.input, session.candidates[:index], word)
+
+	if err == nil {
+		err = session.varnam.learnSymbolPreferences(session.input, word)
+	}
+
+	if err == nil {
+		if reproducible {
+			err = session.varnam.Learn(word, 0)
+		} else {
+			err = session.varnam.Train(session.input, word)
+		}
+	}
+
+	session.reset()
+
+	// Words like these can be typed, but not learnt
+	if errors.Is(err, ErrSingleConjunct) || errors.Is(err, ErrNothingToLearn) || errors.Is(err, ErrWordBlocked) {
+		err = nil
+	}
+
+	return word, err
+}
+
+// Reset clear input without learning anything
+func (session *Session) Reset() {
+	session.mutex.Lock()
+	defer session.mutex.Unlock()
+
+	session.reset()
+}
+
+func (session *Session) reset() {
+	session.input = ""
+	session.tokens = nil
+	session.tokenizedInput = ""
+	session.clearCandidates()
+
+	// Learnings may have changed
+	session.dictCache = newDictionaryCache()
+}
+
+// Candidates are of the input before, they shouldn't
+// be committed for input it changed to
+func (session *Session) clearCandidates() {
+	session.result = TransliterationResult{}
+	session.candidates = nil
+}
+
+// Tokenize & transliterate input again.
+// Candidates are cleared if it fails.
+func (session *Session) update(ctx context.Context) error {
+	if session.input == "" {
+		session.tokens = nil
+		session.tokenizedInput = ""
+		session.clearCandidates()
+		return nil
+	}
+
+	ctx = session.varnam.withOptions(ctx)
+	ctx = withLookupErrors(ctx)
+	ctx = withDictionaryCache(ctx, session.dictCache)
+
+	runes := []rune(session.input)
+
+	reused := session.reusableTokens(runes)
+	tokensPointer := session.varnam.tokenizeRunes(ctx, runes, reused, VARNAM_MATCH_ALL, false)
+
+	// Reused ones have them applied already
+	session.varnam.applySymbolPreferences(ctx, (*tokensPointer)[len(reused):])
+	if ctx.Err() != nil {
+		session.tokens = nil
+		session.tokenizedInput = ""
+		session.clearCandidates()
+		return ctx.Err()
+	}
+
+	// Later stages modify tokens, keep a copy to reuse
+	session.tokens = make([]Token, len(*tokensPointer))
+	copy(session.tokens, *tokensPointer)
+	session.tokenizedInput = session.input
+
+	result := session.varnam.transliterateTokens(ctx, session.input, tokensPointer, time.Time{})
+
+	if err := getLookupError(ctx); err != nil {
+		session.clearCandidates()
+		return err
+	}
+	if ctx.Err() != nil {
+		session.clearCandidates()
+		return ctx.Err()
+	}
+
+	session.result = result
+	session.candidates = result.Suggestions()
+
+	return nil
+}
+
+// Tokens made before that will stay the same for runes.
+// Tokens whose pattern lookup could've seen a changed
+// character have to be made again.
+func (session *Session) reusableTokens(runes []rune) []Token {
+	oldRunes := []rune(session.tokenizedInput)
+
+	// Length of the part both have in common
+	common := 0
+	for common < len(oldRunes) && common < len(runes) && oldRunes[common] == runes[common] {
+		common++
+	}
+
+	var tokens []Token
+
+	start := 0
+	for _, token := range session.tokens {
+		// Lookup of this token read till start + PatternLongestLength.
+		// The last character is looked up differently too.
+		if start+session.varnam.LangRules.PatternLongestLength >= common {
+			break
+		}
+
+		tokens = append(tokens, token)
+		start = token.position + 1
+	}
+
+	return tokens
+}
+
+// Results of dictionary prefix searches kept for reuse
+type dictionaryCache struct {
+	mutex   sync.Mutex
+	results map[string][]searchDictionaryResult
+}
+
+type dictionaryCacheContextKey struct{}
+
+func newDictionaryCache() *dictionaryCache {
+	return &dictionaryCache{results: map[string][]searchDictionaryResult{}}
+}
+
+// Reuse dictionary search results in ctx
+func withDictionaryCache(ctx context.Context, cache *dictionaryCache) context.Context {
+	return context.WithValue(ctx, dictionaryCacheContextKey{}, cache)
+}
+
+func getDictionaryCache(ctx context.Context) *dictionaryCache {
+	cache, _ := ctx.Value(dictionaryCacheContextKey{}).(*dictionaryCache)
+	return cache
+}
+
+// Results are copied both ways since callers modify them
+func (cache *dictionaryCache) get(words []string) ([]searchDictionaryResult, bool) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	results, found := cache.results[strings.Join(words, "\x00")]
+	if !found {
+		return nil, false
+	}
+	return append([]searchDictionaryResult(nil), results...), true
+}
+
+func (cache *dictionaryCache) put(words []string, results []searchDictionaryResult) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	cache.results[strings.Join(words, "\x00")] = append([]searchDictionaryResult(nil), results...)
+}
@@ -0,0 +1,164 @@
+package govarnam
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func suggestionWords(sugs []Suggestion) []string {
+	var words []string
+	for _, sug := range sugs {
+		words = append(words, sug.Word)
+	}
+	return words
+}
+
+func assertSameWords(t *testing.T, got []Suggestion, expected []Suggestion) {
+	t.Helper()
+
+	gotWords := suggestionWords(got)
+	expectedWords := suggestionWords(expected)
+
+	if len(gotWords) != len(expectedWords) {
+		t.Fatalf("Received %v, expected %v", gotWords, expectedWords)
+	}
+	for i := range gotWords {
+		if gotWords[i] != expectedWords[i] {
+			t.Fatalf("Received %v, expected %v", gotWords, expectedWords)
+		}
+	}
+}
+
+func TestSessionUpdateFailed(t *testing.T) {
+	varnam := makeTestVarnam("session-update-failed", simpleTestSymbols, 0)
+	defer varnam.Close()
+
+	session := varnam.NewSession()
+	checkError(session.Append(context.Background(), "ka"))
+	assertEqual(t, session.Preedit(), "ക")
+
+	// Changing candidates given doesn't change the session's
+	candidates := session.Candidates()
+	candidates[0].Word = "മ"
+	assertEqual(t, session.Candidates()[0].Word, "ക")
+
+	// Candidates of "ka" aren't for "kala"
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := session.Append(ctx, "la")
+	assertEqual(t, errors.Is(err, context.Canceled), true)
+	assertEqual(t, session.Input(), "kala")
+	assertEqual(t, len(session.Candidates()), 0)
+	assertEqual(t, session.Preedit(), "kala")
+
+	_, err = session.Commit(0)
+	assertEqual(t, ErrorCode(err), VARNAM_MISUSE)
+
+	// Works again with the next keystroke
+	checkError(session.Append(context.Background(), "ma"))
+	assertEqual(t, session.Preedit(), "കലമ")
+}
+
+func TestMLSessionIncremental(t *testing.T) {
+	varnam := getVarnamInstance("ml")
+	ctx := context.Background()
+
+	for _, input := range []string{"nithyam", "malayalam", "thiruvananthapuram", "kshethrangal"} {
+		session := varnam.NewSession()
+
+		// Should be same as transliterating the whole input every time
+		for i, char := range input {
+			checkError(session.Append(ctx, string(char)))
+
+			expected, err := varnam.TransliterateWithOptions(ctx, input[:i+1], varnam.GetOptions())
+			checkError(err)
+
+			assertEqual(t, session.Input(), input[:i+1])
+			assertSameWords(t, session.Candidates(), expected.Suggestions())
+			assertEqual(t, session.Preedit(), expected.Suggestions()[0].Word)
+		}
+	}
+}
+
+func TestMLSessionBackspace(t *testing.T) {
+	varnam := getVarnamInstance("ml")
+	ctx := context.Background()
+
+	session := varnam.NewSession()
+	checkError(session.Append(ctx, "nithyam"))
+
+	// "m" made the last conjunct
+	checkError(session.Backspace(ctx))
+	assertEqual(t, session.Input(), "nithya")
+
+	expected, err := varnam.TransliterateWithOptions(ctx, "nithya", varnam.GetOptions())
+	checkError(err)
+	assertSameWords(t, session.Candidates(), expected.Suggestions())
+
+	// A conjunct is removed each time
+	for session.Input() != "" {
+		before := session.Input()
+		checkError(session.Backspace(ctx))
+
+		after := session.Input()
+		assertEqual(t, len(after) < len(before) && strings.HasPrefix(before, after), true)
+
+		if after != "" {
+			expected, err := varnam.TransliterateWithOptions(ctx, after, varnam.GetOptions())
+			checkError(err)
+			assertSameWords(t, session.Candidates(), expected.Suggestions())
+		}
+	}
+
+	checkError(session.Backspace(ctx))
+	assertEqual(t, len(session.Candidates()), 0)
+	assertEqual(t, session.Preedit(), "")
+}
+
+func TestMLSessionCommit(t *testing.T) {
+	varnam := getVarnamInstance("ml")
+	ctx := context.Background()
+
+	session := varnam.NewSession()
+
+	_, err := session.Commit(0)
+	assertEqual(t, ErrorCode(err), VARNAM_MISUSE)
+
+	// Greedy output is learnt
+	checkError(session.Append(ctx, "kaadu"))
+	greedy := session.Result().GreedyTokenized[0].Word
+
+	word, err := session.Commit(0)
+	checkError(err)
+	assertEqual(t, word, greedy)
+	assertEqual(t, session.Input(), "")
+
+	_, err = varnam.getWordInfo(greedy)
+	checkError(err)
+
+	// A word not from greedy output gets the input trained as pattern
+	checkError(session.Append(ctx, "pachcha"))
+
+	index := -1
+	for i, sug := range session.Candidates() {
+		if sug.Source != VARNAM_SOURCE_GREEDY_TOKENIZED {
+			index = i
+			break
+		}
+	}
+
+	word, err = session.Commit(index)
+	checkError(err)
+
+	result := varnam.TransliterateAdvanced("pachcha")
+	assertEqual(t, result.ExactWords[0].Word, word)
+
+	// Single conjuncts can be typed, but not learnt
+	checkError(session.Append(ctx, "a"))
+	word, err = session.Commit(0)
+	checkError(err)
+	assertEqual(t, errors.Is(varnam.Learn(word, 0), ErrSingleConjunct), true)
+}
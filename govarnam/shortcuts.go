@@ -0,0 +1,250 @@
+package govarnam
+
+/**
+ * govarnam - An Indian language transliteration library
+ * Copyright Subin Siby <mail at subinsb (.) com>, 2021
+ * Licensed under AGPL-3.0-only. See LICENSE.txt
+ */
+
+import (
+	"bufio"
+	"context"
+	sql "database/sql"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// Shortcut input typed as is which gives a phrase. Unlike
+// learnt words, phrase can have spaces & punctuation.
+type Shortcut struct {
+	Input  string
+	Phrase string
+}
+
+// Check & clean a shortcut before saving
+func (varnam *Varnam) makeShortcut(input string, phrase string) (Shortcut, error) {
+	input = strings.TrimSpace(input)
+	phrase = varnam.normalizeText(strings.TrimSpace(phrase))
+
+	if input == "" || phrase == "" {
+		return Shortcut{}, &Error{Code: VARNAM_MISUSE, Message: "Shortcut needs an input & a phrase"}
+	}
+	if len(strings.Fields(input)) != 1 {
+		return Shortcut{}, &Error{Code: VARNAM_MISUSE, Message: fmt.Sprintf("Shortcut input %q can't have spaces", input)}
+	}
+	// Exported files have a shortcut per line, tab separated
+	if strings.ContainsAny(phrase, "\t\r\n") {
+		return Shortcut{}, &Error{Code: VARNAM_MISUSE, Message: "Shortcut phrase can't have tabs or line breaks"}
+	}
+
+	return Shortcut{input, phrase}, nil
+}
+
+// AddShortcut suggest phrase first when input is typed.
+// Input is matched as it is, case included. Phrase of
+// an existing shortcut with the same input is replaced.
+func (varnam *Varnam) AddShortcut(input string, phrase string) error {
+	shortcut, err := varnam.makeShortcut(input, phrase)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancelFunc := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelFunc()
+
+	err = varnam.writeDict(ctx, func(tx *sql.Tx) error {
+		return saveShortcuts(ctx, tx, []Shortcut{shortcut})
+	})
+	if err != nil {
+		return err
+	}
+
+	varnam.cache.clear()
+
+	return nil
+}
+
+func saveShortcuts(ctx context.Context, tx *sql.Tx, shortcuts []Shortcut) error {
+	stmt, err := tx.PrepareContext(ctx, "INSERT OR REPLACE INTO shortcuts (input, phrase) VALUES (?, ?)")
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, shortcut := range shortcuts {
+		_, err = stmt.ExecContext(ctx, shortcut.Input, shortcut.Phrase)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RemoveShortcut remove shortcut with input
+func (varnam *Varnam) RemoveShortcut(input string) error {
+	ctx, cancelFunc := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelFunc()
+
+	err := varnam.writeDict(ctx, func(tx *sql.Tx) error {
+		result, err := tx.ExecContext(ctx, "DELETE FROM shortcuts WHERE input = ?", strings.TrimSpace(input))
+		if err != nil {
+			return err
+		}
+
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+
+		if affected == 0 {
+			return &Error{Code: VARNAM_WORD_NOT_FOUND, Message: "Shortcut doesn't exist"}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	varnam.cache.clear()
+
+	return nil
+}
+
+// GetShortcuts all shortcuts sorted by input
+func (varnam *Varnam) GetShortcuts(ctx context.Context) ([]Shortcut, error) {
+	var shortcuts []Shortcut
+
+	rows, err := varnam.dictConn.QueryContext(ctx, "SELECT input, phrase FROM shortcuts ORDER BY input")
+	if err != nil {
+		return shortcuts, dictError(err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var shortcut Shortcut
+		rows.Scan(&shortcut.Input, &shortcut.Phrase)
+		shortcuts = append(shortcuts, shortcut)
+	}
+
+	if err := rows.Err(); err != nil {
+		return shortcuts, dictError(err)
+	}
+
+	return shortcuts, nil
+}
+
+// Phrases of shortcut with input as suggestions
+func (varnam *Varnam) getShortcutSuggestions(ctx context.Context, input string) []Suggestion {
+	var sugs []Suggestion
+
+	rows, err := varnam.dictConn.QueryContext(ctx, "SELECT phrase FROM shortcuts WHERE input = ?", input)
+	if err != nil {
+		varnam.lookupFailed(ctx, dictError(err))
+		return sugs
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		sug := Suggestion{
+			Weight: VARNAM_LEARNT_WORD_MIN_WEIGHT,
+			Source: VARNAM_SOURCE_SHORTCUT,
+		}
+		rows.Scan(&sug.Word)
+		sugs = append(sugs, sug)
+	}
+
+	if err := rows.Err(); err != nil {
+		varnam.lookupFailed(ctx, dictError(err))
+	}
+
+	return sugs
+}
+
+// ExportShortcuts write shortcuts to a file with a shortcut
+// per line, input & phrase separated by a tab
+func (varnam *Varnam) ExportShortcuts(filePath string) error {
+	if fileExists(filePath) {
+		return fmt.Errorf("Output file already exists")
+	}
+
+	shortcuts, err := varnam.GetShortcuts(context.Background())
+	if err != nil {
+		return err
+	}
+
+	var contents strings.Builder
+	for _, shortcut := range shortcuts {
+		contents.WriteString(shortcut.Input + "\t" + shortcut.Phrase + "\n")
+	}
+
+	return os.WriteFile(filePath, []byte(contents.String()), 0644)
+}
+
+// ImportShortcuts add shortcuts in a file made by ExportShortcuts().
+// Empty lines & lines starting with # are skipped. Existing
+// shortcuts with the same input are replaced.
+func (varnam *Varnam) ImportShortcuts(filePath string) (LearnStatus, error) {
+	learnStatus := LearnStatus{0, 0}
+
+	file, err := os.Open(filePath)
+	if err != nil {
+		return learnStatus, err
+	}
+	defer file.Close()
+
+	var shortcuts []Shortcut
+
+	scanner := bufio.NewScanner(file)
+
+	lineCount := 0
+	for scanner.Scan() {
+		line := scanner.Text()
+		lineCount++
+
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		learnStatus.TotalWords++
+
+		parts := strings.SplitN(line, "\t", 2)
+		if len(parts) != 2 {
+			learnStatus.FailedWords++
+			varnam.getLogger().Warn("Line is not in correct format", "line", lineCount)
+			continue
+		}
+
+		shortcut, err := varnam.makeShortcut(parts[0], parts[1])
+		if err != nil {
+			learnStatus.FailedWords++
+			varnam.getLogger().Warn("Couldn't import shortcut", "line", lineCount, "error", err)
+			continue
+		}
+
+		shortcuts = append(shortcuts, shortcut)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return learnStatus, err
+	}
+
+	if len(shortcuts) == 0 {
+		return learnStatus, nil
+	}
+
+	ctx, cancelFunc := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancelFunc()
+
+	err = varnam.writeDict(ctx, func(tx *sql.Tx) error {
+		return saveShortcuts(ctx, tx, shortcuts)
+	})
+	if err != nil {
+		return learnStatus, err
+	}
+
+	varnam.cache.clear()
+
+	return learnStatus, nil
+}
@@ -0,0 +1,100 @@
+package govarnam
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path"
+	"testing"
+)
+
+func TestShortcuts(t *testing.T) {
+	varnam := makeTestVarnam("shortcuts", simpleTestSymbols, 0)
+	defer varnam.Close()
+
+	ctx := context.Background()
+
+	phrase := "കമല, പാലം കടക്കുവോളം! മല"
+	checkError(varnam.AddShortcut("kml", phrase))
+
+	result, err := varnam.TransliterateWithOptions(ctx, "kml", varnam.GetOptions())
+	checkError(err)
+	assertEqual(t, result.ExactWords[0].Word, phrase)
+	assertEqual(t, result.ExactWords[0].Source, VARNAM_SOURCE_SHORTCUT)
+
+	// Input is matched as it is
+	result, err = varnam.TransliterateWithOptions(ctx, "kmla", varnam.GetOptions())
+	checkError(err)
+	assertEqual(t, hasSuggestion(result.Suggestions(), phrase), false)
+
+	// Replaced
+	checkError(varnam.AddShortcut("kml", "കമല മല"))
+	result, err = varnam.TransliterateWithOptions(ctx, "kml", varnam.GetOptions())
+	checkError(err)
+	assertEqual(t, result.ExactWords[0].Word, "കമല മല")
+
+	assertEqual(t, varnam.AddShortcut("k ml", "കമല") != nil, true)
+	assertEqual(t, varnam.AddShortcut("kml", " ") != nil, true)
+	assertEqual(t, varnam.AddShortcut("kml", "കമല\tമല") != nil, true)
+
+	// Committing a phrase doesn't learn it
+	session := varnam.NewSession()
+	checkError(session.Append(ctx, "kml"))
+	assertEqual(t, session.Preedit() != "", true)
+
+	committed := ""
+	for i, sug := range session.Candidates() {
+		if sug.Source == VARNAM_SOURCE_SHORTCUT {
+			committed, err = session.Commit(i)
+			checkError(err)
+			break
+		}
+	}
+	assertEqual(t, committed, "കമല മല")
+	assertEqual(t, len(varnam.searchDictionary(ctx, []string{"കമല മല"}, searchExactWords)), 0)
+
+	checkError(varnam.AddShortcut("pl", "പല"))
+
+	shortcuts, err := varnam.GetShortcuts(ctx)
+	checkError(err)
+	assertEqual(t, len(shortcuts), 2)
+	assertEqual(t, shortcuts[0], Shortcut{"kml", "കമല മല"})
+	assertEqual(t, shortcuts[1], Shortcut{"pl", "പല"})
+
+	// Export & import
+	exportPath := path.Join(testTempDir, "shortcuts.tsv")
+	os.Remove(exportPath)
+	checkError(varnam.ExportShortcuts(exportPath))
+	assertEqual(t, varnam.ExportShortcuts(exportPath) != nil, true)
+
+	checkError(varnam.RemoveShortcut("kml"))
+	checkError(varnam.RemoveShortcut("pl"))
+	assertEqual(t, errors.Is(varnam.RemoveShortcut("pl"), ErrWordNotFound), true)
+
+	result, err = varnam.TransliterateWithOptions(ctx, "kml", varnam.GetOptions())
+	checkError(err)
+	assertEqual(t, hasSuggestion(result.Suggestions(), "കമല മല"), false)
+
+	status, err := varnam.ImportShortcuts(exportPath)
+	checkError(err)
+	assertEqual(t, status, LearnStatus{2, 0})
+
+	shortcuts, err = varnam.GetShortcuts(ctx)
+	checkError(err)
+	assertEqual(t, len(shortcuts), 2)
+
+	filePath := makeFile("shortcuts-import.tsv", "# Comment\nmp\tമപ\n\nno phrase\nk l\tകല\n")
+	status, err = varnam.ImportShortcuts(filePath)
+	checkError(err)
+	assertEqual(t, status, LearnStatus{3, 2})
+
+	result, err = varnam.TransliterateWithOptions(ctx, "mp", varnam.GetOptions())
+	checkError(err)
+	assertEqual(t, result.ExactWords[0].Word, "മപ")
+
+	// Blocked phrases aren't shown
+	checkError(varnam.BlockWord("മപ"))
+	result, err = varnam.TransliterateWithOptions(ctx, "mp", varnam.GetOptions())
+	checkError(err)
+	assertEqual(t, hasSuggestion(result.Suggestions(), "മപ"), false)
+}
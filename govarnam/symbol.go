@@ -10,7 +10,7 @@ import (
 	"context"
 	sql "database/sql"
 	"fmt"
-	"log"
+	"strconv"
 	"strings"
 	"unicode"
 
@@ -31,6 +31,9 @@ type Symbol struct {
 	Priority        int
 	AcceptCondition int
 	Flags           int
+
+	// Times the user chose this symbol, see learnSymbolPreferences()
+	preference int
 }
 
 // Token info for making a suggestion
@@ -41,46 +44,71 @@ type Token struct {
 	character string // Non language character
 }
 
-var sqlite3WithLimitDriverRegistered bool
-var sqlite3Conn *sqlite3.SQLiteConn
-
 func openDB(path string) (*sql.DB, error) {
-	if !sqlite3WithLimitDriverRegistered {
-		sql.Register("sqlite3_with_limit", &sqlite3.SQLiteDriver{
-			ConnectHook: func(conn *sqlite3.SQLiteConn) error {
-				sqlite3Conn = conn
-				return nil
-			},
-		})
-		sqlite3WithLimitDriverRegistered = true
-	}
-
-	conn, err := sql.Open("sqlite3_with_limit", path)
+	conn, err := sql.Open("sqlite3", path)
 	if err != nil {
 		return nil, err
 	}
 	return conn, nil
 }
 
+// Get a SQLite limit (sqlite3.SQLITE_LIMIT_*) of a DB connection
+func getDBLimit(db *sql.DB, limitID int) (int, error) {
+	conn, err := db.Conn(context.Background())
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close()
+
+	limit := 0
+
+	err = conn.Raw(func(driverConn interface{}) error {
+		sqliteConn, ok := driverConn.(*sqlite3.SQLiteConn)
+		if !ok {
+			return fmt.Errorf("not a SQLite connection")
+		}
+		limit = sqliteConn.GetLimit(limitID)
+		return nil
+	})
+
+	return limit, err
+}
+
+// Time to wait for a lock held by another connection.
+// Used as connection string parameter
+func busyTimeoutParam() string {
+	return "_busy_timeout=" + strconv.FormatInt(VARNAM_DB_BUSY_TIMEOUT.Milliseconds(), 10)
+}
+
 // InitVST initialize
 func (varnam *Varnam) InitVST(vstPath string) error {
 	var err error
-	varnam.vstConn, err = openDB(vstPath + "?_case_sensitive_like=on")
+
+	// VST is only read from. Shared cache lets all varnam
+	// instances of the process use the same page cache
+	varnam.vstConn, err = openDB("file:" + vstPath + "?mode=ro&cache=shared&_case_sensitive_like=on&" + busyTimeoutParam())
 
 	if err != nil {
 		return err
 	}
 
+	err = varnam.checkVSTSchema()
+	if err != nil {
+		return err
+	}
+
 	err = varnam.setPatternLongestLength()
 	if err != nil {
 		return err
 	}
 
 	varnam.vstConn.Exec("PRAGMA TEMP_STORE=2;")
-	varnam.vstConn.Exec("PRAGMA LOCKING_MODE=EXCLUSIVE;")
 
 	varnam.VSTPath = vstPath
-	varnam.setSchemeInfo()
+	err = varnam.setSchemeInfo()
+	if err != nil {
+		return err
+	}
 
 	return nil
 }
@@ -89,8 +117,9 @@ func (varnam *Varnam) InitVST(vstPath string) error {
 func (varnam *Varnam) setPatternLongestLength() error {
 	rows, err := varnam.vstConn.Query("SELECT MAX(LENGTH(pattern)) FROM symbols")
 	if err != nil {
-		return err
+		return vstError(err)
 	}
+	defer rows.Close()
 
 	length := 0
 	for rows.Next() {
@@ -109,11 +138,11 @@ func (varnam *Varnam) setPatternLongestLength() error {
 	return nil
 }
 
-func (varnam *Varnam) setSchemeInfo() {
+func (varnam *Varnam) setSchemeInfo() error {
 	rows, err := varnam.vstConn.Query("SELECT * FROM metadata")
 
 	if err != nil {
-		log.Print(err)
+		return vstError(err)
 	}
 	defer rows.Close()
 
@@ -141,6 +170,15 @@ func (varnam *Varnam) setSchemeInfo() {
 			}
 		}
 	}
+
+	return vstError(rows.Err())
+}
+
+// A corrupt VST or one of a different schema would
+// otherwise only show up as no suggestions
+func (varnam *Varnam) checkVSTSchema() error {
+	_, err := varnam.vstConn.Exec("SELECT id, type, pattern, value1, value2, value3, tag, match_type, priority, accept_condition, flags, weight FROM symbols LIMIT 1; SELECT key, value FROM metadata LIMIT 1")
+	return vstError(err)
 }
 
 func (varnam *Varnam) searchPattern(ctx context.Context, ch string, matchType int, acceptCondition int) []Symbol {
@@ -161,7 +199,7 @@ func (varnam *Varnam) searchPattern(ctx context.Context, ch string, matchType in
 		}
 
 		if err != nil {
-			log.Print(err)
+			varnam.lookupFailed(ctx, vstError(err))
 			return results
 		}
 		defer rows.Close()
@@ -169,12 +207,13 @@ func (varnam *Varnam) searchPattern(ctx context.Context, ch string, matchType in
 		for rows.Next() {
 			var item Symbol
 			rows.Scan(&item.Identifier, &item.Type, &item.Pattern, &item.Value1, &item.Value2, &item.Value3, &item.Tag, &item.MatchType, &item.Priority, &item.AcceptCondition, &item.Flags, &item.Weight)
+			varnam.normalizeSymbol(&item)
 			results = append(results, item)
 		}
 
 		err = rows.Err()
 		if err != nil {
-			log.Print(err)
+			varnam.lookupFailed(ctx, vstError(err))
 		}
 
 		return results
@@ -190,6 +229,20 @@ func (varnam *Varnam) findLongestPatternMatchSymbols(ctx context.Context, patter
 		vals       []interface{}
 	)
 
+	// No symbol has a pattern longer than this,
+	// keeps the IN list below small whatever the input is.
+	maxLength := varnam.LangRules.PatternLongestLength
+	if maxLength <= 0 {
+		maxLength = VARNAM_SYMBOL_MAX
+	}
+	if len(pattern) > maxLength {
+		pattern = pattern[:maxLength]
+	}
+
+	if len(pattern) == 0 {
+		return results
+	}
+
 	if matchType != VARNAM_MATCH_ALL {
 		vals = append(vals, matchType)
 	}
@@ -205,13 +258,11 @@ func (varnam *Varnam) findLongestPatternMatchSymbols(ctx context.Context, patter
 		vals = append(vals, string(pattern[0:i+1]))
 	}
 
-	if varnam.Debug {
-		// The query will be made like :
-		//   SELECT * FROM symbols WHERE pattern IN ('e', 'en', 'ent', 'enth', 'entho')
-		// Will fetch the longest prefix match
-		// Idea from https://stackoverflow.com/a/1860279/1372424
-		fmt.Println(patternINs, vals)
-	}
+	// The query will be made like :
+	//   SELECT * FROM symbols WHERE pattern IN ('e', 'en', 'ent', 'enth', 'entho')
+	// Will fetch the longest prefix match
+	// Idea from https://stackoverflow.com/a/1860279/1372424
+	varnam.debug("Finding longest pattern match", "patterns", vals)
 
 	select {
 	case <-ctx.Done():
@@ -226,7 +277,7 @@ func (varnam *Varnam) findLongestPatternMatchSymbols(ctx context.Context, patter
 		rows, err := varnam.vstConn.QueryContext(ctx, query, vals...)
 
 		if err != nil {
-			log.Print(err)
+			varnam.lookupFailed(ctx, vstError(err))
 			return results
 		}
 		defer rows.Close()
@@ -234,12 +285,13 @@ func (varnam *Varnam) findLongestPatternMatchSymbols(ctx context.Context, patter
 		for rows.Next() {
 			var item Symbol
 			rows.Scan(&item.Identifier, &item.Type, &item.Pattern, &item.Value1, &item.Value2, &item.Value3, &item.Tag, &item.MatchType, &item.Priority, &item.AcceptCondition, &item.Flags, &item.Weight)
+			varnam.normalizeSymbol(&item)
 			results = append(results, item)
 		}
 
 		err = rows.Err()
 		if err != nil {
-			log.Print(err)
+			varnam.lookupFailed(ctx, vstError(err))
 		}
 
 		return results
@@ -248,15 +300,41 @@ func (varnam *Varnam) findLongestPatternMatchSymbols(ctx context.Context, patter
 
 // Convert a string into Tokens for later processing
 func (varnam *Varnam) tokenizeWord(ctx context.Context, word string, matchType int, partial bool) *[]Token {
-	var results []Token
+	ctx = varnam.withCheckedCache(ctx)
+
+	cacheKey := tokenCacheKey{word, matchType, partial, varnam.options(ctx).IndicDigits}
+
+	// Cached tokens are of VST only, user's preferences
+	// can change without VST changing
+	if tokens, found := varnam.cache.getTokens(cacheKey); found {
+		varnam.applySymbolPreferences(ctx, tokens)
+		return &tokens
+	}
+
+	tokens := varnam.tokenizeRunes(ctx, []rune(word), nil, matchType, partial)
 
+	// Tokens may be incomplete if these happened
+	if ctx.Err() == nil && getLookupError(ctx) == nil {
+		varnam.cache.putTokens(cacheKey, *tokens)
+	}
+
+	varnam.applySymbolPreferences(ctx, *tokens)
+
+	return tokens
+}
+
+// Tokenize the runes after the ones made into results already.
+// Tokens of a prefix of the input can be reused this way.
+func (varnam *Varnam) tokenizeRunes(ctx context.Context, runes []rune, results []Token, matchType int, partial bool) *[]Token {
 	select {
 	case <-ctx.Done():
 		return &results
 	default:
-		runes := []rune(word)
-
 		i := 0
+		if len(results) > 0 {
+			i = results[len(results)-1].position + 1
+		}
+
 		for i < len(runes) {
 			end := i + varnam.LangRules.PatternLongestLength
 			if len(runes) < end {
@@ -280,7 +358,7 @@ func (varnam *Varnam) tokenizeWord(ctx context.Context, word string, matchType i
 				if unicode.In(sequence[0], &varnam.LangRules.UnicodeBlock) {
 					// This helps to get suggestions in inputs like "ആലppu"
 					character := string(sequence[0])
-					token := Token{VARNAM_TOKEN_SYMBOL, []Symbol{{Value1: character}}, i, character}
+					token := Token{VARNAM_TOKEN_SYMBOL, []Symbol{{Value1: varnam.normalizeSymbolValue(character)}}, i, character}
 					results = append(results, token)
 				} else {
 					// No matches, add a character token
@@ -291,7 +369,7 @@ func (varnam *Varnam) tokenizeWord(ctx context.Context, word string, matchType i
 
 				i++
 			} else {
-				if matches[0].Type == VARNAM_SYMBOL_NUMBER && !varnam.LangRules.IndicDigits {
+				if matches[0].Type == VARNAM_SYMBOL_NUMBER && !varnam.options(ctx).IndicDigits {
 					// Skip numbers
 					// Note that we just add 1 character, and move on
 					token := Token{VARNAM_TOKEN_CHAR, []Symbol{}, i, string(sequence[:1])}
@@ -330,17 +408,21 @@ func (varnam *Varnam) tokenizeWord(ctx context.Context, word string, matchType i
 func (varnam *Varnam) tokenizeRestOfWord(ctx context.Context, word string, sugs []Suggestion, limit int) []Suggestion {
 	var results []Suggestion
 
-	if varnam.Debug {
-		fmt.Printf("Tokenizing %s\n", word)
-	}
+	varnam.debug("Tokenizing", "word", word)
 
-	tokensPointerChan := make(chan *[]Token)
+	// Buffered so that the worker won't block if we return on cancel
+	tokensPointerChan := make(chan *[]Token, 1)
 	go varnam.channelTokenizeWord(ctx, word, VARNAM_MATCH_ALL, true, tokensPointerChan)
 
 	select {
 	case <-ctx.Done():
 		return results
 	case restOfWordTokens := <-tokensPointerChan:
+		// Channel gets closed without a value if cancelled
+		if restOfWordTokens == nil {
+			return results
+		}
+
 		for _, sug := range sugs {
 			sugWord := varnam.removeLastVirama(sug.Word)
 			tokensWithWord := []Token{{VARNAM_TOKEN_CHAR, []Symbol{}, 0, sugWord}}
@@ -348,14 +430,13 @@ func (varnam *Varnam) tokenizeRestOfWord(ctx context.Context, word string, sugs
 
 			restOfWordSugs := varnam.tokensToSuggestions(ctx, &tokensWithWord, true, limit)
 
-			if varnam.Debug {
-				fmt.Println("Tokenized & Added:", restOfWordSugs)
-			}
+			varnam.debug("Tokenized & Added", "suggestions", restOfWordSugs)
 
 			for _, restOfWordSug := range restOfWordSugs {
 				// Preserve original word's weight and timestamp
 				restOfWordSug.Weight += sug.Weight
 				restOfWordSug.LearnedOn = sug.LearnedOn
+				restOfWordSug.MatchedLength = sug.MatchedLength
 				results = append(results, restOfWordSug)
 			}
 		}
@@ -434,9 +515,7 @@ func (varnam *Varnam) splitWordByConjunct(word string) []string {
 	var result []string
 	tokens := varnam.splitTextByConjunct(ctx, word)
 
-	if varnam.Debug {
-		log.Println(tokens)
-	}
+	varnam.debug("Split by conjunct", "tokens", tokens)
 
 	for _, token := range tokens {
 		if token.tokenType == VARNAM_TOKEN_SYMBOL {
@@ -459,6 +538,14 @@ func (varnam *Varnam) splitWordByConjunct(word string) []string {
 	return result
 }
 
+// Values of symbols are joined to make words,
+// they're normalized when read from VST
+func (varnam *Varnam) normalizeSymbol(symbol *Symbol) {
+	symbol.Value1 = varnam.normalizeSymbolValue(symbol.Value1)
+	symbol.Value2 = varnam.normalizeSymbolValue(symbol.Value2)
+	symbol.Value3 = varnam.normalizeSymbolValue(symbol.Value3)
+}
+
 func getSymbolValue(symbol Symbol, position int) string {
 	// Ignore render_value2 tag. It's only applicable for libvarnam
 	// https://gitlab.com/subins2000/govarnam/-/issues/3
@@ -471,14 +558,16 @@ func getSymbolValue(symbol Symbol, position int) string {
 }
 
 func getSymbolWeight(symbol Symbol) int {
+	weight := symbol.Weight
 	if symbol.MatchType == VARNAM_MATCH_EXACT {
 		// 200 because there might be possibility matches having weight 100
-		return 200
+		weight = 200
 	}
-	return symbol.Weight
+	return weight + symbol.preference*VARNAM_SYMBOL_PREFERENCE_WEIGHT
 }
 
-// Removes less weighted symbols
+// Removes less weighted symbols. Symbols the user chose have
+// weight & are placed first already, so they're kept.
 func removeLessWeightedSymbols(tokens []Token) []Token {
 	for i := range tokens {
 		var reducedSymbols []Symbol
@@ -503,19 +592,21 @@ func removeNonExactTokens(tokens []Token) []Token {
 	for i, token := range tokens {
 		if token.tokenType == VARNAM_TOKEN_SYMBOL {
 			var reducedSymbols []Symbol
+			hasExact := false
 			for _, symbol := range token.symbols {
 				if symbol.MatchType == VARNAM_MATCH_EXACT {
 					reducedSymbols = append(reducedSymbols, symbol)
-				} else {
-					if len(reducedSymbols) == 0 {
-						// No exact matches, so add the first possibility match
-						reducedSymbols = append(reducedSymbols, symbol)
-					}
-					// If a possibility result, then rest of them will also be same
-					// so save time by skipping rest
-					break
+					hasExact = true
+				} else if !hasExact && symbol.preference > 0 {
+					// User chose it over the exact matches after it.
+					// Exact matches are kept still.
+					reducedSymbols = append(reducedSymbols, symbol)
 				}
 			}
+			if len(reducedSymbols) == 0 && len(token.symbols) > 0 {
+				// No exact matches, so add the first possibility match
+				reducedSymbols = append(reducedSymbols, token.symbols[0])
+			}
 			tokens[i].symbols = reducedSymbols
 		}
 	}
@@ -587,9 +678,7 @@ func (varnam *Varnam) makeSearchSymbolQuery(queryPrefix string, searchCriteria S
 		query += " WHERE " + strings.Join(clauses, " AND ")
 	}
 
-	if varnam.Debug {
-		fmt.Println(query, values)
-	}
+	varnam.debug("Symbol table query", "query", query, "values", values)
 
 	return query, values
 }
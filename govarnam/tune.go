@@ -0,0 +1,380 @@
+package govarnam
+
+/**
+ * govarnam - An Indian language transliteration library
+ * Copyright Subin Siby <mail at subinsb (.) com>, 2021
+ * Licensed under AGPL-3.0-only. See LICENSE.txt
+ */
+
+import (
+	"context"
+	"fmt"
+	"sort"
+)
+
+// Weights tried for a symbol while tuning. 0 isn't
+// tried as that would drop symbols from suggestions.
+var tuningWeights = []int{10, 25, 50, 75, 100, 150, 200, 250, 300}
+
+// Times to go through all symbols while tuning
+const tuningRounds = 5
+
+// TuningCase a pattern & the word expected for it
+type TuningCase struct {
+	Pattern string
+	Word    string
+}
+
+// TunedSymbol a symbol of VST with the weight &
+// priority tuning found better for it
+type TunedSymbol struct {
+	Symbol   Symbol
+	Weight   int
+	Priority int
+}
+
+// TuningReport result of TuneSymbolWeights()
+type TuningReport struct {
+	Cases int
+
+	// Cases where the expected word was the
+	// first tokenizer suggestion
+	Top1Before int
+	Top1After  int
+
+	Tuned []TunedSymbol
+}
+
+// AccuracyBefore share of cases right before tuning
+func (report TuningReport) AccuracyBefore() float64 {
+	if report.Cases == 0 {
+		return 0
+	}
+	return float64(report.Top1Before) / float64(report.Cases)
+}
+
+// AccuracyAfter share of cases right after tuning
+func (report TuningReport) AccuracyAfter() float64 {
+	if report.Cases == 0 {
+		return 0
+	}
+	return float64(report.Top1After) / float64(report.Cases)
+}
+
+type symbolTuning struct {
+	weight   int
+	priority int
+}
+
+// How good weights are for some cases
+type tuningScore struct {
+	top1            int
+	reciprocalRanks float64
+}
+
+// Words becoming first matter most. Otherwise words moving
+// up helps when a word needs more than one symbol changed.
+func (score tuningScore) better(other tuningScore) bool {
+	if score.top1 != other.top1 {
+		return score.top1 > other.top1
+	}
+	return score.reciprocalRanks > other.reciprocalRanks+1e-9
+}
+
+type tuningCase struct {
+	tokens []Token
+	word   string
+}
+
+// GetTrainedPatterns patterns in learnings & the words they
+// were trained for, see Train(). Generated ones are left out.
+func (varnam *Varnam) GetTrainedPatterns(ctx context.Context) ([]TuningCase, error) {
+	var cases []TuningCase
+
+	rows, err := varnam.dictConn.QueryContext(ctx, "SELECT p.pattern, w.word FROM patterns p JOIN words w ON w.id = p.word_id WHERE p.generated = 0 ORDER BY w.id, p.pattern")
+	if err != nil {
+		return cases, dictError(err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var tuningCase TuningCase
+		rows.Scan(&tuningCase.Pattern, &tuningCase.Word)
+		cases = append(cases, tuningCase)
+	}
+
+	if err := rows.Err(); err != nil {
+		return cases, dictError(err)
+	}
+
+	return cases, nil
+}
+
+// TuneSymbolWeights search weights & priorities of symbols
+// which make the expected word the first tokenizer suggestion
+// in more cases. Symbols are tried one by one with each of a
+// few weights, a change is kept only if more cases get right
+// or expected words move up. VST isn't changed, see WriteTunedVST().
+// Only possibility matches are tuned. Exact matches weigh the same
+// whatever their weight is (see getSymbolWeight()), possibility
+// matches are tuned to get ahead of them or not.
+func (varnam *Varnam) TuneSymbolWeights(ctx context.Context, cases []TuningCase) (TuningReport, error) {
+	var report TuningReport
+
+	ctx = varnam.withOptions(ctx)
+	ctx = withLookupErrors(ctx)
+
+	limit := varnam.options(ctx).TokenizerSuggestionsLimit
+
+	var (
+		tuningCases []tuningCase
+
+		// Symbols in cases & cases the ones that can be tuned are in
+		symbols     = map[int]Symbol{}
+		symbolCases = map[int][]int{}
+
+		// Symbols each symbol has to compete with
+		rivals = map[int]map[int]bool{}
+	)
+
+	for _, c := range cases {
+		// Without cache & user's preferences, only VST
+		tokens := *varnam.tokenizeRunes(ctx, []rune(c.Pattern), nil, VARNAM_MATCH_ALL, false)
+
+		if err := getLookupError(ctx); err != nil {
+			return report, err
+		}
+		if ctx.Err() != nil {
+			return report, ctx.Err()
+		}
+
+		caseIndex := len(tuningCases)
+		tuningCases = append(tuningCases, tuningCase{tokens, varnam.normalizeText(c.Word)})
+
+		seen := map[int]bool{}
+		for _, token := range tokens {
+			if token.tokenType != VARNAM_TOKEN_SYMBOL || len(token.symbols) < 2 {
+				continue
+			}
+
+			for _, symbol := range token.symbols {
+				if symbol.Identifier == 0 {
+					continue
+				}
+				symbols[symbol.Identifier] = symbol
+
+				if symbol.MatchType == VARNAM_MATCH_EXACT {
+					continue
+				}
+
+				if rivals[symbol.Identifier] == nil {
+					rivals[symbol.Identifier] = map[int]bool{}
+				}
+				for _, rival := range token.symbols {
+					if rival.Identifier != symbol.Identifier && rival.Identifier != 0 {
+						rivals[symbol.Identifier][rival.Identifier] = true
+					}
+				}
+
+				if !seen[symbol.Identifier] {
+					seen[symbol.Identifier] = true
+					symbolCases[symbol.Identifier] = append(symbolCases[symbol.Identifier], caseIndex)
+				}
+			}
+		}
+	}
+
+	tunings := map[int]symbolTuning{}
+	for id, symbol := range symbols {
+		tunings[id] = symbolTuning{symbol.Weight, symbol.Priority}
+	}
+
+	for _, c := range tuningCases {
+		if varnam.suggestionRank(c, tunings, limit) == 1 {
+			report.Top1Before++
+		}
+	}
+
+	// Symbols that can be tuned
+	var ids []int
+	for id := range symbolCases {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+
+	for round := 0; round < tuningRounds; round++ {
+		changed := false
+
+		for _, id := range ids {
+			if ctx.Err() != nil {
+				return report, ctx.Err()
+			}
+
+			current := tunings[id]
+
+			score := func() tuningScore {
+				var score tuningScore
+				for _, caseIndex := range symbolCases[id] {
+					rank := varnam.suggestionRank(tuningCases[caseIndex], tunings, limit)
+					if rank == 1 {
+						score.top1++
+					}
+					if rank > 0 {
+						score.reciprocalRanks += 1 / float64(rank)
+					}
+				}
+				return score
+			}
+
+			best := current
+			bestScore := score()
+
+			for _, candidate := range tuningCandidates(current, rivals[id], tunings) {
+				tunings[id] = candidate
+				if candidateScore := score(); candidateScore.better(bestScore) {
+					best = candidate
+					bestScore = candidateScore
+				}
+			}
+
+			tunings[id] = best
+			if best != current {
+				changed = true
+			}
+		}
+
+		if !changed {
+			break
+		}
+	}
+
+	report.Cases = len(tuningCases)
+	for _, c := range tuningCases {
+		if varnam.suggestionRank(c, tunings, limit) == 1 {
+			report.Top1After++
+		}
+	}
+
+	for _, id := range ids {
+		symbol := symbols[id]
+		tuning := tunings[id]
+
+		if tuning.weight != symbol.Weight || tuning.priority != symbol.Priority {
+			report.Tuned = append(report.Tuned, TunedSymbol{symbol, tuning.weight, tuning.priority})
+		}
+	}
+
+	return report, nil
+}
+
+// Weights & priorities to try for a symbol, the ones
+// closest to current first so that changes are small
+func tuningCandidates(current symbolTuning, rivals map[int]bool, tunings map[int]symbolTuning) []symbolTuning {
+	priorities := map[int]bool{current.priority: true}
+	for rival := range rivals {
+		priorities[tunings[rival].priority-1] = true
+		priorities[tunings[rival].priority+1] = true
+	}
+
+	var candidates []symbolTuning
+	for _, weight := range tuningWeights {
+		for priority := range priorities {
+			candidate := symbolTuning{weight, priority}
+			if candidate != current {
+				candidates = append(candidates, candidate)
+			}
+		}
+	}
+
+	distance := func(candidate symbolTuning) int {
+		return abs(candidate.weight-current.weight) + abs(candidate.priority-current.priority)
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		if distance(candidates[i]) != distance(candidates[j]) {
+			return distance(candidates[i]) < distance(candidates[j])
+		}
+		if candidates[i].weight != candidates[j].weight {
+			return candidates[i].weight < candidates[j].weight
+		}
+		return candidates[i].priority < candidates[j].priority
+	})
+
+	return candidates
+}
+
+// Position of the expected word in tokenizer suggestions
+// if symbols had weights of tunings, starting from 1.
+// 0 if it's not in the first limit suggestions.
+func (varnam *Varnam) suggestionRank(c tuningCase, tunings map[int]symbolTuning, limit int) int {
+	tokens := make([]Token, len(c.tokens))
+
+	for i, token := range c.tokens {
+		tokens[i] = token
+
+		if len(token.symbols) < 2 {
+			continue
+		}
+
+		symbols := make([]Symbol, len(token.symbols))
+		copy(symbols, token.symbols)
+
+		for j := range symbols {
+			if tuning, ok := tunings[symbols[j].Identifier]; ok {
+				symbols[j].Weight = tuning.weight
+				symbols[j].Priority = tuning.priority
+			}
+		}
+
+		// Same order as VST gives
+		sort.SliceStable(symbols, func(a, b int) bool {
+			if symbols[a].MatchType != symbols[b].MatchType {
+				return symbols[a].MatchType < symbols[b].MatchType
+			}
+			if symbols[a].Weight != symbols[b].Weight {
+				return symbols[a].Weight > symbols[b].Weight
+			}
+			return symbols[a].Priority > symbols[b].Priority
+		})
+
+		tokens[i].symbols = symbols
+	}
+
+	sugs := SortSuggestions(varnam.newTokenEnumerator(tokens, false).next(limit))
+
+	for i, sug := range sugs {
+		if sug.Word == c.word {
+			return i + 1
+		}
+	}
+
+	return 0
+}
+
+// WriteTunedVST copy VST to vstPath with weights & priorities
+// of tuned symbols changed. vstPath shouldn't exist already.
+func (varnam *Varnam) WriteTunedVST(tuned []TunedSymbol, vstPath string) error {
+	if fileExists(vstPath) {
+		return &Error{Code: VARNAM_MISUSE, Message: fmt.Sprintf("%s already exists", vstPath)}
+	}
+
+	_, err := varnam.vstConn.Exec("VACUUM INTO ?", vstPath)
+	if err != nil {
+		return vstError(err)
+	}
+
+	vm, err := VMInit(vstPath)
+	if err != nil {
+		return err
+	}
+	defer vm.Close()
+
+	for _, tunedSymbol := range tuned {
+		err = vm.VMSetSymbolWeight(tunedSymbol.Symbol.Identifier, tunedSymbol.Weight, tunedSymbol.Priority, true)
+		if err != nil {
+			return err
+		}
+	}
+
+	return vm.VMFlushBuffer()
+}
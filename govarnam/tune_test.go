@@ -0,0 +1,89 @@
+package govarnam
+
+import (
+	"context"
+	"path"
+	"testing"
+)
+
+func TestTuneSymbolWeights(t *testing.T) {
+	varnam := makeTestVarnam("tune", kalaTestSymbols, 50)
+	defer varnam.Close()
+
+	ctx := context.Background()
+
+	checkError(varnam.Train("kala", "കള"))
+
+	cases, err := varnam.GetTrainedPatterns(ctx)
+	checkError(err)
+	assertEqual(t, len(cases), 1)
+	assertEqual(t, cases[0], TuningCase{"kala", "കള"})
+
+	cases = append(cases, TuningCase{"kalala", "കളള"}, TuningCase{"lakala", "ലകല"})
+
+	report, err := varnam.TuneSymbolWeights(ctx, cases)
+	checkError(err)
+
+	assertEqual(t, report.Cases, 3)
+	assertEqual(t, report.Top1Before, 1)
+	assertEqual(t, report.Top1After, 2)
+
+	// Weight of a word is in hundreds, ള needs 300 to
+	// beat the exact match. ലകല goes wrong, but two get right.
+	assertEqual(t, len(report.Tuned), 1)
+	assertEqual(t, report.Tuned[0].Symbol.Value1, "ള")
+	assertEqual(t, report.Tuned[0].Weight, 300)
+
+	tunedPath := path.Join(testTempDir, "tune-tuned.vst")
+	checkError(varnam.WriteTunedVST(report.Tuned, tunedPath))
+
+	// Won't overwrite
+	assertEqual(t, varnam.WriteTunedVST(report.Tuned, tunedPath) != nil, true)
+
+	tuned, err := Init(tunedPath, path.Join(testTempDir, "tune-tuned.learnings"))
+	checkError(err)
+	defer tuned.Close()
+
+	result, err := tuned.TransliterateWithOptions(ctx, "kala", tuned.GetOptions())
+	checkError(err)
+	assertEqual(t, result.TokenizerSuggestions[0].Word, "കള")
+
+	// Original VST is as it was
+	result, err = varnam.TransliterateWithOptions(ctx, "kala", varnam.GetOptions())
+	checkError(err)
+	assertEqual(t, result.TokenizerSuggestions[0].Word, "കല")
+}
+
+func TestTuneSymbolWeightsDown(t *testing.T) {
+	varnam := makeTestVarnam("tune-down", kalaTestSymbols, 300)
+	defer varnam.Close()
+
+	ctx := context.Background()
+
+	report, err := varnam.TuneSymbolWeights(ctx, []TuningCase{{"lakala", "ലകല"}})
+	checkError(err)
+	assertEqual(t, report.Top1Before, 0)
+	assertEqual(t, report.Top1After, 1)
+
+	// Exact match weighs 200 whatever its weight is, so only
+	// the possibility match is tuned. It's placed after the
+	// exact match when both weigh the same.
+	assertEqual(t, len(report.Tuned), 1)
+	assertEqual(t, report.Tuned[0].Symbol.Value1, "ള")
+	assertEqual(t, report.Tuned[0].Weight, 200)
+
+	tunedPath := path.Join(testTempDir, "tune-down-tuned.vst")
+	checkError(varnam.WriteTunedVST(report.Tuned, tunedPath))
+
+	tuned, err := Init(tunedPath, path.Join(testTempDir, "tune-down-tuned.learnings"))
+	checkError(err)
+	defer tuned.Close()
+
+	result, err := varnam.TransliterateWithOptions(ctx, "lakala", varnam.GetOptions())
+	checkError(err)
+	assertEqual(t, result.TokenizerSuggestions[0].Word, "ളകള")
+
+	result, err = tuned.TransliterateWithOptions(ctx, "lakala", tuned.GetOptions())
+	checkError(err)
+	assertEqual(t, result.TokenizerSuggestions[0].Word, "ലകല")
+}
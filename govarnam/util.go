@@ -44,3 +44,10 @@ func dirExists(loc string) bool {
 	}
 	return info.IsDir()
 }
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
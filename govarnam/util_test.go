@@ -0,0 +1,68 @@
+package govarnam
+
+import (
+	"path"
+)
+
+// Consonants, each typed as a single pattern
+var simpleTestSymbols = []Symbol{
+	{Pattern: "ka", Value1: "ക"},
+	{Pattern: "ma", Value1: "മ"},
+	{Pattern: "la", Value1: "ല"},
+	{Pattern: "pa", Value1: "പ"},
+}
+
+// "la" can be ല or ള
+var kalaTestSymbols = []Symbol{
+	{Pattern: "ka", Value1: "ക"},
+	{Pattern: "la", Value1: "ല"},
+	{Pattern: "la", Value1: "ള", MatchType: VARNAM_MATCH_POSSIBILITY},
+}
+
+// Every pattern of "kalama" has 2 values, 8 words in total
+var kalamaTestSymbols = []Symbol{
+	{Pattern: "ka", Value1: "ക"},
+	{Pattern: "ka", Value1: "ക്ക", MatchType: VARNAM_MATCH_POSSIBILITY},
+	{Pattern: "la", Value1: "ല"},
+	{Pattern: "la", Value1: "ള", MatchType: VARNAM_MATCH_POSSIBILITY},
+	{Pattern: "ma", Value1: "മ"},
+	{Pattern: "ma", Value1: "മ്മ", MatchType: VARNAM_MATCH_POSSIBILITY},
+}
+
+// Make a VST with symbols & learnings for it, both named
+// after name. Symbols are consonants of exact match unless
+// said otherwise. A weight of 0 leaves weights unset.
+func makeTestVarnam(name string, symbols []Symbol, weight int) *Varnam {
+	vstPath := path.Join(testTempDir, name+".vst")
+
+	vm, err := VMInit(vstPath)
+	checkError(err)
+	for _, symbol := range symbols {
+		if symbol.Type == 0 {
+			symbol.Type = VARNAM_SYMBOL_CONSONANT
+		}
+		if symbol.MatchType == 0 {
+			symbol.MatchType = VARNAM_MATCH_EXACT
+		}
+		checkError(vm.VMCreateToken(symbol.Pattern, symbol.Value1, symbol.Value2, "", "", symbol.Type, symbol.MatchType, 0, symbol.AcceptCondition, false))
+	}
+	if weight != 0 {
+		_, err = vm.vstConn.Exec("UPDATE symbols SET weight = ?", weight)
+		checkError(err)
+	}
+	vm.Close()
+
+	varnam, err := Init(vstPath, path.Join(testTempDir, name+".learnings"))
+	checkError(err)
+
+	return varnam
+}
+
+func hasSuggestion(sugs []Suggestion, word string) bool {
+	for _, sug := range sugs {
+		if sug.Word == word {
+			return true
+		}
+	}
+	return false
+}
@@ -100,7 +100,7 @@ func (varnam *Varnam) vmFlushChanges() error {
 		return nil
 	}
 
-	varnam.log("Writing changes to file...")
+	varnam.debug("Writing changes to file...")
 	_, err := varnam.vstConn.Exec("COMMIT;")
 	if err != nil {
 		return fmt.Errorf("failed to flush changes: " + err.Error())
@@ -108,7 +108,7 @@ func (varnam *Varnam) vmFlushChanges() error {
 
 	varnam.VSTMakerConfig.Buffering = false
 
-	varnam.log("Compacting file...")
+	varnam.debug("Compacting file...")
 	_, err = varnam.vstConn.Exec("VACUUM")
 	if err != nil {
 		return fmt.Errorf("failed to compact db: " + err.Error())
@@ -232,7 +232,7 @@ func (varnam *Varnam) vmPersistToken(pattern string, value1 string, value2 strin
 
 	if persisted {
 		if varnam.VSTMakerConfig.IgnoreDuplicateTokens {
-			varnam.log(fmt.Sprintf("%s => %s is already available. Ignoring duplicate tokens", pattern, value1))
+			varnam.getLogger().Warn("Token is already available. Ignoring duplicate", "pattern", pattern, "value1", value1)
 			return nil
 		}
 
@@ -290,6 +290,31 @@ func (varnam *Varnam) VMDeleteToken(searchCriteria Symbol) error {
 	return nil
 }
 
+// VMSetSymbolWeight change weight & priority of a symbol
+func (varnam *Varnam) VMSetSymbolWeight(identifier int, weight int, priority int, buffered bool) error {
+	if buffered {
+		varnam.vmStartBuffering()
+	}
+
+	result, err := varnam.vstConn.Exec("UPDATE symbols SET weight = ?, priority = ? WHERE id = ?", weight, priority, identifier)
+	if err == nil {
+		var affected int64
+		affected, err = result.RowsAffected()
+		if err == nil && affected == 0 {
+			err = fmt.Errorf("no symbol with id %d", identifier)
+		}
+	}
+
+	if err != nil {
+		if buffered {
+			varnam.vmDiscardChanges()
+		}
+		return err
+	}
+
+	return nil
+}
+
 // Makes a prefix tree. This fills up the flags column
 // TODO incomplete
 func (varnam *Varnam) vmMakePrefixTree() error {
@@ -297,7 +322,7 @@ func (varnam *Varnam) vmMakePrefixTree() error {
 		stmt, err := varnam.vstConn.Prepare(fmt.Sprintf("SELECT id, %s FROM symbols GROUP BY %s ORDER BY LENGTH(%s) ASC", columnName, columnName, columnName))
 
 		if err != nil {
-			varnam.log(err.Error())
+			varnam.getLogger().Error("Making prefix tree failed", "error", err)
 			return nil
 		}
 
@@ -310,7 +335,7 @@ func (varnam *Varnam) vmMakePrefixTree() error {
 
 		updateStmt, err := varnam.vstConn.Prepare(fmt.Sprintf("UPDATE symbols SET flags = flags | %d WHERE %s = ?", mask, columnName))
 		if err != nil {
-			varnam.log(err.Error())
+			varnam.getLogger().Error("Making prefix tree failed", "error", err)
 		}
 
 		varnam.vmFindPrefixesAndUpdateFlags(stmt, updateStmt)
@@ -370,7 +395,7 @@ func (varnam *Varnam) VMSetSchemeDetails(sd SchemeDetails) error {
 		if err != nil {
 			return err
 		}
-		varnam.log("Set " + o.name + " to: " + string(o.value))
+		varnam.debug("Set scheme detail", o.name, o.value)
 	}
 
 	return nil
@@ -0,0 +1,69 @@
+package govarnamgo
+
+/**
+ * govarnam - An Indian language transliteration library
+ * Copyright Subin Siby <mail at subinsb (.) com>, 2021
+ * Licensed under AGPL-3.0-only. See LICENSE.txt
+ */
+
+// #cgo pkg-config: govarnam
+// #include "libgovarnam.h"
+// #include "stdlib.h"
+import "C"
+
+import (
+	"context"
+	"unsafe"
+)
+
+// BlockWord never suggest word & refuse to learn it
+func (handle *VarnamHandle) BlockWord(word string) error {
+	cWord := C.CString(word)
+	defer C.free(unsafe.Pointer(cWord))
+
+	return handle.checkError(C.varnam_block_word(handle.connectionID, cWord))
+}
+
+// UnblockWord undo BlockWord()
+func (handle *VarnamHandle) UnblockWord(word string) error {
+	cWord := C.CString(word)
+	defer C.free(unsafe.Pointer(cWord))
+
+	return handle.checkError(C.varnam_unblock_word(handle.connectionID, cWord))
+}
+
+// LoadBlocklist block words in a file with a word per line.
+// They're not saved, load the file every time.
+func (handle *VarnamHandle) LoadBlocklist(filePath string) error {
+	cFilePath := C.CString(filePath)
+	defer C.free(unsafe.Pointer(cFilePath))
+
+	return handle.checkError(C.varnam_load_blocklist(handle.connectionID, cFilePath))
+}
+
+// GetBlockedWords words blocked with BlockWord()
+func (handle *VarnamHandle) GetBlockedWords(ctx context.Context) ([]string, error) {
+	var result []string
+
+	operationID := makeContextOperation()
+
+	select {
+	case <-ctx.Done():
+		C.varnam_cancel(operationID)
+		return result, ctx.Err()
+	default:
+		var resultPointer *C.varray
+
+		code := C.varnam_get_blocked_words(handle.connectionID, operationID, &resultPointer)
+		if code != C.VARNAM_SUCCESS {
+			return result, handle.checkError(code)
+		}
+		defer C.destroyStringsArray(resultPointer)
+
+		for i := 0; i < int(C.varray_length(resultPointer)); i++ {
+			result = append(result, C.GoString((*C.char)(C.varray_get(resultPointer, C.int(i)))))
+		}
+
+		return result, nil
+	}
+}
@@ -0,0 +1,96 @@
+package govarnamgo
+
+/**
+ * govarnam - An Indian language transliteration library
+ * Copyright Subin Siby <mail at subinsb (.) com>, 2021
+ * Licensed under AGPL-3.0-only. See LICENSE.txt
+ */
+
+// #cgo pkg-config: govarnam
+// #include "libgovarnam.h"
+// #include "stdlib.h"
+import "C"
+
+import (
+	"context"
+	"unsafe"
+)
+
+// CorpusOptions filters for IngestCorpus()
+type CorpusOptions struct {
+	// Words seen fewer times are left out
+	MinCount int
+
+	// Words with more characters are left out. 0 for no limit.
+	MaxLength int
+
+	// Distinct words counted in memory at most, 0 for default
+	MaxWords int
+}
+
+// CorpusStatus result of IngestCorpus()
+type CorpusStatus struct {
+	Files int
+
+	// Words read, including ones filtered out
+	Words int
+
+	// Distinct words counted
+	Distinct int
+
+	// Counts of words, so their weights, are lower than the
+	// actual by at most this. 0 if MaxWords wasn't reached.
+	MaxUndercount int
+
+	// Of words that passed the filters
+	LearnStatus LearnStatus
+}
+
+// IngestCorpus learn words of the language in plain text or
+// gzip (.gz) files. Files in directories are read too. Words
+// are learnt with the times they're seen as weight.
+func (handle *VarnamHandle) IngestCorpus(ctx context.Context, paths []string, opts CorpusOptions) (CorpusStatus, error) {
+	var status CorpusStatus
+
+	cPaths := C.varray_init()
+	defer C.destroyStringsArray(cPaths)
+
+	for _, path := range paths {
+		C.varray_push(cPaths, unsafe.Pointer(C.CString(path)))
+	}
+
+	type cResult struct {
+		code   C.int
+		status C.CorpusStatus
+	}
+
+	operationID := makeContextOperation()
+	// Buffered so that the C call can finish even if we stop waiting
+	channel := make(chan cResult, 1)
+
+	go func() {
+		var result cResult
+		result.code = C.varnam_ingest_corpus(handle.connectionID, operationID, cPaths, C.int(opts.MinCount), C.int(opts.MaxLength), C.int(opts.MaxWords), &result.status)
+		channel <- result
+	}()
+
+	select {
+	case <-ctx.Done():
+		C.varnam_cancel(operationID)
+
+		// Paths can't be freed till the call returns
+		<-channel
+		return status, ctx.Err()
+	case result := <-channel:
+		status.Files = int(result.status.Files)
+		status.Words = int(result.status.Words)
+		status.Distinct = int(result.status.Distinct)
+		status.MaxUndercount = int(result.status.MaxUndercount)
+		status.LearnStatus = LearnStatus{
+			int(result.status.TotalWords),
+			int(result.status.FailedWords),
+		}
+
+		return status, handle.checkError(result.code)
+	}
+}
@@ -0,0 +1,75 @@
+package govarnamgo
+
+/**
+ * govarnam - An Indian language transliteration library
+ * Copyright Subin Siby <mail at subinsb (.) com>, 2021
+ * Licensed under AGPL-3.0-only. See LICENSE.txt
+ */
+
+// #cgo pkg-config: govarnam
+// #include "libgovarnam.h"
+// #include "stdlib.h"
+import "C"
+
+import (
+	"context"
+	"unsafe"
+)
+
+// TokenizerCursor where making tokenizer suggestions of
+// an input stopped. See NextTokenizerSuggestions()
+type TokenizerCursor struct {
+	handle   *VarnamHandle
+	cursorID C.int
+}
+
+// NewTokenizerCursor start making tokenizer suggestions of word.
+// The first TokenizerSuggestionsLimit of them are the ones
+// in TransliterationResult.TokenizerSuggestions.
+func (handle *VarnamHandle) NewTokenizerCursor(ctx context.Context, word string) (*TokenizerCursor, error) {
+	operationID := makeContextOperation()
+
+	select {
+	case <-ctx.Done():
+		C.varnam_cancel(operationID)
+		return nil, ctx.Err()
+	default:
+		cWord := C.CString(word)
+		defer C.free(unsafe.Pointer(cWord))
+
+		var cursorID C.int
+
+		err := handle.checkError(C.varnam_tokenizer_cursor_new(handle.connectionID, operationID, cWord, &cursorID))
+		if err != nil {
+			return nil, err
+		}
+
+		return &TokenizerCursor{handle, cursorID}, nil
+	}
+}
+
+// NextTokenizerSuggestions get upto n suggestions after the
+// ones cursor gave before. Less than n when there are no more.
+func (handle *VarnamHandle) NextTokenizerSuggestions(cursor *TokenizerCursor, n int) ([]Suggestion, error) {
+	var result []Suggestion
+
+	var resultPointer *C.varray
+
+	err := handle.checkError(C.varnam_next_tokenizer_suggestions(cursor.cursorID, C.int(n), &resultPointer))
+	if err != nil {
+		return result, err
+	}
+	defer C.destroySuggestionsArray(resultPointer)
+
+	for i := 0; i < int(C.varray_length(resultPointer)); i++ {
+		cSug := (*C.Suggestion)(C.varray_get(resultPointer, C.int(i)))
+		result = append(result, makeSuggestion(cSug))
+	}
+
+	return result, nil
+}
+
+// Close free the cursor
+func (cursor *TokenizerCursor) Close() error {
+	return cursor.handle.checkError(C.varnam_tokenizer_cursor_close(cursor.cursorID))
+}
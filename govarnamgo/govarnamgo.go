@@ -15,11 +15,18 @@ import "C"
 
 import (
 	"context"
-	"fmt"
-	"log"
+	"time"
 	"unsafe"
 )
 
+// Stages of transliteration, see TransliterateWithDeadline
+const (
+	VARNAM_STAGE_GREEDY_TOKENIZED   = C.VARNAM_STAGE_GREEDY_TOKENIZED
+	VARNAM_STAGE_DICTIONARY         = C.VARNAM_STAGE_DICTIONARY
+	VARNAM_STAGE_PATTERN_DICTIONARY = C.VARNAM_STAGE_PATTERN_DICTIONARY
+	VARNAM_STAGE_TOKENIZER          = C.VARNAM_STAGE_TOKENIZER
+)
+
 // Config  config values
 type Config struct {
 	IndicDigits                       bool
@@ -30,16 +37,55 @@ type Config struct {
 	TokenizerSuggestionsAlways        bool
 }
 
+// VARNAM_CACHE_SIZE default size of transliteration cache
+const VARNAM_CACHE_SIZE = C.VARNAM_CACHE_SIZE
+
+// CacheStats counters of the transliteration cache
+type CacheStats struct {
+	ResultHits   int
+	ResultMisses int
+	TokenHits    int
+	TokenMisses  int
+
+	// Results removed because learnings changed
+	Invalidations int
+
+	// Entries in the cache now
+	Results int
+	Tokens  int
+}
+
 // VarnamHandle for making things easier
 type VarnamHandle struct {
 	connectionID C.int
 }
 
+// Source of a suggestion
+const (
+	VARNAM_SOURCE_EXACT_WORD         = C.VARNAM_SOURCE_EXACT_WORD
+	VARNAM_SOURCE_EXACT_MATCH        = C.VARNAM_SOURCE_EXACT_MATCH
+	VARNAM_SOURCE_DICTIONARY         = C.VARNAM_SOURCE_DICTIONARY
+	VARNAM_SOURCE_PATTERN_DICTIONARY = C.VARNAM_SOURCE_PATTERN_DICTIONARY
+	VARNAM_SOURCE_TOKENIZER          = C.VARNAM_SOURCE_TOKENIZER
+	VARNAM_SOURCE_GREEDY_TOKENIZED   = C.VARNAM_SOURCE_GREEDY_TOKENIZED
+	VARNAM_SOURCE_SHORTCUT           = C.VARNAM_SOURCE_SHORTCUT
+)
+
 // Suggestion suggestion
 type Suggestion struct {
 	Word      string
 	Weight    int
 	LearnedOn int
+
+	// One of VARNAM_SOURCE_*, only set in transliteration results
+	Source int
+
+	// Count of input characters matched by the source
+	MatchedLength int
+
+	// Weight scaled to 0-1 relative to the highest
+	// weighted suggestion of the transliteration
+	Score float64
 }
 
 // TransliterationResult result
@@ -50,6 +96,12 @@ type TransliterationResult struct {
 	PatternDictionarySuggestions []Suggestion
 	TokenizerSuggestions         []Suggestion
 	GreedyTokenized              []Suggestion
+
+	// Bitmask of VARNAM_STAGE_* that didn't finish in time
+	MissingStages int
+
+	// Bitmask of VARNAM_STAGE_* not run because input is too long
+	SkippedStages int
 }
 
 // SchemeDetails of VST
@@ -99,6 +151,9 @@ func makeSuggestion(cSug *C.struct_Suggestion_t) Suggestion {
 	sug.Word = C.GoString(cSug.Word)
 	sug.Weight = int(cSug.Weight)
 	sug.LearnedOn = int(cSug.LearnedOn)
+	sug.Source = int(cSug.Source)
+	sug.MatchedLength = int(cSug.MatchedLength)
+	sug.Score = float64(cSug.Score)
 
 	return sug
 }
@@ -108,6 +163,7 @@ func makeGoTransliterationResult(ctx context.Context, cResult *C.struct_Translit
 
 	select {
 	case <-ctx.Done():
+		go C.destroyTransliterationResult(cResult)
 		return result
 	default:
 		var i int
@@ -189,6 +245,28 @@ func (err VarnamError) Error() string {
 	return err.Message
 }
 
+// Is errors of same code are the same kind of error
+func (err VarnamError) Is(target error) bool {
+	switch targetErr := target.(type) {
+	case *VarnamError:
+		return targetErr.ErrorCode == err.ErrorCode
+	case VarnamError:
+		return targetErr.ErrorCode == err.ErrorCode
+	}
+	return false
+}
+
+// Kinds of errors, check with errors.Is(err, ErrWordNotFound)
+var (
+	ErrCancelled         = &VarnamError{ErrorCode: C.VARNAM_CANCELLED, Message: "Cancelled"}
+	ErrWordNotFound      = &VarnamError{ErrorCode: C.VARNAM_WORD_NOT_FOUND, Message: "Word doesn't exist"}
+	ErrSingleConjunct    = &VarnamError{ErrorCode: C.VARNAM_SINGLE_CONJUNCT, Message: "Can't learn a single conjunct"}
+	ErrNothingToLearn    = &VarnamError{ErrorCode: C.VARNAM_NOTHING_TO_LEARN, Message: "Nothing to learn"}
+	ErrVSTSchemaMismatch = &VarnamError{ErrorCode: C.VARNAM_VST_SCHEMA_MISMATCH, Message: "VST is corrupt or of a different schema"}
+	ErrDBLocked          = &VarnamError{ErrorCode: C.VARNAM_DB_LOCKED, Message: "Database is locked by another process"}
+	ErrWordBlocked       = &VarnamError{ErrorCode: C.VARNAM_WORD_BLOCKED, Message: "Word is blocked"}
+)
+
 func (handle *VarnamHandle) checkError(code C.int) error {
 	if code == C.VARNAM_SUCCESS {
 		return nil
@@ -220,10 +298,11 @@ func Init(vstLoc string, dictLoc string) (*VarnamHandle, error) {
 	C.free(unsafe.Pointer(cVSTFile))
 	C.free(unsafe.Pointer(cDictLoc))
 
+	handle := &VarnamHandle{handleID}
 	if err != C.VARNAM_SUCCESS {
-		return nil, fmt.Errorf(C.GoString(C.varnam_get_last_error(handleID)))
+		return nil, handle.checkError(err)
 	}
-	return &VarnamHandle{handleID}, nil
+	return handle, nil
 }
 
 // InitFromID Initialize
@@ -233,10 +312,11 @@ func InitFromID(id string) (*VarnamHandle, error) {
 	err := C.varnam_init_from_id(cID, unsafe.Pointer(&handleID))
 	C.free(unsafe.Pointer(cID))
 
+	handle := &VarnamHandle{handleID}
 	if err != C.VARNAM_SUCCESS {
-		return nil, fmt.Errorf(C.GoString(C.varnam_get_last_error(handleID)))
+		return nil, handle.checkError(err)
 	}
-	return &VarnamHandle{handleID}, nil
+	return handle, nil
 }
 
 // GetLastError get last error
@@ -250,6 +330,11 @@ func (handle *VarnamHandle) GetLastError() string {
 // Close db connections and end varnam
 func (handle *VarnamHandle) Close() error {
 	err := C.varnam_close(handle.connectionID)
+	if err == C.VARNAM_SUCCESS {
+		loggersMutex.Lock()
+		delete(loggers, handle.connectionID)
+		loggersMutex.Unlock()
+	}
 	return handle.checkError(err)
 }
 
@@ -262,6 +347,11 @@ func (handle *VarnamHandle) Debug(val bool) {
 	}
 }
 
+// DebugGoroutines number of goroutines running in the library
+func DebugGoroutines() int {
+	return int(C.varnam_debug_goroutines())
+}
+
 // SetConfig set config
 func (handle *VarnamHandle) SetConfig(config Config) {
 	C.varnam_set_dictionary_suggestions_limit(handle.connectionID, C.int(config.DictionarySuggestionsLimit))
@@ -283,6 +373,39 @@ func (handle *VarnamHandle) SetConfig(config Config) {
 	}
 }
 
+// SetCacheSize change how many transliteration
+// results are cached. 0 disables caching.
+func (handle *VarnamHandle) SetCacheSize(size int) {
+	C.varnam_config(handle.connectionID, C.VARNAM_CONFIG_SET_CACHE_SIZE, C.int(size))
+}
+
+// SetDictionaryIndex keep dictionary words in memory so
+// that lookups while transliterating don't query SQLite.
+// Faster with big dictionaries, at the cost of memory.
+func (handle *VarnamHandle) SetDictionaryIndex(enable bool) error {
+	value := C.int(0)
+	if enable {
+		value = C.int(1)
+	}
+	return handle.checkError(C.varnam_config(handle.connectionID, C.VARNAM_CONFIG_USE_DICTIONARY_INDEX, value))
+}
+
+// CacheStats hit/miss counts of transliteration cache
+func (handle *VarnamHandle) CacheStats() CacheStats {
+	var stats C.CacheStats
+	C.varnam_get_cache_stats(handle.connectionID, &stats)
+
+	return CacheStats{
+		ResultHits:    int(stats.ResultHits),
+		ResultMisses:  int(stats.ResultMisses),
+		TokenHits:     int(stats.TokenHits),
+		TokenMisses:   int(stats.TokenMisses),
+		Invalidations: int(stats.Invalidations),
+		Results:       int(stats.Results),
+		Tokens:        int(stats.Tokens),
+	}
+}
+
 type cgoVarnamTransliterateResult struct {
 	result *C.varray
 	err    error
@@ -304,7 +427,7 @@ func (handle *VarnamHandle) cgoVarnamTransliterate(operationID C.int, resultChan
 	} else {
 		resultChannel <- cgoVarnamTransliterateResult{
 			resultPointer,
-			fmt.Errorf(handle.GetLastError()),
+			handle.checkError(code),
 		}
 	}
 
@@ -316,13 +439,23 @@ func (handle *VarnamHandle) Transliterate(ctx context.Context, word string) ([]S
 	var result []Suggestion
 
 	operationID := makeContextOperation()
-	channel := make(chan cgoVarnamTransliterateResult)
+	// Buffered so that the C call can finish even if we stop waiting
+	channel := make(chan cgoVarnamTransliterateResult, 1)
 
 	go handle.cgoVarnamTransliterate(operationID, channel, word)
 
 	select {
 	case <-ctx.Done():
 		C.varnam_cancel(operationID)
+
+		// The C call may have finished already, free its result
+		go func() {
+			channelResult := <-channel
+			if channelResult.err == nil && channelResult.result != nil {
+				C.destroySuggestionsArray(channelResult.result)
+			}
+		}()
+
 		return result, nil
 	case channelResult := <-channel:
 		if channelResult.err != nil {
@@ -344,8 +477,10 @@ func (handle *VarnamHandle) Transliterate(ctx context.Context, word string) ([]S
 }
 
 type cgoVarnamTransliterateAdvancedResult struct {
-	result *C.struct_TransliterationResult_t
-	err    error
+	result        *C.struct_TransliterationResult_t
+	err           error
+	missingStages int
+	skippedStages int
 }
 
 func (handle *VarnamHandle) cgoVarnamTransliterateAdvanced(operationID C.int, resultChannel chan<- cgoVarnamTransliterateAdvancedResult, word string) {
@@ -359,11 +494,15 @@ func (handle *VarnamHandle) cgoVarnamTransliterateAdvanced(operationID C.int, re
 		resultChannel <- cgoVarnamTransliterateAdvancedResult{
 			resultPointer,
 			nil,
+			0,
+			0,
 		}
 	} else {
 		resultChannel <- cgoVarnamTransliterateAdvancedResult{
 			resultPointer,
-			fmt.Errorf(handle.GetLastError()),
+			handle.checkError(code),
+			0,
+			0,
 		}
 	}
 
@@ -375,13 +514,23 @@ func (handle *VarnamHandle) TransliterateAdvanced(ctx context.Context, word stri
 	var result TransliterationResult
 
 	operationID := makeContextOperation()
-	channel := make(chan cgoVarnamTransliterateAdvancedResult)
+	// Buffered so that the C call can finish even if we stop waiting
+	channel := make(chan cgoVarnamTransliterateAdvancedResult, 1)
 
 	go handle.cgoVarnamTransliterateAdvanced(operationID, channel, word)
 
 	select {
 	case <-ctx.Done():
 		C.varnam_cancel(operationID)
+
+		// The C call may have finished already, free its result
+		go func() {
+			channelResult := <-channel
+			if channelResult.err == nil && channelResult.result != nil {
+				C.destroyTransliterationResult(channelResult.result)
+			}
+		}()
+
 		return result, nil
 	case channelResult := <-channel:
 		if channelResult.err != nil {
@@ -392,6 +541,72 @@ func (handle *VarnamHandle) TransliterateAdvanced(ctx context.Context, word stri
 	}
 }
 
+func (handle *VarnamHandle) cgoVarnamTransliterateWithDeadline(operationID C.int, resultChannel chan<- cgoVarnamTransliterateAdvancedResult, word string, timeout time.Duration) {
+	cWord := C.CString(word)
+	defer C.free(unsafe.Pointer(cWord))
+
+	var (
+		resultPointer *C.struct_TransliterationResult_t
+		missingStages C.int
+		skippedStages C.int
+	)
+
+	code := C.varnam_transliterate_with_deadline(handle.connectionID, operationID, cWord, C.int(timeout.Milliseconds()), &resultPointer, &missingStages, &skippedStages)
+	if code == C.VARNAM_SUCCESS {
+		resultChannel <- cgoVarnamTransliterateAdvancedResult{
+			resultPointer,
+			nil,
+			int(missingStages),
+			int(skippedStages),
+		}
+	} else {
+		resultChannel <- cgoVarnamTransliterateAdvancedResult{
+			resultPointer,
+			handle.checkError(code),
+			0,
+			0,
+		}
+	}
+
+	close(resultChannel)
+}
+
+// TransliterateWithDeadline transliterate, but give whatever finished
+// by deadline instead of nothing. See result.MissingStages &
+// result.SkippedStages
+func (handle *VarnamHandle) TransliterateWithDeadline(ctx context.Context, word string, deadline time.Time) (TransliterationResult, error) {
+	var result TransliterationResult
+
+	operationID := makeContextOperation()
+	// Buffered so that the C call can finish even if we stop waiting
+	channel := make(chan cgoVarnamTransliterateAdvancedResult, 1)
+
+	go handle.cgoVarnamTransliterateWithDeadline(operationID, channel, word, time.Until(deadline))
+
+	select {
+	case <-ctx.Done():
+		C.varnam_cancel(operationID)
+
+		// The C call may have finished already, free its result
+		go func() {
+			channelResult := <-channel
+			if channelResult.err == nil && channelResult.result != nil {
+				C.destroyTransliterationResult(channelResult.result)
+			}
+		}()
+
+		return result, nil
+	case channelResult := <-channel:
+		if channelResult.err != nil {
+			return result, channelResult.err
+		}
+		result = makeGoTransliterationResult(ctx, channelResult.result)
+		result.MissingStages = channelResult.missingStages
+		result.SkippedStages = channelResult.skippedStages
+		return result, nil
+	}
+}
+
 // TransliterateGreedyTokenized transliterate but only tokenizer output
 func (handle *VarnamHandle) TransliterateGreedyTokenized(word string) []Suggestion {
 	var result []Suggestion
@@ -403,7 +618,9 @@ func (handle *VarnamHandle) TransliterateGreedyTokenized(word string) []Suggesti
 
 	code := C.varnam_transliterate_greedy_tokenized(handle.connectionID, cWord, &resultPointer)
 	if code != C.VARNAM_SUCCESS {
-		log.Print(handle.GetLastError())
+		if logger := handle.getLogger(); logger != nil {
+			logger.Error("Greedy tokenization failed", "error", handle.GetLastError())
+		}
 		return result
 	}
 
@@ -470,6 +687,32 @@ func (handle *VarnamHandle) Learn(word string, weight int) error {
 	return handle.checkError(err)
 }
 
+// AcceptSuggestion the user chose shown[chosenIndex] for input.
+// The word is learnt, and trained with input as pattern if the
+// tokenizer made it. Suggestions shown above it are ranked
+// lower for input after.
+func (handle *VarnamHandle) AcceptSuggestion(input string, shown []Suggestion, chosenIndex int) error {
+	cInput := C.CString(input)
+	defer C.free(unsafe.Pointer(cInput))
+
+	cShown := C.varray_init()
+	defer C.destroySuggestionsArray(cShown)
+
+	for _, sug := range shown {
+		cSug := C.makeSuggestion(
+			C.CString(sug.Word),
+			C.int(sug.Weight),
+			C.int(sug.LearnedOn),
+			C.int(sug.Source),
+			C.int(sug.MatchedLength),
+			C.double(sug.Score),
+		)
+		C.varray_push(cShown, unsafe.Pointer(cSug))
+	}
+
+	return handle.checkError(C.varnam_accept_suggestion(handle.connectionID, cInput, cShown, C.int(chosenIndex)))
+}
+
 // Unlearn a word
 func (handle *VarnamHandle) Unlearn(word string) error {
 	cWord := C.CString(word)
@@ -481,6 +724,16 @@ func (handle *VarnamHandle) Unlearn(word string) error {
 	return handle.checkError(err)
 }
 
+// AddBaseDictionary stack a read-only dictionary below user's learnings
+func (handle *VarnamHandle) AddBaseDictionary(dictPath string, weightScale float64) error {
+	cDictPath := C.CString(dictPath)
+	defer C.free(unsafe.Pointer(cDictPath))
+
+	err := C.varnam_add_base_dictionary(handle.connectionID, cDictPath, C.double(weightScale))
+
+	return handle.checkError(err)
+}
+
 // LearnFromFile learn words from a file
 func (handle *VarnamHandle) LearnFromFile(filePath string) (LearnStatus, error) {
 	var learnStatus LearnStatus
@@ -2,9 +2,12 @@ package govarnamgo
 
 import (
 	"context"
+	"errors"
 	"os"
 	"path"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestSchemeDetails(t *testing.T) {
@@ -30,6 +33,94 @@ func TestTransliterateAdvanced(t *testing.T) {
 	checkError(err)
 
 	assertEqual(t, result.TokenizerSuggestions[0].Word, "നിത്യം")
+	assertEqual(t, result.TokenizerSuggestions[0].Source, VARNAM_SOURCE_TOKENIZER)
+	assertEqual(t, result.GreedyTokenized[0].Source, VARNAM_SOURCE_GREEDY_TOKENIZED)
+	assertEqual(t, result.GreedyTokenized[0].MatchedLength, len("nithyam"))
+
+	if result.GreedyTokenized[0].Score <= 0 || result.GreedyTokenized[0].Score > 1 {
+		t.Errorf("Score %v not in 0-1", result.GreedyTokenized[0].Score)
+	}
+}
+
+func TestTransliterateCancel(t *testing.T) {
+	varnam := getVarnamInstance("ml")
+
+	// Warm up so that DB connections are open already
+	varnam.TransliterateAdvanced(context.Background(), "nithyam")
+
+	// The library has its own Go runtime, count goroutines there
+	baseline := DebugGoroutines()
+
+	for i := 0; i < 50; i++ {
+		// Cancel at different stages, this goes through varnam_cancel
+		ctx, cancel := context.WithTimeout(context.Background(), time.Duration(i*20)*time.Microsecond)
+
+		_, err := varnam.Transliterate(ctx, "nithyam")
+		checkError(err)
+
+		_, err = varnam.TransliterateAdvanced(ctx, "nithyam")
+		checkError(err)
+
+		cancel()
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for DebugGoroutines() > baseline {
+		if time.Now().After(deadline) {
+			t.Fatalf("Goroutines leaked: %d > %d", DebugGoroutines(), baseline)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// Works fine after cancellations
+	result, err := varnam.Transliterate(context.Background(), "nithyam")
+	checkError(err)
+	assertEqual(t, result[0].Word, "നിത്യം")
+}
+
+func TestTransliterateWithDeadline(t *testing.T) {
+	varnam := getVarnamInstance("ml")
+
+	result, err := varnam.TransliterateWithDeadline(context.Background(), "nithyam", time.Now().Add(time.Minute))
+	checkError(err)
+	assertEqual(t, result.MissingStages, 0)
+
+	// Complete result is cached, deadline doesn't matter
+	result, err = varnam.TransliterateWithDeadline(context.Background(), "nithyam", time.Now())
+	checkError(err)
+	assertEqual(t, result.MissingStages, 0)
+
+	varnam.SetCacheSize(0)
+	defer varnam.SetCacheSize(VARNAM_CACHE_SIZE)
+
+	// Deadline passed, only greedy output
+	result, err = varnam.TransliterateWithDeadline(context.Background(), "nithyam", time.Now())
+	checkError(err)
+	assertEqual(t, result.MissingStages, VARNAM_STAGE_DICTIONARY|VARNAM_STAGE_PATTERN_DICTIONARY|VARNAM_STAGE_TOKENIZER)
+	assertEqual(t, result.SkippedStages, 0)
+	assertEqual(t, result.GreedyTokenized[0].Word, "നിത്യം")
+
+	// Too long input is only greedy tokenized
+	result, err = varnam.TransliterateWithDeadline(context.Background(), strings.Repeat("nithyam", 20), time.Now().Add(time.Minute))
+	checkError(err)
+	assertEqual(t, result.MissingStages, 0)
+	assertEqual(t, result.SkippedStages, VARNAM_STAGE_DICTIONARY|VARNAM_STAGE_PATTERN_DICTIONARY|VARNAM_STAGE_TOKENIZER)
+}
+
+func TestCacheStats(t *testing.T) {
+	varnam := getVarnamInstance("ml")
+
+	_, err := varnam.Transliterate(context.Background(), "kerala")
+	checkError(err)
+
+	before := varnam.CacheStats()
+
+	_, err = varnam.Transliterate(context.Background(), "kerala")
+	checkError(err)
+
+	after := varnam.CacheStats()
+	assertEqual(t, after.ResultHits, before.ResultHits+1)
+	assertEqual(t, after.ResultMisses, before.ResultMisses)
 }
 
 func TestReverseTransliterate(t *testing.T) {
@@ -71,6 +162,85 @@ func TestLearn(t *testing.T) {
 	assertEqual(t, result.ExactWords[0].Weight, 12)
 }
 
+func TestLearnErrors(t *testing.T) {
+	varnam := getVarnamInstance("ml")
+
+	err := varnam.Learn("Шаблон", 0)
+	assertEqual(t, errors.Is(err, ErrNothingToLearn), true)
+	assertEqual(t, err.(*VarnamError).ErrorCode, ErrNothingToLearn.ErrorCode)
+
+	assertEqual(t, errors.Is(varnam.Learn("ഉ", 0), ErrSingleConjunct), true)
+	assertEqual(t, errors.Is(varnam.Unlearn("nosuchpattern"), ErrWordNotFound), true)
+}
+
+type testLogger struct {
+	debugs []string
+}
+
+func (l *testLogger) Debug(msg string, args ...interface{}) { l.debugs = append(l.debugs, msg) }
+func (l *testLogger) Info(msg string, args ...interface{})  {}
+func (l *testLogger) Warn(msg string, args ...interface{})  {}
+func (l *testLogger) Error(msg string, args ...interface{}) {}
+
+func TestLogger(t *testing.T) {
+	varnam := getVarnamInstance("ml")
+
+	logger := &testLogger{}
+	varnam.SetLogger(logger)
+
+	varnam.Debug(true)
+	defer varnam.Debug(false)
+
+	varnam.ReverseTransliterate("നിത്യം")
+	tokenized := false
+	for _, msg := range logger.debugs {
+		if strings.HasPrefix(msg, "Tokenized tokens=") {
+			tokenized = true
+		}
+	}
+	assertEqual(t, tokenized, true)
+
+	// Stops logging
+	varnam.SetLogger(nil)
+	count := len(logger.debugs)
+	varnam.ReverseTransliterate("നിത്യം")
+	assertEqual(t, len(logger.debugs), count)
+}
+
+func TestSession(t *testing.T) {
+	varnam := getVarnamInstance("ml")
+	ctx := context.Background()
+
+	session, err := varnam.NewSession()
+	checkError(err)
+	defer session.Close()
+
+	for _, char := range "nithyam" {
+		checkError(session.Append(ctx, string(char)))
+	}
+
+	expected, err := varnam.Transliterate(ctx, "nithyam")
+	checkError(err)
+
+	assertEqual(t, session.Input(), "nithyam")
+	assertEqual(t, session.Preedit(), expected[0].Word)
+	assertEqual(t, len(session.Candidates()), len(expected))
+
+	checkError(session.Backspace(ctx))
+	assertEqual(t, session.Input(), "nithya")
+
+	preedit := session.Preedit()
+
+	word, err := session.Commit(0)
+	checkError(err)
+	assertEqual(t, word, preedit)
+	assertEqual(t, session.Input(), "")
+
+	// Nothing to commit
+	_, err = session.Commit(0)
+	assertEqual(t, err != nil, true)
+}
+
 func TestRecentlyLearnedWords(t *testing.T) {
 	varnam := getVarnamInstance("ml")
 
@@ -97,3 +267,234 @@ func TestSearchSymbolTable(t *testing.T) {
 
 	assertEqual(t, result[0].Value1, "ല")
 }
+
+func TestTokenLattice(t *testing.T) {
+	varnam := getVarnamInstance("ml")
+
+	lattice, err := varnam.TokenLattice(context.Background(), "mala")
+	checkError(err)
+
+	assertEqual(t, len(lattice), 2)
+	assertEqual(t, lattice[1].Input, "la")
+	assertEqual(t, lattice[1].Start, 2)
+	assertEqual(t, lattice[1].End, 4)
+
+	choice := -1
+	for i, alternative := range lattice[1].Alternatives {
+		if alternative.Value == "ള" {
+			choice = i
+		}
+	}
+
+	word, err := varnam.BuildWord(lattice, []int{0, choice})
+	checkError(err)
+	assertEqual(t, word, "മള")
+
+	_, err = varnam.BuildWord(lattice, []int{0, len(lattice[1].Alternatives)})
+	assertEqual(t, err != nil, true)
+}
+
+func TestTokenizerCursor(t *testing.T) {
+	varnam := getVarnamInstance("ml")
+	ctx := context.Background()
+
+	result, err := varnam.TransliterateAdvanced(ctx, "thiruvananthapuram")
+	checkError(err)
+
+	cursor, err := varnam.NewTokenizerCursor(ctx, "thiruvananthapuram")
+	checkError(err)
+
+	first, err := varnam.NextTokenizerSuggestions(cursor, len(result.TokenizerSuggestions))
+	checkError(err)
+	assertEqual(t, len(first), len(result.TokenizerSuggestions))
+	for i, sug := range first {
+		assertEqual(t, sug.Word, result.TokenizerSuggestions[i].Word)
+	}
+
+	more, err := varnam.NextTokenizerSuggestions(cursor, 5)
+	checkError(err)
+	assertEqual(t, len(more), 5)
+
+	checkError(cursor.Close())
+
+	// Closed already
+	_, err = varnam.NextTokenizerSuggestions(cursor, 5)
+	assertEqual(t, err != nil, true)
+}
+
+func TestAcceptSuggestion(t *testing.T) {
+	varnam := getVarnamInstance("ml")
+	ctx := context.Background()
+
+	result, err := varnam.TransliterateAdvanced(ctx, "kalamala")
+	checkError(err)
+
+	shown := result.TokenizerSuggestions
+	checkError(varnam.AcceptSuggestion("kalamala", shown, 2))
+
+	result, err = varnam.TransliterateAdvanced(ctx, "kalamala")
+	checkError(err)
+
+	// Passed over ones are ranked lower
+	assertEqual(t, result.TokenizerSuggestions[0].Word != shown[0].Word, true)
+
+	err = varnam.AcceptSuggestion("kalamala", shown, len(shown))
+	assertEqual(t, err != nil, true)
+}
+
+func TestReachability(t *testing.T) {
+	varnam := getVarnamInstance("ml")
+
+	report, err := varnam.CheckReachability(context.Background(), []string{"മലയാളം", "മലxയാളം"})
+	checkError(err)
+
+	assertEqual(t, report.Total, 2)
+	assertEqual(t, report.Reachable, 1)
+	assertEqual(t, len(report.Unreachable), 1)
+	assertEqual(t, report.Unreachable[0].Word, "മലxയാളം")
+}
+
+func TestTuneSymbolWeights(t *testing.T) {
+	varnam := getVarnamInstance("ml")
+
+	cases := []TuningCase{{"mala", "മല"}, {"kalam", "കളം"}}
+	tunedPath := path.Join(testTempDir, "tuned.vst")
+
+	report, err := varnam.TuneSymbolWeights(context.Background(), cases, tunedPath)
+	checkError(err)
+
+	assertEqual(t, report.Cases, 2)
+	assertEqual(t, report.Top1After >= report.Top1Before, true)
+
+	_, err = os.Stat(tunedPath)
+	checkError(err)
+
+	// Won't overwrite
+	_, err = varnam.TuneSymbolWeights(context.Background(), cases, tunedPath)
+	assertEqual(t, err != nil, true)
+}
+
+func TestGeneratePatterns(t *testing.T) {
+	varnam := getVarnamInstance("ml")
+
+	checkError(varnam.Learn("കമ്പ്യൂട്ടർ", 0))
+
+	status, err := varnam.GeneratePatterns(context.Background(), 3)
+	checkError(err)
+	assertEqual(t, status.Words > 0, true)
+
+	// Casual spelling
+	sugs, err := varnam.Transliterate(context.Background(), "kampyotar")
+	checkError(err)
+	assertEqual(t, sugs[0].Word, "കമ്പ്യൂട്ടർ")
+
+	_, err = varnam.DeleteGeneratedPatterns()
+	checkError(err)
+}
+
+func TestIngestCorpus(t *testing.T) {
+	varnam := getVarnamInstance("ml")
+
+	corpusPath := path.Join(testTempDir, "corpus.txt")
+	checkError(os.WriteFile(corpusPath, []byte("വാർത്ത: തിരുവനന്തപുരം (2021) വാർത്ത"), 0644))
+
+	status, err := varnam.IngestCorpus(context.Background(), []string{corpusPath}, CorpusOptions{MinCount: 2})
+	checkError(err)
+
+	assertEqual(t, status.Files, 1)
+	assertEqual(t, status.Words, 3)
+	assertEqual(t, status.LearnStatus.TotalWords, 1)
+
+	_, err = varnam.IngestCorpus(context.Background(), []string{path.Join(testTempDir, "no-corpus")}, CorpusOptions{})
+	assertEqual(t, err != nil, true)
+}
+
+func TestDictionaryIndex(t *testing.T) {
+	varnam := getVarnamInstance("ml")
+	ctx := context.Background()
+
+	checkError(varnam.SetDictionaryIndex(true))
+	defer varnam.SetDictionaryIndex(false)
+
+	checkError(varnam.Learn("കമലദളം", 0))
+
+	sugs, err := varnam.GetSuggestions(ctx, "കമലദ")
+	checkError(err)
+	assertEqual(t, len(sugs), 1)
+	assertEqual(t, sugs[0].Word, "കമലദളം")
+
+	checkError(varnam.Unlearn("കമലദളം"))
+
+	sugs, err = varnam.GetSuggestions(ctx, "കമലദ")
+	checkError(err)
+	assertEqual(t, len(sugs), 0)
+}
+
+func TestBlocklist(t *testing.T) {
+	varnam := getVarnamInstance("ml")
+	ctx := context.Background()
+
+	checkError(varnam.Learn("കമലദളം", 0))
+	checkError(varnam.BlockWord("കമലദളം"))
+
+	sugs, err := varnam.GetSuggestions(ctx, "കമലദ")
+	checkError(err)
+	assertEqual(t, len(sugs), 0)
+
+	assertEqual(t, errors.Is(varnam.Learn("കമലദളം", 0), ErrWordBlocked), true)
+
+	words, err := varnam.GetBlockedWords(ctx)
+	checkError(err)
+	assertEqual(t, len(words), 1)
+	assertEqual(t, words[0], "കമലദളം")
+
+	checkError(varnam.UnblockWord("കമലദളം"))
+
+	sugs, err = varnam.GetSuggestions(ctx, "കമലദ")
+	checkError(err)
+	assertEqual(t, len(sugs), 1)
+
+	checkError(varnam.Unlearn("കമലദളം"))
+}
+
+func TestShortcuts(t *testing.T) {
+	varnam := getVarnamInstance("ml")
+	ctx := context.Background()
+
+	checkError(varnam.AddShortcut("ty", "നന്ദി, വീണ്ടും വരിക!"))
+
+	result, err := varnam.TransliterateAdvanced(ctx, "ty")
+	checkError(err)
+	assertEqual(t, result.ExactWords[0].Word, "നന്ദി, വീണ്ടും വരിക!")
+	assertEqual(t, result.ExactWords[0].Source, VARNAM_SOURCE_SHORTCUT)
+
+	shortcuts, err := varnam.GetShortcuts(ctx)
+	checkError(err)
+	assertEqual(t, len(shortcuts), 1)
+	assertEqual(t, shortcuts[0], Shortcut{"ty", "നന്ദി, വീണ്ടും വരിക!"})
+
+	exportPath := path.Join(testTempDir, "shortcuts.tsv")
+	checkError(varnam.ExportShortcuts(exportPath))
+	checkError(varnam.RemoveShortcut("ty"))
+
+	status, err := varnam.ImportShortcuts(exportPath)
+	checkError(err)
+	assertEqual(t, status.TotalWords, 1)
+	assertEqual(t, status.FailedWords, 0)
+
+	checkError(varnam.RemoveShortcut("ty"))
+	assertEqual(t, errors.Is(varnam.RemoveShortcut("ty"), ErrWordNotFound), true)
+}
+
+func TestPhoneticKey(t *testing.T) {
+	varnam := getVarnamInstance("ml")
+	ctx := context.Background()
+
+	key, err := varnam.PhoneticKey(ctx, "വാർത്ത")
+	checkError(err)
+	assertEqual(t, key != "", true)
+
+	inputKey, err := varnam.InputPhoneticKey(ctx, "vaartha")
+	checkError(err)
+	assertEqual(t, inputKey, key)
+}
@@ -0,0 +1,122 @@
+package govarnamgo
+
+/**
+ * govarnam - An Indian language transliteration library
+ * Copyright Subin Siby <mail at subinsb (.) com>, 2021
+ * Licensed under AGPL-3.0-only. See LICENSE.txt
+ */
+
+// #cgo pkg-config: govarnam
+// #include "libgovarnam.h"
+// #include "stdlib.h"
+import "C"
+
+import (
+	"context"
+	"unsafe"
+)
+
+// LatticeToken a part of input & what it can be written as
+type LatticeToken struct {
+	// Input characters this token is made from,
+	// Start inclusive & End exclusive (in runes)
+	Start int
+	End   int
+	Input string
+
+	// Values this token can have, most likely first
+	Alternatives []LatticeAlternative
+}
+
+// LatticeAlternative a value a token can have
+type LatticeAlternative struct {
+	Value  string
+	Weight int
+}
+
+// TokenLattice tokens of word with all their alternatives
+func (handle *VarnamHandle) TokenLattice(ctx context.Context, word string) ([]LatticeToken, error) {
+	var lattice []LatticeToken
+
+	operationID := makeContextOperation()
+
+	select {
+	case <-ctx.Done():
+		C.varnam_cancel(operationID)
+		return lattice, ctx.Err()
+	default:
+		cWord := C.CString(word)
+		defer C.free(unsafe.Pointer(cWord))
+
+		var resultPointer *C.varray
+
+		code := C.varnam_token_lattice(handle.connectionID, operationID, cWord, &resultPointer)
+		if code != C.VARNAM_SUCCESS {
+			return lattice, handle.checkError(code)
+		}
+		defer C.destroyLatticeArray(resultPointer)
+
+		for i := 0; i < int(C.varray_length(resultPointer)); i++ {
+			cToken := (*C.LatticeToken)(C.varray_get(resultPointer, C.int(i)))
+
+			token := LatticeToken{
+				Start: int(cToken.Start),
+				End:   int(cToken.End),
+				Input: C.GoString(cToken.Input),
+			}
+
+			for j := 0; j < int(C.varray_length(cToken.Alternatives)); j++ {
+				cAlternative := (*C.LatticeAlternative)(C.varray_get(cToken.Alternatives, C.int(j)))
+
+				token.Alternatives = append(token.Alternatives, LatticeAlternative{
+					Value:  C.GoString(cAlternative.Value),
+					Weight: int(cAlternative.Weight),
+				})
+			}
+
+			lattice = append(lattice, token)
+		}
+
+		return lattice, nil
+	}
+}
+
+// BuildWord make word from lattice with the alternative at
+// choices[i] for lattice[i]. Tokens without a choice get
+// their first alternative.
+func (handle *VarnamHandle) BuildWord(lattice []LatticeToken, choices []int) (string, error) {
+	cLattice := C.varray_init()
+	defer C.destroyLatticeArray(cLattice)
+
+	for _, token := range lattice {
+		cAlternatives := C.varray_init()
+		for _, alternative := range token.Alternatives {
+			cAlternative := C.makeLatticeAlternative(C.CString(alternative.Value), C.int(alternative.Weight))
+			C.varray_push(cAlternatives, unsafe.Pointer(cAlternative))
+		}
+
+		cToken := C.makeLatticeToken(C.int(token.Start), C.int(token.End), C.CString(token.Input), cAlternatives)
+		C.varray_push(cLattice, unsafe.Pointer(cToken))
+	}
+
+	var cChoices *C.int
+	if len(choices) > 0 {
+		cChoices = (*C.int)(C.malloc(C.size_t(len(choices)) * C.size_t(unsafe.Sizeof(C.int(0)))))
+		defer C.free(unsafe.Pointer(cChoices))
+
+		choicesArray := (*[1 << 28]C.int)(unsafe.Pointer(cChoices))[:len(choices):len(choices)]
+		for i, choice := range choices {
+			choicesArray[i] = C.int(choice)
+		}
+	}
+
+	var cWord *C.char
+
+	code := C.varnam_build_word(handle.connectionID, cLattice, cChoices, C.int(len(choices)), &cWord)
+	if code != C.VARNAM_SUCCESS {
+		return "", handle.checkError(code)
+	}
+	defer C.free(unsafe.Pointer(cWord))
+
+	return C.GoString(cWord), nil
+}
@@ -0,0 +1,86 @@
+package govarnamgo
+
+/**
+ * govarnam - An Indian language transliteration library
+ * Copyright Subin Siby <mail at subinsb (.) com>, 2021
+ * Licensed under AGPL-3.0-only. See LICENSE.txt
+ */
+
+// #cgo pkg-config: govarnam
+// #include "libgovarnam.h"
+// extern void govarnamgoLogCallback(int varnamHandleID, int level, char* message);
+import "C"
+
+import (
+	"sync"
+	"unsafe"
+)
+
+// Log levels
+const (
+	VARNAM_LOG_DEBUG = C.VARNAM_LOG_DEBUG
+	VARNAM_LOG_INFO  = C.VARNAM_LOG_INFO
+	VARNAM_LOG_WARN  = C.VARNAM_LOG_WARN
+	VARNAM_LOG_ERROR = C.VARNAM_LOG_ERROR
+)
+
+// Logger receives diagnostics from varnam.
+// *slog.Logger satisfies this, so can be used directly.
+// Messages come with args already added as key=value.
+type Logger interface {
+	Debug(msg string, args ...interface{})
+	Info(msg string, args ...interface{})
+	Warn(msg string, args ...interface{})
+	Error(msg string, args ...interface{})
+}
+
+var loggers = map[C.int]Logger{}
+var loggersMutex = sync.RWMutex{}
+
+//export govarnamgoLogCallback
+func govarnamgoLogCallback(varnamHandleID C.int, level C.int, message *C.char) {
+	loggersMutex.RLock()
+	logger, ok := loggers[varnamHandleID]
+	loggersMutex.RUnlock()
+
+	if !ok {
+		return
+	}
+
+	msg := C.GoString(message)
+
+	switch {
+	case level >= VARNAM_LOG_ERROR:
+		logger.Error(msg)
+	case level >= VARNAM_LOG_WARN:
+		logger.Warn(msg)
+	case level >= VARNAM_LOG_INFO:
+		logger.Info(msg)
+	default:
+		logger.Debug(msg)
+	}
+}
+
+// SetLogger logger for this instance. nil to stop logging.
+func (handle *VarnamHandle) SetLogger(logger Logger) {
+	loggersMutex.Lock()
+	if logger == nil {
+		delete(loggers, handle.connectionID)
+	} else {
+		loggers[handle.connectionID] = logger
+	}
+	loggersMutex.Unlock()
+
+	if logger == nil {
+		C.varnam_set_log_callback(handle.connectionID, nil)
+	} else {
+		C.varnam_set_log_callback(handle.connectionID, C.VarnamLogCallback(unsafe.Pointer(C.govarnamgoLogCallback)))
+	}
+}
+
+func (handle *VarnamHandle) getLogger() Logger {
+	loggersMutex.RLock()
+	defer loggersMutex.RUnlock()
+
+	return loggers[handle.connectionID]
+}
@@ -0,0 +1,71 @@
+package govarnamgo
+
+/**
+ * govarnam - An Indian language transliteration library
+ * Copyright Subin Siby <mail at subinsb (.) com>, 2021
+ * Licensed under AGPL-3.0-only. See LICENSE.txt
+ */
+
+// #cgo pkg-config: govarnam
+// #include "libgovarnam.h"
+import "C"
+
+import (
+	"context"
+)
+
+// PatternGenerationStatus result of GeneratePatterns()
+type PatternGenerationStatus struct {
+	Words int
+
+	// Patterns added, ones already there aren't counted
+	Patterns int
+}
+
+// GeneratePatterns add upto perWord likely patterns of each
+// learnt word to patterns dictionary so that they can be
+// found by typing those. They're marked as generated,
+// see DeleteGeneratedPatterns().
+func (handle *VarnamHandle) GeneratePatterns(ctx context.Context, perWord int) (PatternGenerationStatus, error) {
+	var status PatternGenerationStatus
+
+	type cResult struct {
+		code     C.int
+		words    C.int
+		patterns C.int
+	}
+
+	operationID := makeContextOperation()
+	// Buffered so that the C call can finish even if we stop waiting
+	channel := make(chan cResult, 1)
+
+	go func() {
+		var result cResult
+		result.code = C.varnam_generate_patterns(handle.connectionID, operationID, C.int(perWord), &result.words, &result.patterns)
+		channel <- result
+	}()
+
+	select {
+	case <-ctx.Done():
+		C.varnam_cancel(operationID)
+
+		// Learnings can't be used till the call returns
+		<-channel
+		return status, ctx.Err()
+	case result := <-channel:
+		status.Words = int(result.words)
+		status.Patterns = int(result.patterns)
+
+		return status, handle.checkError(result.code)
+	}
+}
+
+// DeleteGeneratedPatterns remove patterns added by
+// GeneratePatterns() that weren't trained since
+func (handle *VarnamHandle) DeleteGeneratedPatterns() (int, error) {
+	var deleted C.int
+
+	err := handle.checkError(C.varnam_delete_generated_patterns(handle.connectionID, &deleted))
+
+	return int(deleted), err
+}
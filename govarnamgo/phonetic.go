@@ -0,0 +1,58 @@
+package govarnamgo
+
+/**
+ * govarnam - An Indian language transliteration library
+ * Copyright Subin Siby <mail at subinsb (.) com>, 2021
+ * Licensed under AGPL-3.0-only. See LICENSE.txt
+ */
+
+// #cgo pkg-config: govarnam
+// #include "libgovarnam.h"
+// #include "stdlib.h"
+import "C"
+
+import (
+	"context"
+	"unsafe"
+)
+
+type phoneticKeyFunc func(varnamHandleID C.int, id C.int, text *C.char, key **C.char) C.int
+
+// PhoneticKey key of a native word to match with
+// keys of Latin inputs, see InputPhoneticKey()
+func (handle *VarnamHandle) PhoneticKey(ctx context.Context, word string) (string, error) {
+	return handle.phoneticKey(ctx, word, func(varnamHandleID C.int, id C.int, text *C.char, key **C.char) C.int {
+		return C.varnam_phonetic_key(varnamHandleID, id, text, key)
+	})
+}
+
+// InputPhoneticKey key of a Latin input, same as
+// PhoneticKey() of the word meant by it
+func (handle *VarnamHandle) InputPhoneticKey(ctx context.Context, input string) (string, error) {
+	return handle.phoneticKey(ctx, input, func(varnamHandleID C.int, id C.int, text *C.char, key **C.char) C.int {
+		return C.varnam_input_phonetic_key(varnamHandleID, id, text, key)
+	})
+}
+
+func (handle *VarnamHandle) phoneticKey(ctx context.Context, text string, makeKey phoneticKeyFunc) (string, error) {
+	cText := C.CString(text)
+	defer C.free(unsafe.Pointer(cText))
+
+	operationID := makeContextOperation()
+
+	select {
+	case <-ctx.Done():
+		C.varnam_cancel(operationID)
+		return "", ctx.Err()
+	default:
+		var cKey *C.char
+
+		code := makeKey(handle.connectionID, operationID, cText, &cKey)
+		if code != C.VARNAM_SUCCESS {
+			return "", handle.checkError(code)
+		}
+		defer C.free(unsafe.Pointer(cKey))
+
+		return C.GoString(cKey), nil
+	}
+}
@@ -0,0 +1,116 @@
+package govarnamgo
+
+/**
+ * govarnam - An Indian language transliteration library
+ * Copyright Subin Siby <mail at subinsb (.) com>, 2021
+ * Licensed under AGPL-3.0-only. See LICENSE.txt
+ */
+
+// #cgo pkg-config: govarnam
+// #include "libgovarnam.h"
+// #include "stdlib.h"
+import "C"
+
+import (
+	"context"
+	"unsafe"
+)
+
+// UnreachableWord a word that can't be made by typing in the scheme
+type UnreachableWord struct {
+	Word string
+
+	// The conjunct which can't be made
+	Conjunct string
+
+	// Pattern tried, empty if there was none
+	Pattern string
+}
+
+// ReachabilityReport how many of the words can be typed
+type ReachabilityReport struct {
+	Total       int
+	Reachable   int
+	Unreachable []UnreachableWord
+}
+
+// Coverage fraction of words that can be typed
+func (report ReachabilityReport) Coverage() float64 {
+	if report.Total == 0 {
+		return 0
+	}
+	return float64(report.Reachable) / float64(report.Total)
+}
+
+// CheckReachability find which of the words can't be made by typing
+func (handle *VarnamHandle) CheckReachability(ctx context.Context, words []string) (ReachabilityReport, error) {
+	cWords := C.varray_init()
+	defer C.destroyStringsArray(cWords)
+
+	for _, word := range words {
+		C.varray_push(cWords, unsafe.Pointer(C.CString(word)))
+	}
+
+	return handle.checkReachability(ctx, cWords)
+}
+
+// CheckDictionaryReachability CheckReachability() of all learnt words
+func (handle *VarnamHandle) CheckDictionaryReachability(ctx context.Context) (ReachabilityReport, error) {
+	return handle.checkReachability(ctx, nil)
+}
+
+func (handle *VarnamHandle) checkReachability(ctx context.Context, cWords *C.varray) (ReachabilityReport, error) {
+	var report ReachabilityReport
+
+	type cResult struct {
+		code        C.int
+		total       C.int
+		reachable   C.int
+		unreachable *C.varray
+	}
+
+	operationID := makeContextOperation()
+	// Buffered so that the C call can finish even if we stop waiting
+	channel := make(chan cResult, 1)
+
+	go func() {
+		var result cResult
+		result.code = C.varnam_check_reachability(handle.connectionID, operationID, cWords, &result.total, &result.reachable, &result.unreachable)
+		channel <- result
+	}()
+
+	var result cResult
+
+	select {
+	case <-ctx.Done():
+		C.varnam_cancel(operationID)
+
+		// Words can't be freed till the call returns
+		result = <-channel
+		if result.code == C.VARNAM_SUCCESS {
+			C.destroyUnreachableWordsArray(result.unreachable)
+		}
+		return report, ctx.Err()
+	case result = <-channel:
+	}
+
+	if result.code != C.VARNAM_SUCCESS {
+		return report, handle.checkError(result.code)
+	}
+	defer C.destroyUnreachableWordsArray(result.unreachable)
+
+	report.Total = int(result.total)
+	report.Reachable = int(result.reachable)
+
+	for i := 0; i < int(C.varray_length(result.unreachable)); i++ {
+		cWord := (*C.UnreachableWord)(C.varray_get(result.unreachable, C.int(i)))
+
+		report.Unreachable = append(report.Unreachable, UnreachableWord{
+			Word:     C.GoString(cWord.Word),
+			Conjunct: C.GoString(cWord.Conjunct),
+			Pattern:  C.GoString(cWord.Pattern),
+		})
+	}
+
+	return report, nil
+}
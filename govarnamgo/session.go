@@ -0,0 +1,148 @@
+package govarnamgo
+
+/**
+ * govarnam - An Indian language transliteration library
+ * Copyright Subin Siby <mail at subinsb (.) com>, 2021
+ * Licensed under AGPL-3.0-only. See LICENSE.txt
+ */
+
+// #cgo pkg-config: govarnam
+// #include "libgovarnam.h"
+// #include "stdlib.h"
+import "C"
+
+import (
+	"context"
+	"unsafe"
+)
+
+// Session composition state of an input method.
+// Feed keys with Append() & Backspace(), show Preedit() &
+// Candidates(), give the chosen one to Commit().
+type Session struct {
+	handle    *VarnamHandle
+	sessionID C.int
+}
+
+// NewSession start a composition
+func (handle *VarnamHandle) NewSession() (*Session, error) {
+	var sessionID C.int
+
+	err := handle.checkError(C.varnam_session_new(handle.connectionID, &sessionID))
+	if err != nil {
+		return nil, err
+	}
+
+	return &Session{handle, sessionID}, nil
+}
+
+// Run a cancellable session operation
+func (session *Session) run(ctx context.Context, operation func(operationID C.int) C.int) error {
+	operationID := makeContextOperation()
+	// Buffered so that the C call can finish even if we stop waiting
+	channel := make(chan C.int, 1)
+
+	go func() {
+		channel <- operation(operationID)
+	}()
+
+	select {
+	case <-ctx.Done():
+		C.varnam_cancel(operationID)
+
+		// Session can't be used till the call returns
+		<-channel
+		return ctx.Err()
+	case code := <-channel:
+		return session.handle.checkError(code)
+	}
+}
+
+// Append text typed to the input & update candidates
+func (session *Session) Append(ctx context.Context, text string) error {
+	cText := C.CString(text)
+	defer C.free(unsafe.Pointer(cText))
+
+	return session.run(ctx, func(operationID C.int) C.int {
+		return C.varnam_session_append(session.sessionID, operationID, cText)
+	})
+}
+
+// Backspace remove the last conjunct from input & update candidates
+func (session *Session) Backspace(ctx context.Context) error {
+	return session.run(ctx, func(operationID C.int) C.int {
+		return C.varnam_session_backspace(session.sessionID, operationID)
+	})
+}
+
+// Input text typed so far
+func (session *Session) Input() string {
+	var cInput *C.char
+
+	if C.varnam_session_input(session.sessionID, &cInput) != C.VARNAM_SUCCESS {
+		return ""
+	}
+	defer C.free(unsafe.Pointer(cInput))
+
+	return C.GoString(cInput)
+}
+
+// Preedit text to show while composing
+func (session *Session) Preedit() string {
+	var cPreedit *C.char
+
+	if C.varnam_session_preedit(session.sessionID, &cPreedit) != C.VARNAM_SUCCESS {
+		return ""
+	}
+	defer C.free(unsafe.Pointer(cPreedit))
+
+	return C.GoString(cPreedit)
+}
+
+// Candidates suggestions for the input
+func (session *Session) Candidates() []Suggestion {
+	var result []Suggestion
+
+	var resultPointer *C.varray
+
+	if C.varnam_session_candidates(session.sessionID, &resultPointer) != C.VARNAM_SUCCESS {
+		return result
+	}
+
+	i := 0
+	for i < int(C.varray_length(resultPointer)) {
+		cSug := (*C.Suggestion)(C.varray_get(resultPointer, C.int(i)))
+		result = append(result, makeSuggestion(cSug))
+		i++
+	}
+
+	go C.destroySuggestionsArray(resultPointer)
+
+	return result
+}
+
+// Commit the candidate at index, it gets learnt.
+// The word is returned even if learning failed.
+func (session *Session) Commit(index int) (string, error) {
+	var cWord *C.char
+
+	code := C.varnam_session_commit(session.sessionID, C.int(index), &cWord)
+
+	var word string
+	if cWord != nil {
+		word = C.GoString(cWord)
+		C.free(unsafe.Pointer(cWord))
+	}
+
+	return word, session.handle.checkError(code)
+}
+
+// Reset clear input without learning anything
+func (session *Session) Reset() {
+	C.varnam_session_reset(session.sessionID)
+}
+
+// Close free the session
+func (session *Session) Close() error {
+	return session.handle.checkError(C.varnam_session_close(session.sessionID))
+}
@@ -0,0 +1,106 @@
+package govarnamgo
+
+/**
+ * govarnam - An Indian language transliteration library
+ * Copyright Subin Siby <mail at subinsb (.) com>, 2021
+ * Licensed under AGPL-3.0-only. See LICENSE.txt
+ */
+
+// #cgo pkg-config: govarnam
+// #include "libgovarnam.h"
+// #include "stdlib.h"
+import "C"
+
+import (
+	"context"
+	"unsafe"
+)
+
+// Shortcut input typed as is which gives a phrase
+type Shortcut struct {
+	Input  string
+	Phrase string
+}
+
+// AddShortcut suggest phrase first when input is typed.
+// Phrase of an existing shortcut with the input is replaced.
+func (handle *VarnamHandle) AddShortcut(input string, phrase string) error {
+	cInput := C.CString(input)
+	defer C.free(unsafe.Pointer(cInput))
+
+	cPhrase := C.CString(phrase)
+	defer C.free(unsafe.Pointer(cPhrase))
+
+	return handle.checkError(C.varnam_add_shortcut(handle.connectionID, cInput, cPhrase))
+}
+
+// RemoveShortcut remove shortcut with input
+func (handle *VarnamHandle) RemoveShortcut(input string) error {
+	cInput := C.CString(input)
+	defer C.free(unsafe.Pointer(cInput))
+
+	return handle.checkError(C.varnam_remove_shortcut(handle.connectionID, cInput))
+}
+
+// GetShortcuts all shortcuts sorted by input
+func (handle *VarnamHandle) GetShortcuts(ctx context.Context) ([]Shortcut, error) {
+	var result []Shortcut
+
+	operationID := makeContextOperation()
+
+	select {
+	case <-ctx.Done():
+		C.varnam_cancel(operationID)
+		return result, ctx.Err()
+	default:
+		var resultPointer *C.varray
+
+		code := C.varnam_get_shortcuts(handle.connectionID, operationID, &resultPointer)
+		if code != C.VARNAM_SUCCESS {
+			return result, handle.checkError(code)
+		}
+		defer C.destroyShortcutsArray(resultPointer)
+
+		for i := 0; i < int(C.varray_length(resultPointer)); i++ {
+			cShortcut := (*C.Shortcut)(C.varray_get(resultPointer, C.int(i)))
+
+			result = append(result, Shortcut{
+				Input:  C.GoString(cShortcut.Input),
+				Phrase: C.GoString(cShortcut.Phrase),
+			})
+		}
+
+		return result, nil
+	}
+}
+
+// ExportShortcuts write shortcuts to a file, a shortcut
+// per line with input & phrase separated by a tab
+func (handle *VarnamHandle) ExportShortcuts(filePath string) error {
+	cFilePath := C.CString(filePath)
+	defer C.free(unsafe.Pointer(cFilePath))
+
+	return handle.checkError(C.varnam_export_shortcuts(handle.connectionID, cFilePath))
+}
+
+// ImportShortcuts add shortcuts in a file made by ExportShortcuts()
+func (handle *VarnamHandle) ImportShortcuts(filePath string) (LearnStatus, error) {
+	var learnStatus LearnStatus
+
+	cFilePath := C.CString(filePath)
+	defer C.free(unsafe.Pointer(cFilePath))
+
+	var resultPointer *C.LearnStatus
+
+	code := C.varnam_import_shortcuts(handle.connectionID, cFilePath, &resultPointer)
+	if code != C.VARNAM_SUCCESS {
+		return learnStatus, handle.checkError(code)
+	}
+
+	learnStatus = LearnStatus{
+		int((*resultPointer).TotalWords),
+		int((*resultPointer).FailedWords),
+	}
+
+	return learnStatus, nil
+}
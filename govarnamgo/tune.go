@@ -0,0 +1,141 @@
+package govarnamgo
+
+/**
+ * govarnam - An Indian language transliteration library
+ * Copyright Subin Siby <mail at subinsb (.) com>, 2021
+ * Licensed under AGPL-3.0-only. See LICENSE.txt
+ */
+
+// #cgo pkg-config: govarnam
+// #include "libgovarnam.h"
+// #include "stdlib.h"
+import "C"
+
+import (
+	"context"
+	"unsafe"
+)
+
+// TuningCase a pattern & the word expected for it
+type TuningCase struct {
+	Pattern string
+	Word    string
+}
+
+// TunedSymbol a symbol of VST with the weight &
+// priority tuning found better for it
+type TunedSymbol struct {
+	Symbol   Symbol
+	Weight   int
+	Priority int
+}
+
+// TuningReport result of TuneSymbolWeights()
+type TuningReport struct {
+	Cases int
+
+	// Cases where the expected word was the
+	// first tokenizer suggestion
+	Top1Before int
+	Top1After  int
+
+	Tuned []TunedSymbol
+}
+
+// AccuracyBefore share of cases right before tuning
+func (report TuningReport) AccuracyBefore() float64 {
+	if report.Cases == 0 {
+		return 0
+	}
+	return float64(report.Top1Before) / float64(report.Cases)
+}
+
+// AccuracyAfter share of cases right after tuning
+func (report TuningReport) AccuracyAfter() float64 {
+	if report.Cases == 0 {
+		return 0
+	}
+	return float64(report.Top1After) / float64(report.Cases)
+}
+
+// TuneSymbolWeights find weights & priorities of symbols that make
+// the expected word the first tokenizer suggestion more often.
+// Trained patterns are used if cases is nil. A copy of VST with
+// the tuned weights is written to outputVSTPath if it's not empty.
+func (handle *VarnamHandle) TuneSymbolWeights(ctx context.Context, cases []TuningCase, outputVSTPath string) (TuningReport, error) {
+	var report TuningReport
+
+	var cPatterns, cWords *C.varray
+	if cases != nil {
+		cPatterns = C.varray_init()
+		defer C.destroyStringsArray(cPatterns)
+
+		cWords = C.varray_init()
+		defer C.destroyStringsArray(cWords)
+
+		for _, tuningCase := range cases {
+			C.varray_push(cPatterns, unsafe.Pointer(C.CString(tuningCase.Pattern)))
+			C.varray_push(cWords, unsafe.Pointer(C.CString(tuningCase.Word)))
+		}
+	}
+
+	var cOutputVSTPath *C.char
+	if outputVSTPath != "" {
+		cOutputVSTPath = C.CString(outputVSTPath)
+		defer C.free(unsafe.Pointer(cOutputVSTPath))
+	}
+
+	type cResult struct {
+		code       C.int
+		cases      C.int
+		top1Before C.int
+		top1After  C.int
+		tuned      *C.varray
+	}
+
+	operationID := makeContextOperation()
+	// Buffered so that the C call can finish even if we stop waiting
+	channel := make(chan cResult, 1)
+
+	go func() {
+		var result cResult
+		result.code = C.varnam_tune_symbol_weights(handle.connectionID, operationID, cPatterns, cWords, cOutputVSTPath, &result.cases, &result.top1Before, &result.top1After, &result.tuned)
+		channel <- result
+	}()
+
+	var result cResult
+
+	select {
+	case <-ctx.Done():
+		C.varnam_cancel(operationID)
+
+		// Cases can't be freed till the call returns
+		result = <-channel
+		if result.code == C.VARNAM_SUCCESS {
+			C.destroyTunedSymbolsArray(result.tuned)
+		}
+		return report, ctx.Err()
+	case result = <-channel:
+	}
+
+	if result.code != C.VARNAM_SUCCESS {
+		return report, handle.checkError(result.code)
+	}
+	defer C.destroyTunedSymbolsArray(result.tuned)
+
+	report.Cases = int(result.cases)
+	report.Top1Before = int(result.top1Before)
+	report.Top1After = int(result.top1After)
+
+	for i := 0; i < int(C.varray_length(result.tuned)); i++ {
+		cTuned := (*C.TunedSymbol)(C.varray_get(result.tuned, C.int(i)))
+
+		report.Tuned = append(report.Tuned, TunedSymbol{
+			Symbol:   makeGoSymbol(cTuned.Symbol),
+			Weight:   int(cTuned.Weight),
+			Priority: int(cTuned.Priority),
+		})
+	}
+
+	return report, nil
+}